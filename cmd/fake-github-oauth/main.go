@@ -0,0 +1,130 @@
+// Command fake-github-oauth is a stand-in for GitHub's OAuth web flow, for
+// trying the "Connect GitHub" flow locally without registering an OAuth app.
+// Every authorization is approved at once and tokens belong to FAKE_GITHUB_LOGIN.
+//
+//	go run ./cmd/fake-github-oauth
+//	GITHUB_OAUTH_CLIENT_ID=fake GITHUB_OAUTH_CLIENT_SECRET=fake \
+//	GITHUB_OAUTH_URL=http://localhost:9091 GITHUB_OAUTH_API_URL=http://localhost:9091 \
+//	TOKEN_ENCRYPTION_KEY=dev go run .
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+type fakeServer struct {
+	login string
+
+	mu     sync.Mutex
+	codes  map[string]string // authorization code -> redirect_uri
+	tokens map[string]bool
+}
+
+func main() {
+	port := os.Getenv("FAKE_GITHUB_PORT")
+	if port == "" {
+		port = "9091"
+	}
+	login := os.Getenv("FAKE_GITHUB_LOGIN")
+	if login == "" {
+		login = "octocat"
+	}
+
+	server := &fakeServer{
+		login:  login,
+		codes:  make(map[string]string),
+		tokens: make(map[string]bool),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /login/oauth/authorize", server.handleAuthorize)
+	mux.HandleFunc("POST /login/oauth/access_token", server.handleAccessToken)
+	mux.HandleFunc("GET /user", server.handleUser)
+
+	log.Printf("Fake GitHub OAuth server listening on port %s, authorizing as %s", port, login)
+	log.Fatal(http.ListenAndServe(":"+port, mux))
+}
+
+// handleAuthorize approves the authorization and redirects back with a code
+func (s *fakeServer) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	redirectURI := r.URL.Query().Get("redirect_uri")
+	target, err := url.Parse(redirectURI)
+	if err != nil || redirectURI == "" {
+		http.Error(w, "redirect_uri is required", http.StatusBadRequest)
+		return
+	}
+
+	code := randomHex()
+	s.mu.Lock()
+	s.codes[code] = redirectURI
+	s.mu.Unlock()
+
+	query := target.Query()
+	query.Set("code", code)
+	query.Set("state", r.URL.Query().Get("state"))
+	target.RawQuery = query.Encode()
+	log.Printf("Approved authorization for client %s, redirecting to %s", r.URL.Query().Get("client_id"), redirectURI)
+	http.Redirect(w, r, target.String(), http.StatusFound)
+}
+
+// handleAccessToken exchanges a code for a token, answering errors the way GitHub does
+func (s *fakeServer) handleAccessToken(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	code := r.FormValue("code")
+
+	s.mu.Lock()
+	redirectURI, ok := s.codes[code]
+	delete(s.codes, code)
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if !ok || redirectURI != r.FormValue("redirect_uri") {
+		json.NewEncoder(w).Encode(map[string]string{
+			"error":             "bad_verification_code",
+			"error_description": "The code passed is incorrect or expired.",
+		})
+		return
+	}
+
+	token := "gho_" + randomHex()
+	s.mu.Lock()
+	s.tokens[token] = true
+	s.mu.Unlock()
+
+	json.NewEncoder(w).Encode(map[string]string{
+		"access_token": token,
+		"token_type":   "bearer",
+		"scope":        "repo",
+	})
+}
+
+// handleUser returns the account owning the bearer token
+func (s *fakeServer) handleUser(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	s.mu.Lock()
+	ok := s.tokens[token]
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Bad credentials"})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{"login": s.login, "id": 1, "type": "User"})
+}
+
+func randomHex() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,67 @@
+// Command pr-describe writes a pull request description for a branch of a
+// local git repository, before it is pushed anywhere. It uses the LLM provider
+// configured for the server (LLM_PROVIDER, OPENAI_API_KEY, ...) and prints the
+// description to standard output.
+//
+//	go run ./cmd/pr-describe -repo . -base main
+//	go run ./cmd/pr-describe -repo ../service -base origin/main -head feature/login -model gpt-4o
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+
+	"github.com/joho/godotenv"
+	"github.com/nahue/pr-toolbox-go/internal/llm"
+	"github.com/nahue/pr-toolbox-go/internal/localgit"
+)
+
+func main() {
+	repoPath := flag.String("repo", ".", "path of the git repository")
+	base := flag.String("base", "main", "branch or commit the changes are compared against")
+	head := flag.String("head", "HEAD", "branch or commit to describe")
+	model := flag.String("model", "", "model to use (default: the provider's default model)")
+	flag.Parse()
+
+	log.SetFlags(0)
+	log.SetPrefix("pr-describe: ")
+
+	// Load environment variables from .env file, if there is one
+	godotenv.Load()
+
+	provider, err := llm.NewProviderFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to initialize LLM provider: %v", err)
+	}
+	llmService := llm.NewService(provider)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	settings := llmService.DefaultSettings()
+	if *model != "" {
+		if err := llmService.ValidateModel(ctx, *model); err != nil {
+			log.Fatalf("Invalid model: %v", err)
+		}
+		settings.Model = *model
+	}
+
+	// The command line reads whatever repository it is pointed at
+	prData, err := localgit.NewService(nil).FetchChangeRequest(ctx, localgit.Request{RepoPath: *repoPath, Base: *base, Head: *head})
+	if err != nil {
+		log.Fatalf("Failed to read %s: %v", *repoPath, err)
+	}
+	log.Printf("Describing %d commits changing %d files with %s (%s)", len(prData.Commits), prData.TotalChangedFiles, provider.Name(), settings.Model)
+
+	generation, err := llmService.GeneratePRDescription(ctx, prData, settings, func(done, total int) {
+		log.Printf("%d/%d stages done", done, total)
+	})
+	if err != nil {
+		log.Fatalf("Failed to generate description: %v", err)
+	}
+	fmt.Println(generation.Description)
+}
@@ -11,12 +11,12 @@ require (
 	github.com/a-h/templ v0.3.924
 	github.com/google/go-github/v62 v62.0.0
 	github.com/joho/godotenv v1.5.1
+	github.com/mattn/go-sqlite3 v1.14.30
 	github.com/sashabaranov/go-openai v1.40.5
 )
 
 require (
 	github.com/google/go-querystring v1.1.0 // indirect
-	github.com/mattn/go-sqlite3 v1.14.30 // indirect
 	github.com/mfridman/interpolate v0.0.2 // indirect
 	github.com/pressly/goose/v3 v3.24.3 // indirect
 	github.com/sethvargo/go-retry v0.3.0 // indirect
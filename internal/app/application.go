@@ -5,30 +5,51 @@ import (
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
 	"github.com/nahue/pr-toolbox-go/internal/database"
-	githubsvc "github.com/nahue/pr-toolbox-go/internal/github"
-	"github.com/nahue/pr-toolbox-go/internal/openai"
+	"github.com/nahue/pr-toolbox-go/internal/llm"
+	"github.com/nahue/pr-toolbox-go/internal/localgit"
 )
 
 // Application holds all the services and dependencies
 type Application struct {
 	db            *database.Database
-	openaiService *openai.Service
-	githubService *githubsvc.Service
+	llmService    *llm.Service
+	codeHosts     *codehost.Registry // GitHub, GitLab, ... routed by host
 	router        *chi.Mux
 	useAuth       bool
+	webhookSecret []byte            // verifies GitHub webhook deliveries; webhooks are disabled when empty
+	webhookJobs   chan webhookJob   // accepted deliveries waiting for a worker
+	githubOAuth   *githubOAuth      // nil unless users can connect their GitHub account
+	localGit      *localgit.Service // nil unless LOCAL_GIT_ROOTS allows describing local repositories
 }
 
 type GeneratePRDescriptionRequest struct {
-	PRUrl string `json:"prUrl"`
+	PRUrl      string `json:"prUrl"`
+	Regenerate bool   `json:"regenerate"` // ignore a cached description for the same PR head
+}
+
+// GeneratePRDescriptionResponse is a stored description, flagged when it was served from the cache
+type GeneratePRDescriptionResponse struct {
+	*database.PRDescription
+	Cached bool `json:"cached"`
+}
+
+// PRRefResponse is a parsed pull request reference, as shown by the form before it is submitted
+type PRRefResponse struct {
+	codehost.Ref
+	Name     string `json:"name"` // owner/repo#123, prefixed with the host outside github.com
+	URL      string `json:"url"`
+	Provider string `json:"provider"` // GitHub, GitLab, ...
 }
 
 // NewApplication creates a new application instance with all dependencies
-func NewApplication(db *database.Database, openaiService *openai.Service, githubService *githubsvc.Service) *Application {
+func NewApplication(db *database.Database, llmService *llm.Service, codeHosts *codehost.Registry) *Application {
 	// Check if authentication is enabled via environment variable
 	useAuth := true // default to true for security
 	if useAuthStr := os.Getenv("USE_AUTH"); useAuthStr != "" {
@@ -43,12 +64,39 @@ func NewApplication(db *database.Database, openaiService *openai.Service, github
 		log.Println("Warning: Authentication is DISABLED. This should only be used for development.")
 	}
 
+	webhookSecret := os.Getenv("GITHUB_WEBHOOK_SECRET")
+	if webhookSecret == "" {
+		log.Println("GITHUB_WEBHOOK_SECRET not provided, GitHub webhooks are disabled")
+	}
+
+	githubOAuth, err := newGitHubOAuthFromEnv()
+	if err != nil {
+		log.Printf("Warning: %v, connecting GitHub accounts is disabled", err)
+	} else if githubOAuth == nil {
+		log.Println("GITHUB_OAUTH_CLIENT_ID not provided, connecting GitHub accounts is disabled")
+	}
+
+	var localGit *localgit.Service
+	if roots := localgit.RootsFromEnv(); len(roots) > 0 {
+		localGit = localgit.NewService(roots)
+		log.Printf("Local repositories under %s can be described", strings.Join(roots, ", "))
+	} else {
+		log.Println("LOCAL_GIT_ROOTS not provided, describing local repositories is disabled")
+	}
+
 	app := &Application{
 		db:            db,
-		openaiService: openaiService,
-		githubService: githubService,
+		llmService:    llmService,
+		codeHosts:     codeHosts,
 		router:        chi.NewRouter(),
 		useAuth:       useAuth,
+		webhookSecret: []byte(webhookSecret),
+		githubOAuth:   githubOAuth,
+		localGit:      localGit,
+	}
+
+	if len(app.webhookSecret) > 0 {
+		app.startWebhookWorkers()
 	}
 
 	app.setupMiddleware()
@@ -84,14 +132,40 @@ func (app *Application) setupRoutes() {
 	app.router.Get("/auth/verify", app.handleMagicLinkVerification)
 	app.router.Post("/auth/logout", app.handleLogout)
 	app.router.Get("/auth/me", app.handleCurrentUser)
+	app.router.Get("/auth/github/callback", app.handleGitHubCallback)
+
+	// Webhook routes (public, authenticated by signature)
+	app.router.Post("/webhooks/github", app.handleGitHubWebhook)
 
 	// Apply auth middleware to protected routes
 	app.router.Group(func(r chi.Router) {
 		r.Use(app.authMiddleware)
+		r.Use(app.githubUserMiddleware)
 
 		// Protected routes
+		r.Get("/auth/github/connect", app.handleGitHubConnect)
+		r.Post("/auth/github/disconnect", app.handleGitHubDisconnect)
 		r.Get("/", app.servePrDescriptions)
 		r.Post("/api/generate-pr-description", app.generatePRDescription)
+		r.Get("/api/generate-pr-description/stream", app.streamPRDescription)
+		r.Get("/api/pr-ref", app.handleParsePRRef)
+		r.Post("/api/generate-local-description", app.generateLocalDescription)
+		r.Post("/api/generate-compare-description", app.generateCompareDescription)
+		r.Get("/release-notes", app.handleReleaseNotesPage)
+		r.Post("/api/generate-release-notes", app.generateReleaseNotes)
+		r.Get("/review", app.handleReviewPage)
+		r.Post("/api/generate-review", app.generateReview)
+		r.Post("/api/submit-review", app.handleSubmitReview)
+		r.Get("/history", app.handleHistoryPage)
+		r.Get("/api/pr-descriptions", app.handleListHistory)
+		r.Get("/api/pr-descriptions/{id}/apply", app.handleApplyPreview)
+		r.Post("/api/pr-descriptions/{id}/apply", app.handleApply)
+		r.Post("/api/pr-descriptions/{id}/draft-pull-request", app.handleCreateDraftPullRequest)
+		r.Post("/api/refresh-pr-description", app.handleRefreshPRDescription)
+		r.Get("/settings", app.handleSettingsPage)
+		r.Get("/api/settings", app.handleGetSettings)
+		r.Post("/api/settings", app.handleSaveSettings)
+		r.Post("/api/settings/webhooks", app.handleSaveWebhookSettings)
 	})
 }
 
@@ -104,4 +178,13 @@ func (app *Application) Start(port string) error {
 // HTTP Handlers moved to separate files:
 // - auth_handlers.go for authentication routes
 // - pr_handlers.go for PR description routes
+// - local_handlers.go for branches of local repositories
+// - compare_handlers.go for branch comparisons and draft pull requests
+// - release_handlers.go for release notes
+// - review_handlers.go for line-level reviews
+// - history_handlers.go for stored PR descriptions
+// - apply_handlers.go for writing descriptions back to GitHub
+// - webhook_handlers.go for GitHub webhook deliveries
+// - github_oauth.go for connecting users' GitHub accounts
+// - settings_handlers.go for generation settings
 // - health_handlers.go for health check routes
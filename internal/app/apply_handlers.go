@@ -0,0 +1,339 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+	"github.com/nahue/pr-toolbox-go/internal/database"
+	"github.com/nahue/pr-toolbox-go/internal/textdiff"
+	"github.com/nahue/pr-toolbox-go/templates"
+)
+
+// Modes for writing a stored description to its pull request. Either way the
+// description is written between the managed-region markers, so later updates
+// only replace that region.
+const (
+	applyModeReplace = "replace" // the generated region replaces the whole PR body
+	applyModeMerge   = "merge"   // only the generated region is replaced, or appended when missing
+)
+
+// ApplyRequest is the body of POST /api/pr-descriptions/{id}/apply.
+// ExpectedUpdatedAt is the PR's updated_at from the preview; the write is
+// rejected when the PR has changed since.
+type ApplyRequest struct {
+	Mode              string    `json:"mode"`
+	ExpectedUpdatedAt time.Time `json:"expected_updated_at"`
+}
+
+// ApplyPreviewResponse is returned by GET /api/pr-descriptions/{id}/apply
+type ApplyPreviewResponse struct {
+	Mode        string          `json:"mode"`
+	CurrentBody string          `json:"current_body"`
+	NewBody     string          `json:"new_body"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	Diff        []textdiff.Line `json:"diff"`
+	CanWrite    bool            `json:"can_write"`
+}
+
+// composeBody returns the PR body that results from applying the generated description
+func composeBody(current, generated, mode string) string {
+	if mode == applyModeMerge {
+		return codehost.SetManagedRegion(current, generated)
+	}
+	return codehost.SetManagedRegion("", generated)
+}
+
+// parseApplyMode validates the apply mode, defaulting to merge
+func parseApplyMode(mode string) (string, error) {
+	switch mode {
+	case applyModeReplace:
+		return applyModeReplace, nil
+	case "", applyModeMerge:
+		return applyModeMerge, nil
+	default:
+		return "", fmt.Errorf("invalid mode %q (expected replace or merge)", mode)
+	}
+}
+
+// applyRequestError maps errors from writing to the code host to HTTP status codes and user-facing messages
+func applyRequestError(err error) *requestError {
+	switch {
+	case errors.Is(err, codehost.ErrReadOnly):
+		return &requestError{status: http.StatusForbidden, message: "Applying descriptions needs a token with write access to pull requests (GITHUB_TOKEN, GITLAB_TOKEN, ...), and this server only has read access to this host."}
+	case errors.Is(err, codehost.ErrConflict):
+		return &requestError{status: http.StatusConflict, message: "The pull request changed after the preview was made. Preview again to see its current description."}
+	case errors.Is(err, codehost.ErrForbidden), errors.Is(err, codehost.ErrNotFound):
+		// The PR was readable moments ago, so the code host is refusing the write itself
+		return &requestError{status: http.StatusForbidden, message: "The code host refused to update the pull request. On GitHub the token needs the \"repo\" scope or \"Pull requests: Read and write\"; on GitLab it needs the \"api\" scope and at least the Developer role."}
+	default:
+		return codeHostRequestError(err)
+	}
+}
+
+// loadDescriptionForApply loads the stored description named in the URL.
+// Users can only apply their own descriptions: those of other users are
+// reported as missing, as are those generated by webhooks. Release notes
+// stored alongside descriptions cannot be applied.
+func (app *Application) loadDescriptionForApply(r *http.Request) (*database.PRDescription, *requestError) {
+	description, err := app.db.GetPRDescription(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Printf("Error loading PR description: %v", err)
+		return nil, &requestError{status: http.StatusInternalServerError, message: "Failed to load the description"}
+	}
+	user := GetUserFromContext(r.Context())
+	if description == nil || user == nil || description.UserID == "" || description.UserID != user.ID {
+		return nil, &requestError{status: http.StatusNotFound, message: "Description not found"}
+	}
+	if description.Kind == database.GenerationKindReleaseNotes {
+		return nil, &requestError{status: http.StatusBadRequest, message: "Release notes are not a pull request description."}
+	}
+	return description, nil
+}
+
+// descriptionRef returns the pull request a stored description was generated for
+func descriptionRef(description *database.PRDescription) codehost.Ref {
+	owner, repo := codehost.SplitRepository(description.Repository)
+	return codehost.Ref{Host: description.Host, Owner: owner, Repo: repo, Number: description.PRNumber}
+}
+
+// handleApplyPreview handles GET /api/pr-descriptions/{id}/apply
+func (app *Application) handleApplyPreview(w http.ResponseWriter, r *http.Request) {
+	description, reqErr := app.loadDescriptionForApply(r)
+	if reqErr != nil {
+		app.writeApplyError(w, r, reqErr)
+		return
+	}
+
+	mode, err := parseApplyMode(r.URL.Query().Get("mode"))
+	if err != nil {
+		app.writeApplyError(w, r, &requestError{status: http.StatusBadRequest, message: err.Error()})
+		return
+	}
+
+	current, err := app.codeHosts.FetchBody(r.Context(), descriptionRef(description))
+	if err != nil {
+		log.Printf("Error fetching PR body: %v", err)
+		app.writeApplyError(w, r, codeHostRequestError(err))
+		return
+	}
+
+	newBody := composeBody(current.Body, description.Description, mode)
+	preview := ApplyPreviewResponse{
+		Mode:        mode,
+		CurrentBody: current.Body,
+		NewBody:     newBody,
+		UpdatedAt:   current.UpdatedAt,
+		Diff:        textdiff.Lines(current.Body, newBody),
+		CanWrite:    app.codeHosts.CanWrite(r.Context(), description.Host, descriptionRef(description).Owner),
+	}
+
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(preview)
+		return
+	}
+
+	component := templates.ApplyPreview(templates.ApplyPreviewData{
+		DescriptionID: description.ID,
+		Repository:    description.Repository,
+		PRNumber:      description.PRNumber,
+		Mode:          preview.Mode,
+		UpdatedAt:     preview.UpdatedAt,
+		Diff:          preview.Diff,
+		CanWrite:      preview.CanWrite,
+	})
+	component.Render(r.Context(), w)
+}
+
+// handleApply handles POST /api/pr-descriptions/{id}/apply
+func (app *Application) handleApply(w http.ResponseWriter, r *http.Request) {
+	var req ApplyRequest
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			app.writeApplyError(w, r, &requestError{status: http.StatusBadRequest, message: "Invalid request"})
+			return
+		}
+	} else {
+		if err := r.ParseForm(); err != nil {
+			app.writeApplyError(w, r, &requestError{status: http.StatusBadRequest, message: "Invalid request"})
+			return
+		}
+		req.Mode = r.FormValue("mode")
+		if value := r.FormValue("expected_updated_at"); value != "" {
+			expected, err := time.Parse(time.RFC3339Nano, value)
+			if err != nil {
+				app.writeApplyError(w, r, &requestError{status: http.StatusBadRequest, message: "Invalid expected_updated_at"})
+				return
+			}
+			req.ExpectedUpdatedAt = expected
+		}
+	}
+
+	mode, err := parseApplyMode(req.Mode)
+	if err != nil {
+		app.writeApplyError(w, r, &requestError{status: http.StatusBadRequest, message: err.Error()})
+		return
+	}
+	if req.ExpectedUpdatedAt.IsZero() {
+		app.writeApplyError(w, r, &requestError{status: http.StatusBadRequest, message: "expected_updated_at is required; preview the change first"})
+		return
+	}
+
+	description, reqErr := app.loadDescriptionForApply(r)
+	if reqErr != nil {
+		app.writeApplyError(w, r, reqErr)
+		return
+	}
+	if err := app.codeHosts.CheckWrite(r.Context(), description.Host, descriptionRef(description).Owner); err != nil {
+		app.writeApplyError(w, r, applyRequestError(err))
+		return
+	}
+
+	appliedBy, userID := "unknown user", ""
+	if user := GetUserFromContext(r.Context()); user != nil {
+		appliedBy, userID = user.Email, user.ID
+	}
+
+	application, err := app.applyDescription(r.Context(), description, mode, req.ExpectedUpdatedAt, userID)
+	if err != nil {
+		log.Printf("Error applying description %s: %v", description.ID, err)
+		app.writeApplyError(w, r, applyRequestError(err))
+		return
+	}
+	log.Printf("Description %s applied to %s#%d (%s) by %s", description.ID, description.Repository, description.PRNumber, mode, appliedBy)
+
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(application)
+		return
+	}
+
+	component := templates.ApplyResult(application, appliedBy)
+	component.Render(r.Context(), w)
+}
+
+// handleRefreshPRDescription handles POST /api/refresh-pr-description. It
+// brings the generated region of the PR body up to date with the PR's current
+// head, regenerating only when the cached description is stale (or when
+// regenerate is set), and leaves everything outside the region untouched.
+func (app *Application) handleRefreshPRDescription(w http.ResponseWriter, r *http.Request) {
+	var req GeneratePRDescriptionRequest
+	if err := r.ParseForm(); err == nil {
+		req.PRUrl = r.FormValue("prUrl")
+		req.Regenerate, _ = strconv.ParseBool(r.FormValue("regenerate"))
+	}
+	if req.PRUrl == "" {
+		json.NewDecoder(r.Body).Decode(&req)
+	}
+
+	genReq, reqErr := app.parseGenerationRequest(r.Context(), req.PRUrl, req.Regenerate)
+	if reqErr != nil {
+		app.writeApplyError(w, r, reqErr)
+		return
+	}
+	if err := app.codeHosts.CheckWrite(r.Context(), genReq.ref.Host, genReq.ref.Owner); err != nil {
+		app.writeApplyError(w, r, applyRequestError(err))
+		return
+	}
+
+	description, _, reqErr := app.describe(r.Context(), genReq)
+	if reqErr != nil {
+		app.writeApplyError(w, r, reqErr)
+		return
+	}
+
+	appliedBy, userID := "unknown user", ""
+	if user := GetUserFromContext(r.Context()); user != nil {
+		appliedBy, userID = user.Email, user.ID
+	}
+
+	application, err := app.applyDescription(r.Context(), description, applyModeMerge, time.Time{}, userID)
+	if err != nil {
+		log.Printf("Error refreshing description of %s#%d: %v", description.Repository, description.PRNumber, err)
+		app.writeApplyError(w, r, applyRequestError(err))
+		return
+	}
+	log.Printf("Generated region of %s#%d refreshed by %s", description.Repository, description.PRNumber, appliedBy)
+
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(application)
+		return
+	}
+
+	component := templates.ApplyResult(application, appliedBy)
+	component.Render(r.Context(), w)
+}
+
+// applyDescription writes a stored description to its pull request and
+// records the write. When expectedUpdatedAt is set and the pull request was
+// updated after it, nothing is written and ErrConflict is returned.
+//
+// The body is read once and the new body composed from it. The code host
+// reads it again right before writing and refuses the write if it changed in
+// between, but an edit landing after that last read is still overwritten: no
+// code host offers a conditional update, so the window is one round trip.
+func (app *Application) applyDescription(ctx context.Context, description *database.PRDescription, mode string, expectedUpdatedAt time.Time, userID string) (*database.PRDescriptionApplication, error) {
+	ref := descriptionRef(description)
+	current, err := app.codeHosts.FetchBody(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	if !expectedUpdatedAt.IsZero() && !current.UpdatedAt.Equal(expectedUpdatedAt) {
+		return nil, fmt.Errorf("%w: %s was updated at %s", codehost.ErrConflict, ref, current.UpdatedAt.Format(time.RFC3339))
+	}
+
+	newBody := composeBody(current.Body, description.Description, mode)
+	if _, err := app.codeHosts.UpdateBody(ctx, ref, newBody, current); err != nil {
+		return nil, err
+	}
+
+	application := &database.PRDescriptionApplication{
+		PRDescriptionID: description.ID,
+		UserID:          userID,
+		Host:            description.Host,
+		Repository:      description.Repository,
+		PRNumber:        description.PRNumber,
+		URL:             app.codeHosts.URL(ref),
+		Mode:            mode,
+		PreviousBody:    current.Body,
+		NewBody:         newBody,
+	}
+	if description.ID == "" {
+		// The description could not be stored, so there is nothing to link the record to
+		application.AppliedAt = time.Now()
+		return application, nil
+	}
+	if err := app.db.CreatePRDescriptionApplication(application); err != nil {
+		// The PR is already updated, so report success and only log the missing record
+		log.Printf("Error recording application of description %s: %v", description.ID, err)
+		application.AppliedAt = time.Now()
+	}
+	return application, nil
+}
+
+// writeApplyError renders an error fragment in place of the apply preview for
+// Alpine AJAX requests and a plain-text error otherwise
+func (app *Application) writeApplyError(w http.ResponseWriter, r *http.Request, reqErr *requestError) {
+	if reqErr.retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(reqErr.retryAfter))
+	}
+	if r.Header.Get("X-Alpine-Request") == "" {
+		http.Error(w, reqErr.message, reqErr.status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	w.WriteHeader(reqErr.status)
+	component := templates.ApplyError(reqErr.message)
+	component.Render(r.Context(), w)
+}
@@ -0,0 +1,49 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/nahue/pr-toolbox-go/internal/database"
+)
+
+func TestLoadDescriptionForApplyChecksOwner(t *testing.T) {
+	db := newTestDatabase(t)
+	app := &Application{db: db}
+	owned := &database.PRDescription{UserID: "user-a", Host: "github.com", Repository: "acme/private", PRNumber: 1, Provider: "fake", Model: "fake-model"}
+	automated := &database.PRDescription{Host: "github.com", Repository: "acme/private", PRNumber: 2, Provider: "fake", Model: "fake-model"}
+	for _, description := range []*database.PRDescription{owned, automated} {
+		if err := db.CreatePRDescription(description); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		id         string
+		user       string
+		wantStatus int // 0 when the description is returned
+	}{
+		{"owner", owned.ID, "user-a", 0},
+		{"other user", owned.ID, "user-b", http.StatusNotFound},
+		{"webhook description", automated.ID, "user-a", http.StatusNotFound},
+		{"unknown", "missing", "user-a", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		routeContext := chi.NewRouteContext()
+		routeContext.URLParams.Add("id", tt.id)
+		r := httptest.NewRequest(http.MethodGet, "/api/pr-descriptions/"+tt.id+"/apply", nil)
+		r = asUser(r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, routeContext)), tt.user)
+
+		description, reqErr := app.loadDescriptionForApply(r)
+		switch {
+		case tt.wantStatus == 0 && (reqErr != nil || description == nil || description.ID != tt.id):
+			t.Errorf("%s: loadDescriptionForApply() = %v, %+v, want description %s", tt.name, description, reqErr, tt.id)
+		case tt.wantStatus != 0 && (reqErr == nil || reqErr.status != tt.wantStatus):
+			t.Errorf("%s: loadDescriptionForApply() error = %+v, want status %d", tt.name, reqErr, tt.wantStatus)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package app
+
+import (
+	"context"
+	"log"
+
+	"github.com/nahue/pr-toolbox-go/internal/database"
+	"github.com/nahue/pr-toolbox-go/internal/llm"
+)
+
+// cachedGeneration returns the stored description for the pull request's
+// current head commit, generated with the same prompt version and settings,
+// or nil on a miss. A new push changes the head SHA and so misses the cache.
+// Lookup failures are logged and treated as misses. A hit generated for
+// someone else is returned as a copy owned by the requesting user.
+func (app *Application) cachedGeneration(ctx context.Context, req *generationRequest) *database.PRDescription {
+	if req.regenerate {
+		return nil
+	}
+
+	headSHA, err := app.codeHosts.FetchHeadSHA(ctx, req.ref)
+	if err != nil {
+		// The full fetch that follows reports the error to the user
+		log.Printf("Warning: skipping generation cache for %s: %v", req.ref, err)
+		return nil
+	}
+	if headSHA == "" {
+		return nil
+	}
+
+	var userID string
+	if user := GetUserFromContext(ctx); user != nil {
+		userID = user.ID
+	}
+	description, err := app.db.GetCachedPRDescription(database.PRDescriptionCacheKey{
+		Host:          req.ref.Host,
+		Repository:    req.ref.Repository(),
+		PRNumber:      req.ref.Number,
+		HeadSHA:       headSHA,
+		PromptVersion: llm.PromptVersion,
+		Provider:      app.llmService.ProviderName(),
+		Model:         req.settings.Model,
+		Temperature:   float32ToFloat64(req.settings.Temperature),
+		MaxTokens:     req.settings.MaxTokens,
+		PreferUserID:  userID,
+	})
+	if err != nil {
+		log.Printf("Warning: generation cache lookup failed for %s: %v", req.ref, err)
+		return nil
+	}
+	if description == nil {
+		return nil
+	}
+	log.Printf("Serving cached description for %s at %s", req.ref, headSHA)
+	return app.ownCachedGeneration(ctx, description)
+}
+
+// ownCachedGeneration returns the cached description as owned by the user in
+// ctx, or by no one for webhooks. Descriptions can only be applied by their
+// owner and other users' rows must not be exposed, so a hit stored for
+// someone else is copied. No tokens were spent on the copy, so it records
+// none. A copy that cannot be stored is still returned, without an ID.
+func (app *Application) ownCachedGeneration(ctx context.Context, cached *database.PRDescription) *database.PRDescription {
+	var userID, userEmail string
+	if user := GetUserFromContext(ctx); user != nil {
+		userID, userEmail = user.ID, user.Email
+	}
+	if cached.UserID == userID {
+		return cached
+	}
+
+	owned := *cached
+	owned.ID = ""
+	owned.UserID = userID
+	owned.UserEmail = userEmail
+	owned.PromptTokens = 0
+	owned.CompletionTokens = 0
+	if err := app.db.CreatePRDescription(&owned); err != nil {
+		log.Printf("Error saving cached description for %s#%d: %v", owned.Repository, owned.PRNumber, err)
+	}
+	return &owned
+}
@@ -0,0 +1,184 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+	"github.com/nahue/pr-toolbox-go/internal/database"
+	githubsvc "github.com/nahue/pr-toolbox-go/internal/github"
+	"github.com/nahue/pr-toolbox-go/internal/llm"
+)
+
+func TestCachedPRDescriptionKey(t *testing.T) {
+	db := newTestDatabase(t)
+	var temperature float32 = 0.7
+
+	stored := &database.PRDescription{
+		UserID:        "user-a",
+		Host:          "github.com",
+		Repository:    "acme/widgets",
+		PRNumber:      7,
+		HeadSHA:       "abc123",
+		Provider:      "fake",
+		Model:         "fake-model",
+		Temperature:   float32ToFloat64(temperature),
+		MaxTokens:     1000,
+		PromptVersion: "2025-01-01",
+		Description:   "cached",
+	}
+	if err := db.CreatePRDescription(stored); err != nil {
+		t.Fatal(err)
+	}
+
+	key := database.PRDescriptionCacheKey{
+		Host:          "github.com",
+		Repository:    "acme/widgets",
+		PRNumber:      7,
+		HeadSHA:       "abc123",
+		PromptVersion: "2025-01-01",
+		Provider:      "fake",
+		Model:         "fake-model",
+		Temperature:   float32ToFloat64(temperature),
+		MaxTokens:     1000,
+	}
+
+	tests := []struct {
+		name    string
+		change  func(key *database.PRDescriptionCacheKey)
+		wantHit bool
+	}{
+		{"same key", func(key *database.PRDescriptionCacheKey) {}, true},
+		{"repository in another case", func(key *database.PRDescriptionCacheKey) { key.Repository = "Acme/Widgets" }, true},
+		{"other host", func(key *database.PRDescriptionCacheKey) { key.Host = "ghe.example.com" }, false},
+		{"other pull request", func(key *database.PRDescriptionCacheKey) { key.PRNumber = 8 }, false},
+		{"new push", func(key *database.PRDescriptionCacheKey) { key.HeadSHA = "def456" }, false},
+		{"new prompt", func(key *database.PRDescriptionCacheKey) { key.PromptVersion = "2025-01-02" }, false},
+		{"other provider", func(key *database.PRDescriptionCacheKey) { key.Provider = "openai" }, false},
+		{"other model", func(key *database.PRDescriptionCacheKey) { key.Model = "other-model" }, false},
+		{"other temperature", func(key *database.PRDescriptionCacheKey) { key.Temperature = 0.2 }, false},
+		{"other token limit", func(key *database.PRDescriptionCacheKey) { key.MaxTokens = 2000 }, false},
+	}
+
+	for _, tt := range tests {
+		lookup := key
+		tt.change(&lookup)
+		cached, err := db.GetCachedPRDescription(lookup)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hit := cached != nil && cached.ID == stored.ID; hit != tt.wantHit {
+			t.Errorf("%s: GetCachedPRDescription() = %+v, want hit %v", tt.name, cached, tt.wantHit)
+		}
+	}
+}
+
+func TestCachedGenerationIsCopiedForAnotherUser(t *testing.T) {
+	var written string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/repos/acme/widgets/pulls/7" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method == http.MethodPatch {
+			var update struct {
+				Body string `json:"body"`
+			}
+			json.NewDecoder(r.Body).Decode(&update)
+			written = update.Body
+		}
+		fmt.Fprint(w, `{"number": 7, "body": "Written by the author.", "updated_at": "2025-09-01T12:00:00Z", "head": {"sha": "abc123"}}`)
+	}))
+	defer server.Close()
+
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GITHUB_DEMO_MODE", "")
+	t.Setenv("GITHUB_ENTERPRISE_HOSTS", "ghe.example.com="+server.URL+"/api/v3/")
+	db := newTestDatabase(t)
+	app := &Application{db: db, codeHosts: codehost.NewRegistry(githubsvc.NewService()), llmService: llm.NewService(llm.NewFakeProvider())}
+	settings := app.llmService.DefaultSettings()
+
+	stored := &database.PRDescription{
+		UserID:           "user-a",
+		Host:             "ghe.example.com",
+		Repository:       "acme/widgets",
+		PRNumber:         7,
+		HeadSHA:          "abc123",
+		Provider:         app.llmService.ProviderName(),
+		Model:            settings.Model,
+		Temperature:      float32ToFloat64(settings.Temperature),
+		MaxTokens:        settings.MaxTokens,
+		PromptVersion:    llm.PromptVersion,
+		Description:      "Cached description",
+		PromptTokens:     120,
+		CompletionTokens: 80,
+	}
+	if err := db.CreatePRDescription(stored); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.WithValue(context.Background(), userContextKey, &AuthUser{ID: "user-b", Email: "b@example.com", IsActive: true})
+	ctx = githubsvc.WithUserToken(ctx, "ghe.example.com", "user-token")
+	req := &generationRequest{ref: codehost.Ref{Host: "ghe.example.com", Owner: "acme", Repo: "widgets", Number: 7}, settings: settings}
+
+	description, cached, reqErr := app.describe(ctx, req)
+	if reqErr != nil || !cached {
+		t.Fatalf("describe() = %+v, %v, %+v, want a cache hit", description, cached, reqErr)
+	}
+	if description.ID == stored.ID || description.UserID != "user-b" || description.UserEmail != "b@example.com" {
+		t.Errorf("describe() = %+v, want a copy owned by user-b", description)
+	}
+	if description.Description != stored.Description || description.PromptTokens != 0 || description.CompletionTokens != 0 {
+		t.Errorf("describe() = %+v, want the cached description without token usage", description)
+	}
+
+	// The copy can be previewed and applied by user-b
+	routeContext := chi.NewRouteContext()
+	routeContext.URLParams.Add("id", description.ID)
+	r := httptest.NewRequest(http.MethodPost, "/api/pr-descriptions/"+description.ID+"/apply", nil)
+	r = asUser(r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, routeContext)), "user-b")
+	loaded, reqErr := app.loadDescriptionForApply(r)
+	if reqErr != nil {
+		t.Fatalf("loadDescriptionForApply() error = %+v, want the copy", reqErr)
+	}
+	if _, err := app.applyDescription(ctx, loaded, applyModeMerge, time.Time{}, "user-b"); err != nil {
+		t.Fatalf("applyDescription() error = %v", err)
+	}
+	if !strings.Contains(written, "Cached description") {
+		t.Errorf("written body = %q, want the cached description", written)
+	}
+
+	// Each user's next request hits their own row rather than copying again
+	if again, _, _ := app.describe(ctx, req); again.ID != description.ID {
+		t.Errorf("describe() for user-b again = %s, want the copy %s", again.ID, description.ID)
+	}
+	ctxA := context.WithValue(ctx, userContextKey, &AuthUser{ID: "user-a", IsActive: true})
+	if again, _, _ := app.describe(ctxA, req); again.ID != stored.ID {
+		t.Errorf("describe() for user-a = %s, want their own row %s", again.ID, stored.ID)
+	}
+}
+
+func TestFloat32ToFloat64(t *testing.T) {
+	tests := []struct {
+		value float32
+		want  float64
+	}{
+		{0, 0},
+		{0.7, 0.7},
+		{0.2, 0.2},
+		{1.5, 1.5},
+	}
+
+	for _, tt := range tests {
+		if got := float32ToFloat64(tt.value); got != tt.want {
+			t.Errorf("float32ToFloat64(%v) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
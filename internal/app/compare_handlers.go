@@ -0,0 +1,239 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+	"github.com/nahue/pr-toolbox-go/internal/database"
+	githubsvc "github.com/nahue/pr-toolbox-go/internal/github"
+	"github.com/nahue/pr-toolbox-go/templates"
+)
+
+// CompareRequest is the body of POST /api/generate-compare-description: either
+// a compare URL, or a repository with the base and head branches
+type CompareRequest struct {
+	CompareURL string `json:"compareUrl"`
+	Repository string `json:"repository"` // owner/repo, or host/owner/repo outside github.com
+	Base       string `json:"base"`
+	Head       string `json:"head"`
+}
+
+// DraftPullRequestRequest is the body of POST /api/pr-descriptions/{id}/draft-pull-request.
+// The title is generated from the description when empty.
+type DraftPullRequestRequest struct {
+	Title string `json:"title"`
+}
+
+// DraftPullRequestResponse is the draft pull request opened for a stored description
+type DraftPullRequestResponse struct {
+	codehost.Ref
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// githubFor returns the GitHub service serving host. Branch comparisons, draft
+// pull requests, release notes and reviews are only supported on GitHub.
+func (app *Application) githubFor(host string) (*githubsvc.Service, *requestError) {
+	provider, err := app.codeHosts.Provider(host)
+	if err != nil {
+		return nil, codeHostRequestError(err)
+	}
+	github, ok := provider.(*githubsvc.Service)
+	if !ok {
+		return nil, &requestError{status: http.StatusBadRequest, message: fmt.Sprintf("This is only supported on GitHub, and %s is a %s host.", host, provider.Name())}
+	}
+	return github, nil
+}
+
+// generateCompareDescription handles POST /api/generate-compare-description.
+// It describes the changes between two branches before a pull request is
+// opened for them. Like local branches, comparisons are stored in the history
+// but never served from the cache, since the same head can be compared
+// against different bases.
+func (app *Application) generateCompareDescription(w http.ResponseWriter, r *http.Request) {
+	var req CompareRequest
+	if err := r.ParseForm(); err == nil {
+		req.CompareURL = r.FormValue("compareUrl")
+		req.Repository = r.FormValue("repository")
+		req.Base = r.FormValue("base")
+		req.Head = r.FormValue("head")
+	}
+	if req.CompareURL == "" && req.Repository == "" {
+		json.NewDecoder(r.Body).Decode(&req)
+	}
+
+	var ref githubsvc.CompareRef
+	var err error
+	switch {
+	case strings.TrimSpace(req.CompareURL) != "":
+		ref, err = githubsvc.ParseCompareRef(req.CompareURL)
+	case strings.TrimSpace(req.Repository) != "":
+		ref, err = githubsvc.NewCompareRef(req.Repository, req.Base, req.Head)
+	default:
+		app.writeError(w, r, "A compare URL, or a repository with base and head branches, is required", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		app.writeError(w, r, fmt.Sprintf("Invalid branch comparison: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	github, reqErr := app.githubFor(ref.Host)
+	if reqErr != nil {
+		app.writeRequestError(w, r, reqErr)
+		return
+	}
+	prData, err := github.FetchComparison(r.Context(), ref)
+	if err != nil {
+		log.Printf("Error comparing %s: %v", ref, err)
+		app.writeRequestError(w, r, compareRequestError(err))
+		return
+	}
+
+	var userID string
+	if user := GetUserFromContext(r.Context()); user != nil {
+		userID = user.ID
+	}
+	settings, err := app.resolveSettings(r.Context(), prData.Host, prData.Repository, userID)
+	if err != nil {
+		log.Printf("Error resolving generation settings: %v", err)
+		app.writeError(w, r, "Failed to load generation settings", http.StatusInternalServerError)
+		return
+	}
+
+	generation, err := app.llmService.GeneratePRDescription(r.Context(), prData, settings, func(done, total int) {
+		log.Printf("Generating description for %s: %d/%d stages done", ref, done, total)
+	})
+	if err != nil {
+		log.Printf("Error generating description: %v", err)
+		app.writeError(w, r, "Failed to generate description. Please try again.", http.StatusInternalServerError)
+		return
+	}
+	description := app.saveGeneration(r.Context(), prData, generation)
+
+	// Return JSON for API clients that ask for it
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(GeneratePRDescriptionResponse{PRDescription: description})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	templates.PrDescriptionResult(description, false).Render(r.Context(), w)
+}
+
+// descriptionCompareRef returns the branch comparison a stored description was
+// generated from. Descriptions stored before the compared refs were recorded
+// fall back to parsing their compare URL.
+func descriptionCompareRef(description *database.PRDescription) (githubsvc.CompareRef, error) {
+	if description.Kind != database.GenerationKindDescription || description.PRNumber != 0 {
+		return githubsvc.CompareRef{}, errors.New("not a branch comparison")
+	}
+	if description.BaseRef == "" {
+		return githubsvc.ParseCompareRef(description.URL)
+	}
+	return githubsvc.NewCompareRef(description.Host+"/"+description.Repository, description.BaseRef, description.HeadRef)
+}
+
+// handleCreateDraftPullRequest handles POST /api/pr-descriptions/{id}/draft-pull-request.
+// It opens a draft pull request for the branch comparison a stored description
+// was generated from, with the description in the generated region of its
+// body, so later refreshes and applies update it in place.
+func (app *Application) handleCreateDraftPullRequest(w http.ResponseWriter, r *http.Request) {
+	var req DraftPullRequestRequest
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			app.writeApplyError(w, r, &requestError{status: http.StatusBadRequest, message: "Invalid request"})
+			return
+		}
+	} else if err := r.ParseForm(); err == nil {
+		req.Title = r.FormValue("title")
+	}
+
+	description, reqErr := app.loadDescriptionForApply(r)
+	if reqErr != nil {
+		app.writeApplyError(w, r, reqErr)
+		return
+	}
+	ref, err := descriptionCompareRef(description)
+	if err != nil {
+		app.writeApplyError(w, r, &requestError{status: http.StatusBadRequest, message: "This description was not generated from a branch comparison."})
+		return
+	}
+	github, reqErr := app.githubFor(ref.Host)
+	if reqErr != nil {
+		app.writeApplyError(w, r, reqErr)
+		return
+	}
+	if err := github.CheckWrite(r.Context(), ref.Host, ref.Owner); err != nil {
+		app.writeApplyError(w, r, draftRequestError(err))
+		return
+	}
+
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		var userID string
+		if user := GetUserFromContext(r.Context()); user != nil {
+			userID = user.ID
+		}
+		settings, err := app.resolveSettings(r.Context(), description.Host, description.Repository, userID)
+		if err != nil {
+			log.Printf("Error resolving generation settings: %v", err)
+			app.writeApplyError(w, r, &requestError{status: http.StatusInternalServerError, message: "Failed to load generation settings"})
+			return
+		}
+		title, err = app.llmService.GenerateTitle(r.Context(), description.Repository, description.Description, settings)
+		if err != nil || title == "" {
+			log.Printf("Error generating title for description %s: %v", description.ID, err)
+			app.writeApplyError(w, r, &requestError{status: http.StatusInternalServerError, message: "Failed to generate a title. Enter one and try again."})
+			return
+		}
+	}
+
+	prRef, err := github.CreateDraftPullRequest(r.Context(), ref, title, codehost.SetManagedRegion("", description.Description))
+	if err != nil {
+		log.Printf("Error creating draft pull request for %s: %v", ref, err)
+		app.writeApplyError(w, r, draftRequestError(err))
+		return
+	}
+	log.Printf("Draft pull request %s opened from description %s", prRef, description.ID)
+
+	response := DraftPullRequestResponse{Ref: prRef, Title: title, URL: githubsvc.PullRequestURL(prRef)}
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	component := templates.DraftPullRequestResult(response.URL, prRef.String(), title)
+	component.Render(r.Context(), w)
+}
+
+// compareRequestError maps errors from comparing branches to HTTP status codes and user-facing messages
+func compareRequestError(err error) *requestError {
+	switch {
+	case errors.Is(err, githubsvc.ErrNothingToCompare):
+		return &requestError{status: http.StatusBadRequest, message: err.Error()}
+	case errors.Is(err, codehost.ErrNotFound):
+		return &requestError{status: http.StatusNotFound, message: "Repository or branch not found. Check the names, or make sure the configured token can access this repository."}
+	default:
+		return codeHostRequestError(err)
+	}
+}
+
+// draftRequestError maps errors from opening a pull request to HTTP status codes and user-facing messages
+func draftRequestError(err error) *requestError {
+	switch {
+	case errors.Is(err, codehost.ErrReadOnly):
+		return &requestError{status: http.StatusForbidden, message: "Opening pull requests needs a token with write access to pull requests (GITHUB_TOKEN), and this server only has read access to this host."}
+	case errors.Is(err, githubsvc.ErrPullRequestRejected):
+		return &requestError{status: http.StatusUnprocessableEntity, message: err.Error()}
+	default:
+		return applyRequestError(err)
+	}
+}
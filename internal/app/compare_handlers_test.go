@@ -0,0 +1,50 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/nahue/pr-toolbox-go/internal/database"
+	githubsvc "github.com/nahue/pr-toolbox-go/internal/github"
+)
+
+func TestDescriptionCompareRef(t *testing.T) {
+	want := githubsvc.CompareRef{Host: "github.com", Owner: "acme", Repo: "widgets", Base: "main", Head: "fix#12"}
+
+	tests := []struct {
+		name        string
+		description database.PRDescription
+		wantErr     bool
+	}{
+		{
+			"stored refs",
+			database.PRDescription{Kind: database.GenerationKindDescription, Host: "github.com", Repository: "acme/widgets", URL: "https://github.com/acme/widgets/compare/main...fix", BaseRef: "main", HeadRef: "fix#12"},
+			false,
+		},
+		{
+			"escaped URL of a description stored without refs",
+			database.PRDescription{Kind: database.GenerationKindDescription, Host: "github.com", Repository: "acme/widgets", URL: "https://github.com/acme/widgets/compare/main...fix%2312"},
+			false,
+		},
+		{
+			"pull request",
+			database.PRDescription{Kind: database.GenerationKindDescription, Host: "github.com", Repository: "acme/widgets", PRNumber: 12, URL: "https://github.com/acme/widgets/pull/12"},
+			true,
+		},
+		{
+			"release notes",
+			database.PRDescription{Kind: database.GenerationKindReleaseNotes, Host: "github.com", Repository: "acme/widgets", URL: "https://github.com/acme/widgets/compare/main...fix%2312", BaseRef: "main", HeadRef: "fix#12"},
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := descriptionCompareRef(&tt.description)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: descriptionCompareRef() error = %v, want error %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err == nil && got != want {
+			t.Errorf("%s: descriptionCompareRef() = %+v, want %+v", tt.name, got, want)
+		}
+	}
+}
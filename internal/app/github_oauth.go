@@ -0,0 +1,400 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nahue/pr-toolbox-go/internal/database"
+	githubsvc "github.com/nahue/pr-toolbox-go/internal/github"
+	"github.com/nahue/pr-toolbox-go/internal/secretbox"
+	"github.com/nahue/pr-toolbox-go/templates"
+)
+
+const (
+	githubOAuthScope       = "repo"
+	githubOAuthStateCookie = "github_oauth_state"
+	githubOAuthStateTTL    = 10 * time.Minute
+)
+
+// githubOAuth lets users connect their GitHub account with the OAuth web flow,
+// so GitHub is called with their own permissions instead of the server's token
+type githubOAuth struct {
+	clientID     string
+	clientSecret string
+	webURL       string // where users authorize, e.g. https://github.com
+	host         string // GitHub host the issued tokens are valid for
+	apiURL       string // REST API used to look up the connected account
+	redirectURL  string // empty to derive it from the request
+	required     bool   // signed-in users may not fall back to the server's credentials
+	box          *secretbox.Box
+	httpClient   *http.Client
+
+	mu     sync.Mutex
+	states map[string]pendingGitHubOAuth // by state parameter
+}
+
+// pendingGitHubOAuth is an authorization started by a user and not yet completed
+type pendingGitHubOAuth struct {
+	userID    string
+	expiresAt time.Time
+}
+
+// newGitHubOAuthFromEnv configures the OAuth flow from GITHUB_OAUTH_* variables.
+// It returns nil when no OAuth app is configured.
+func newGitHubOAuthFromEnv() (*githubOAuth, error) {
+	clientID := os.Getenv("GITHUB_OAUTH_CLIENT_ID")
+	clientSecret := os.Getenv("GITHUB_OAUTH_CLIENT_SECRET")
+	if clientID == "" && clientSecret == "" {
+		return nil, nil
+	}
+	if clientID == "" || clientSecret == "" {
+		return nil, fmt.Errorf("both GITHUB_OAUTH_CLIENT_ID and GITHUB_OAUTH_CLIENT_SECRET must be set")
+	}
+
+	box, err := secretbox.New(os.Getenv("TOKEN_ENCRYPTION_KEY"))
+	if err != nil {
+		return nil, fmt.Errorf("TOKEN_ENCRYPTION_KEY is required to store GitHub tokens: %w", err)
+	}
+
+	required := false
+	if requiredStr := os.Getenv("GITHUB_OAUTH_REQUIRED"); requiredStr != "" {
+		if parsed, err := strconv.ParseBool(requiredStr); err == nil {
+			required = parsed
+		} else {
+			log.Printf("Warning: Invalid GITHUB_OAUTH_REQUIRED value '%s', defaulting to false", requiredStr)
+		}
+	}
+
+	webURL := strings.TrimSuffix(envOrDefault("GITHUB_OAUTH_URL", "https://github.com"), "/")
+	parsedWebURL, err := url.Parse(webURL)
+	if err != nil || parsedWebURL.Host == "" {
+		return nil, fmt.Errorf("invalid GITHUB_OAUTH_URL %q", webURL)
+	}
+
+	return &githubOAuth{
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		webURL:       webURL,
+		host:         strings.ToLower(parsedWebURL.Host),
+		apiURL:       strings.TrimSuffix(envOrDefault("GITHUB_OAUTH_API_URL", "https://api.github.com"), "/"),
+		redirectURL:  os.Getenv("GITHUB_OAUTH_REDIRECT_URL"),
+		required:     required,
+		box:          box,
+		httpClient:   &http.Client{Timeout: 15 * time.Second},
+		states:       make(map[string]pendingGitHubOAuth),
+	}, nil
+}
+
+func envOrDefault(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// callbackURL returns the URL GitHub redirects back to after authorization
+func (o *githubOAuth) callbackURL(r *http.Request) string {
+	if o.redirectURL != "" {
+		return o.redirectURL
+	}
+	return getBaseURL(r) + "/auth/github/callback"
+}
+
+// begin records a new authorization for userID and returns its state parameter
+func (o *githubOAuth) begin(userID string) (string, error) {
+	state, err := generateSecureToken()
+	if err != nil {
+		return "", err
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	now := time.Now()
+	for key, pending := range o.states {
+		if now.After(pending.expiresAt) {
+			delete(o.states, key)
+		}
+	}
+	o.states[state] = pendingGitHubOAuth{userID: userID, expiresAt: now.Add(githubOAuthStateTTL)}
+	return state, nil
+}
+
+// complete consumes the authorization started with state and returns the user who started it
+func (o *githubOAuth) complete(state string) (string, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	pending, ok := o.states[state]
+	delete(o.states, state)
+	if !ok || time.Now().After(pending.expiresAt) {
+		return "", false
+	}
+	return pending.userID, true
+}
+
+// exchangeCode trades an authorization code for an access token and the scopes granted
+func (o *githubOAuth) exchangeCode(ctx context.Context, code, redirectURL string) (token, scopes string, err error) {
+	form := url.Values{
+		"client_id":     {o.clientID},
+		"client_secret": {o.clientSecret},
+		"code":          {code},
+		"redirect_uri":  {redirectURL},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.webURL+"/login/oauth/access_token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := o.httpClient.Do(req)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		AccessToken      string `json:"access_token"`
+		Scope            string `json:"scope"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", "", fmt.Errorf("failed to decode token response (status %d): %w", resp.StatusCode, err)
+	}
+	if result.Error != "" {
+		return "", "", fmt.Errorf("GitHub rejected the authorization code: %s (%s)", result.Error, result.ErrorDescription)
+	}
+	if result.AccessToken == "" {
+		return "", "", fmt.Errorf("token response contained no access token (status %d)", resp.StatusCode)
+	}
+	return result.AccessToken, result.Scope, nil
+}
+
+// fetchLogin returns the login of the account owning token
+func (o *githubOAuth) fetchLogin(ctx context.Context, token string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, o.apiURL+"/user", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := o.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch GitHub user: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch GitHub user: status %d", resp.StatusCode)
+	}
+
+	var user struct {
+		Login string `json:"login"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return "", fmt.Errorf("failed to decode GitHub user: %w", err)
+	}
+	return user.Login, nil
+}
+
+// githubUserMiddleware makes GitHub calls act as the signed-in user on every
+// host where they have connected an account. Calls to other hosts fall back to
+// the server's credentials, unless GITHUB_OAUTH_REQUIRED is set.
+func (app *Application) githubUserMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := GetUserFromContext(r.Context())
+		if app.githubOAuth == nil || user == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		connections, err := app.db.ListGitHubConnections(user.ID)
+		if err != nil {
+			log.Printf("Error loading GitHub connections for user %s: %v", user.ID, err)
+		}
+
+		// Each token is only ever sent to the host that issued it
+		ctx := r.Context()
+		for _, connection := range connections {
+			token, err := app.githubOAuth.box.Open(connection.EncryptedToken)
+			if err != nil {
+				log.Printf("Error decrypting GitHub token for user %s on %s: %v", user.ID, connection.Host, err)
+				continue
+			}
+			ctx = githubsvc.WithUserToken(ctx, connection.Host, token)
+		}
+		if app.githubOAuth.required {
+			ctx = githubsvc.RequireUserTokens(ctx)
+		}
+		r = r.WithContext(ctx)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// loadGitHubAccount describes the user's GitHub connection for the settings page
+func (app *Application) loadGitHubAccount(user *AuthUser) templates.GitHubAccount {
+	if app.githubOAuth == nil || user == nil {
+		return templates.GitHubAccount{}
+	}
+
+	account := templates.GitHubAccount{Enabled: true, Required: app.githubOAuth.required}
+	connection, err := app.db.GetGitHubConnection(user.ID, app.githubOAuth.host)
+	if err != nil {
+		log.Printf("Error loading GitHub connection for user %s: %v", user.ID, err)
+	}
+	if connection != nil {
+		account.Login = connection.Login
+		account.ConnectedAt = connection.UpdatedAt
+	}
+	return account
+}
+
+// handleGitHubConnect handles GET /auth/github/connect
+func (app *Application) handleGitHubConnect(w http.ResponseWriter, r *http.Request) {
+	if app.githubOAuth == nil {
+		http.Error(w, "GitHub OAuth is not configured", http.StatusNotFound)
+		return
+	}
+	user := GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	state, err := app.githubOAuth.begin(user.ID)
+	if err != nil {
+		log.Printf("Error starting GitHub authorization: %v", err)
+		http.Error(w, "Failed to start GitHub authorization", http.StatusInternalServerError)
+		return
+	}
+
+	// The cookie binds the state to this browser. It must survive the
+	// cross-site redirect back from GitHub, hence SameSite=Lax.
+	http.SetCookie(w, &http.Cookie{
+		Name:     githubOAuthStateCookie,
+		Value:    state,
+		Path:     "/auth/github",
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   int(githubOAuthStateTTL.Seconds()),
+	})
+
+	query := url.Values{
+		"client_id":    {app.githubOAuth.clientID},
+		"redirect_uri": {app.githubOAuth.callbackURL(r)},
+		"scope":        {githubOAuthScope},
+		"state":        {state},
+	}
+	http.Redirect(w, r, app.githubOAuth.webURL+"/login/oauth/authorize?"+query.Encode(), http.StatusFound)
+}
+
+// handleGitHubCallback handles GET /auth/github/callback. It is a public route:
+// the session cookie is SameSite=Strict and is not sent on the redirect from
+// GitHub, so the user is identified by the state parameter instead.
+func (app *Application) handleGitHubCallback(w http.ResponseWriter, r *http.Request) {
+	if app.githubOAuth == nil {
+		http.Error(w, "GitHub OAuth is not configured", http.StatusNotFound)
+		return
+	}
+
+	state := r.URL.Query().Get("state")
+	cookie, err := r.Cookie(githubOAuthStateCookie)
+	if err != nil || state == "" || cookie.Value != state {
+		http.Error(w, "Invalid or expired GitHub authorization, please try again", http.StatusBadRequest)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     githubOAuthStateCookie,
+		Value:    "",
+		Path:     "/auth/github",
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   -1,
+	})
+
+	userID, ok := app.githubOAuth.complete(state)
+	if !ok {
+		http.Error(w, "Invalid or expired GitHub authorization, please try again", http.StatusBadRequest)
+		return
+	}
+
+	if oauthErr := r.URL.Query().Get("error"); oauthErr != "" {
+		log.Printf("GitHub authorization for user %s was not granted: %s", userID, oauthErr)
+		app.renderGitHubRedirect(w, r, "/settings?error="+url.QueryEscape("GitHub authorization was not granted"))
+		return
+	}
+
+	token, scopes, err := app.githubOAuth.exchangeCode(r.Context(), r.URL.Query().Get("code"), app.githubOAuth.callbackURL(r))
+	if err != nil {
+		log.Printf("Error completing GitHub authorization for user %s: %v", userID, err)
+		app.renderGitHubRedirect(w, r, "/settings?error="+url.QueryEscape("Failed to connect your GitHub account"))
+		return
+	}
+
+	login, err := app.githubOAuth.fetchLogin(r.Context(), token)
+	if err != nil {
+		log.Printf("Error completing GitHub authorization for user %s: %v", userID, err)
+		app.renderGitHubRedirect(w, r, "/settings?error="+url.QueryEscape("Failed to connect your GitHub account"))
+		return
+	}
+
+	encrypted, err := app.githubOAuth.box.Seal(token)
+	if err == nil {
+		err = app.db.UpsertGitHubConnection(&database.GitHubConnection{
+			UserID:         userID,
+			Host:           app.githubOAuth.host,
+			Login:          login,
+			EncryptedToken: encrypted,
+			Scopes:         scopes,
+		})
+	}
+	if err != nil {
+		log.Printf("Error saving GitHub connection for user %s: %v", userID, err)
+		app.renderGitHubRedirect(w, r, "/settings?error="+url.QueryEscape("Failed to save your GitHub connection"))
+		return
+	}
+
+	log.Printf("User %s connected GitHub account %s", userID, login)
+	app.renderGitHubRedirect(w, r, "/settings?saved=1")
+}
+
+// renderGitHubRedirect sends the browser on to target with a same-site
+// navigation, so the SameSite=Strict session cookie is sent again. An HTTP
+// redirect would still count as part of the cross-site chain from GitHub.
+func (app *Application) renderGitHubRedirect(w http.ResponseWriter, r *http.Request, target string) {
+	w.Header().Set("Content-Type", "text/html")
+	component := templates.RedirectPage(target)
+	component.Render(r.Context(), w)
+}
+
+// handleGitHubDisconnect handles POST /auth/github/disconnect
+func (app *Application) handleGitHubDisconnect(w http.ResponseWriter, r *http.Request) {
+	user := GetUserFromContext(r.Context())
+	if app.githubOAuth == nil || user == nil {
+		http.Redirect(w, r, "/settings", http.StatusSeeOther)
+		return
+	}
+
+	if err := app.db.DeleteGitHubConnection(user.ID, app.githubOAuth.host); err != nil {
+		log.Printf("Error deleting GitHub connection for user %s: %v", user.ID, err)
+		http.Redirect(w, r, "/settings?error="+url.QueryEscape("Failed to disconnect your GitHub account"), http.StatusSeeOther)
+		return
+	}
+
+	log.Printf("User %s disconnected their GitHub account", user.ID)
+	http.Redirect(w, r, "/settings?saved=1", http.StatusSeeOther)
+}
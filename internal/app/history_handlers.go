@@ -0,0 +1,150 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+	"github.com/nahue/pr-toolbox-go/internal/database"
+	"github.com/nahue/pr-toolbox-go/internal/llm"
+	"github.com/nahue/pr-toolbox-go/templates"
+)
+
+// historyPageLimit caps the number of generations listed at once
+const historyPageLimit = 200
+
+// historyDateLayout is the format of the from/to history filters
+const historyDateLayout = "2006-01-02"
+
+// saveGeneration stores a generated description and returns the stored record.
+// Failures are logged rather than returned so that a storage problem never
+// hides a generated description; the record then has no ID.
+func (app *Application) saveGeneration(ctx context.Context, prData *codehost.ChangeRequest, generation *llm.Generation) *database.PRDescription {
+	description := &database.PRDescription{
+		Host:             prData.Host,
+		Repository:       prData.Repository,
+		PRNumber:         prData.PRNumber,
+		URL:              prData.URL,
+		BaseRef:          prData.BaseRef,
+		HeadRef:          prData.HeadRef,
+		HeadSHA:          prData.HeadSHA,
+		Provider:         generation.Provider,
+		Model:            generation.Settings.Model,
+		Temperature:      float32ToFloat64(generation.Settings.Temperature),
+		MaxTokens:        generation.Settings.MaxTokens,
+		PromptVersion:    generation.PromptVersion,
+		Description:      generation.Description,
+		PromptTokens:     generation.Usage.PromptTokens,
+		CompletionTokens: generation.Usage.CompletionTokens,
+	}
+	if user := GetUserFromContext(ctx); user != nil {
+		description.UserID = user.ID
+	}
+
+	if err := app.db.CreatePRDescription(description); err != nil {
+		log.Printf("Error saving generated description for %s#%d: %v", prData.Repository, prData.PRNumber, err)
+	}
+	return description
+}
+
+// float32ToFloat64 converts without exposing float32 rounding (0.7 stays 0.7 rather than 0.699999988)
+func float32ToFloat64(value float32) float64 {
+	converted, _ := strconv.ParseFloat(strconv.FormatFloat(float64(value), 'g', -1, 32), 64)
+	return converted
+}
+
+// parseHistoryFilter reads the repository, from and to query parameters.
+// Dates are whole days, so "to" includes the whole day it names. Users only
+// see their own generations, since they may describe pull requests other
+// users cannot read; generations without a user (from webhooks) are not listed.
+func parseHistoryFilter(r *http.Request) (database.PRDescriptionFilter, error) {
+	query := r.URL.Query()
+	filter := database.PRDescriptionFilter{
+		UserID:     historyUserID(r),
+		Repository: strings.TrimSpace(query.Get("repository")),
+		Limit:      historyPageLimit,
+	}
+
+	if from := query.Get("from"); from != "" {
+		since, err := time.ParseInLocation(historyDateLayout, from, time.Local)
+		if err != nil {
+			return filter, fmt.Errorf("invalid from date %q (expected YYYY-MM-DD)", from)
+		}
+		filter.Since = since
+	}
+	if to := query.Get("to"); to != "" {
+		until, err := time.ParseInLocation(historyDateLayout, to, time.Local)
+		if err != nil {
+			return filter, fmt.Errorf("invalid to date %q (expected YYYY-MM-DD)", to)
+		}
+		filter.Until = until.AddDate(0, 0, 1)
+	}
+
+	return filter, nil
+}
+
+// historyUserID returns the signed-in user whose history is shown. The history
+// routes sit behind authMiddleware, which always sets a user; should one be
+// missing, an ID no generation is stored with keeps the lists empty.
+func historyUserID(r *http.Request) string {
+	if user := GetUserFromContext(r.Context()); user != nil && user.ID != "" {
+		return user.ID
+	}
+	return "-"
+}
+
+// handleHistoryPage handles GET /history
+func (app *Application) handleHistoryPage(w http.ResponseWriter, r *http.Request) {
+	data := templates.HistoryPageData{
+		Repository: r.URL.Query().Get("repository"),
+		From:       r.URL.Query().Get("from"),
+		To:         r.URL.Query().Get("to"),
+	}
+
+	filter, err := parseHistoryFilter(r)
+	if err != nil {
+		data.Error = err.Error()
+	} else if data.Entries, err = app.db.ListPRDescriptions(filter); err != nil {
+		log.Printf("Error listing PR descriptions: %v", err)
+		http.Error(w, "Failed to load history", http.StatusInternalServerError)
+		return
+	}
+
+	data.Repositories, err = app.db.ListPRDescriptionRepositories(historyUserID(r))
+	if err != nil {
+		log.Printf("Error listing PR description repositories: %v", err)
+		http.Error(w, "Failed to load history", http.StatusInternalServerError)
+		return
+	}
+
+	component := templates.HistoryPage(data)
+	component.Render(r.Context(), w)
+}
+
+// handleListHistory handles GET /api/pr-descriptions
+func (app *Application) handleListHistory(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseHistoryFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	entries, err := app.db.ListPRDescriptions(filter)
+	if err != nil {
+		log.Printf("Error listing PR descriptions: %v", err)
+		http.Error(w, "Failed to load history", http.StatusInternalServerError)
+		return
+	}
+	if entries == nil {
+		entries = []*database.PRDescription{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
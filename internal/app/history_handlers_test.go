@@ -0,0 +1,66 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nahue/pr-toolbox-go/internal/database"
+)
+
+// asUser returns the request as made by the signed-in user
+func asUser(r *http.Request, userID string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), userContextKey, &AuthUser{ID: userID, IsActive: true}))
+}
+
+func TestHistoryOnlyListsOwnDescriptions(t *testing.T) {
+	db := newTestDatabase(t)
+	app := &Application{db: db}
+	for _, description := range []*database.PRDescription{
+		{UserID: "user-a", Host: "github.com", Repository: "acme/private", PRNumber: 1, Provider: "fake", Model: "fake-model", Description: "A's description"},
+		{UserID: "user-b", Host: "github.com", Repository: "acme/public", PRNumber: 2, Provider: "fake", Model: "fake-model", Description: "B's description"},
+		{Host: "github.com", Repository: "acme/webhooks", PRNumber: 3, Provider: "fake", Model: "fake-model", Description: "from a webhook"},
+	} {
+		if err := db.CreatePRDescription(description); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		user  string
+		query string
+		want  []string
+	}{
+		{"user-a", "", []string{"acme/private"}},
+		{"user-b", "", []string{"acme/public"}},
+		{"user-b", "?repository=acme/private", nil},
+		{"user-c", "", nil},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		app.handleListHistory(w, asUser(httptest.NewRequest(http.MethodGet, "/api/pr-descriptions"+tt.query, nil), tt.user))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s%s: status %d: %s", tt.user, tt.query, w.Code, w.Body)
+		}
+		var entries []*database.PRDescription
+		if err := json.NewDecoder(w.Body).Decode(&entries); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, entry := range entries {
+			got = append(got, entry.Repository)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s%s: listed %v, want %v", tt.user, tt.query, got, tt.want)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	app.handleHistoryPage(w, asUser(httptest.NewRequest(http.MethodGet, "/history", nil), "user-b"))
+	if page := w.Body.String(); w.Code != http.StatusOK || strings.Contains(page, "acme/private") || strings.Contains(page, "acme/webhooks") {
+		t.Errorf("history page of user-b (status %d) shows other users' repositories", w.Code)
+	}
+}
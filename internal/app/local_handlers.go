@@ -0,0 +1,97 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/nahue/pr-toolbox-go/internal/localgit"
+	"github.com/nahue/pr-toolbox-go/templates"
+)
+
+// generateLocalDescription handles POST /api/generate-local-description. It
+// describes a branch of a repository on the server's disk, under one of the
+// LOCAL_GIT_ROOTS directories. Local descriptions are stored in the history
+// but never served from the cache, since the same head can be compared
+// against different bases.
+func (app *Application) generateLocalDescription(w http.ResponseWriter, r *http.Request) {
+	if app.localGit == nil {
+		app.writeError(w, r, "Describing local repositories is not enabled on this server", http.StatusNotFound)
+		return
+	}
+
+	var req localgit.Request
+	if err := r.ParseForm(); err == nil {
+		req.RepoPath = r.FormValue("repoPath")
+		req.Base = r.FormValue("base")
+		req.Head = r.FormValue("head")
+	}
+	if req.RepoPath == "" {
+		json.NewDecoder(r.Body).Decode(&req)
+	}
+	req.RepoPath = strings.TrimSpace(req.RepoPath)
+	req.Base = strings.TrimSpace(req.Base)
+	req.Head = strings.TrimSpace(req.Head)
+	if req.Head == "" {
+		req.Head = "HEAD"
+	}
+	if req.RepoPath == "" || req.Base == "" {
+		app.writeError(w, r, "Repository path and base are required", http.StatusBadRequest)
+		return
+	}
+
+	prData, err := app.localGit.FetchChangeRequest(r.Context(), req)
+	if err != nil {
+		log.Printf("Error reading local repository %s: %v", req.RepoPath, err)
+		reqErr := localGitRequestError(err)
+		app.writeError(w, r, reqErr.message, reqErr.status)
+		return
+	}
+
+	var userID string
+	if user := GetUserFromContext(r.Context()); user != nil {
+		userID = user.ID
+	}
+	settings, err := app.resolveSettings(r.Context(), prData.Host, prData.Repository, userID)
+	if err != nil {
+		log.Printf("Error resolving generation settings: %v", err)
+		app.writeError(w, r, "Failed to load generation settings", http.StatusInternalServerError)
+		return
+	}
+
+	generation, err := app.llmService.GeneratePRDescription(r.Context(), prData, settings, func(done, total int) {
+		log.Printf("Generating description for local branch %s of %s: %d/%d stages done", req.Head, prData.Repository, done, total)
+	})
+	if err != nil {
+		log.Printf("Error generating description: %v", err)
+		app.writeError(w, r, "Failed to generate description. Please try again.", http.StatusInternalServerError)
+		return
+	}
+	description := app.saveGeneration(r.Context(), prData, generation)
+
+	// Return JSON for API clients that ask for it
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(GeneratePRDescriptionResponse{PRDescription: description})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	templates.PrDescriptionResult(description, false).Render(r.Context(), w)
+}
+
+// localGitRequestError maps local repository errors to HTTP status codes and user-facing messages
+func localGitRequestError(err error) *requestError {
+	switch {
+	case errors.Is(err, localgit.ErrOutsideRoots):
+		return &requestError{status: http.StatusForbidden, message: "This repository is outside the directories the server may read (LOCAL_GIT_ROOTS)."}
+	case errors.Is(err, localgit.ErrNotRepository):
+		return &requestError{status: http.StatusBadRequest, message: "No git repository was found at this path."}
+	case errors.Is(err, localgit.ErrUnknownRevision), errors.Is(err, localgit.ErrNoChanges), errors.Is(err, localgit.ErrUnrelatedHistories):
+		return &requestError{status: http.StatusBadRequest, message: err.Error()}
+	default:
+		return &requestError{status: http.StatusInternalServerError, message: "Failed to read the local repository"}
+	}
+}
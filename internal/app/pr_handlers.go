@@ -1,68 +1,266 @@
 package app
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+	"github.com/nahue/pr-toolbox-go/internal/database"
+	githubsvc "github.com/nahue/pr-toolbox-go/internal/github"
+	"github.com/nahue/pr-toolbox-go/internal/llm"
 	"github.com/nahue/pr-toolbox-go/templates"
 )
 
 // servePrDescriptions handles GET /
 func (app *Application) servePrDescriptions(w http.ResponseWriter, r *http.Request) {
-	component := templates.PrDescriptions()
+	component := templates.PrDescriptions(app.localGit != nil)
 	component.Render(r.Context(), w)
 }
 
 // generatePRDescription handles POST /api/generate-pr-description
 func (app *Application) generatePRDescription(w http.ResponseWriter, r *http.Request) {
-	var prUrl string
+	var req GeneratePRDescriptionRequest
 
 	// Handle POST requests - try to parse form data first, then JSON
 	if err := r.ParseForm(); err == nil {
 		// Try to get from form data
-		prUrl = r.FormValue("prUrl")
+		req.PRUrl = r.FormValue("prUrl")
+		req.Regenerate, _ = strconv.ParseBool(r.FormValue("regenerate"))
 	}
 
 	// If no form data found, try JSON
-	if prUrl == "" {
-		var req GeneratePRDescriptionRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err == nil {
-			prUrl = req.PRUrl
-		}
+	if req.PRUrl == "" {
+		json.NewDecoder(r.Body).Decode(&req)
+	}
+
+	genReq, reqErr := app.parseGenerationRequest(r.Context(), req.PRUrl, req.Regenerate)
+	if reqErr != nil {
+		app.writeRequestError(w, r, reqErr)
+		return
 	}
 
-	if prUrl == "" {
-		http.Error(w, "PR URL is required", http.StatusBadRequest)
+	description, cached, reqErr := app.describe(r.Context(), genReq)
+	if reqErr != nil {
+		app.writeRequestError(w, r, reqErr)
 		return
 	}
 
-	// Parse GitHub URL to extract owner, repo, and PR number
-	owner, repo, prNumber, err := app.githubService.ParseGitHubURL(prUrl)
+	// Return JSON for API clients that ask for it
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(GeneratePRDescriptionResponse{PRDescription: description, Cached: cached})
+		return
+	}
+
+	// Return HTML for Alpine AJAX
+	w.Header().Set("Content-Type", "text/html")
+	component := templates.PrDescriptionResult(description, cached)
+	component.Render(r.Context(), w)
+}
+
+// streamPRDescription handles GET /api/generate-pr-description/stream. It sends
+// Server-Sent Events: "progress" as stages complete, "token" for each piece of
+// the description, then "done" with the rendered result, or "error". Closing
+// the connection cancels the upstream LLM request.
+func (app *Application) streamPRDescription(w http.ResponseWriter, r *http.Request) {
+	sse, err := newSSEWriter(w)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Invalid GitHub PR URL: %v", err), http.StatusBadRequest)
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
 		return
 	}
 
-	// Fetch GitHub PR data
-	prData, err := app.githubService.FetchPRData(owner, repo, prNumber)
+	regenerate, _ := strconv.ParseBool(r.URL.Query().Get("regenerate"))
+	genReq, reqErr := app.parseGenerationRequest(r.Context(), r.URL.Query().Get("prUrl"), regenerate)
+	if reqErr != nil {
+		sse.send("error", map[string]any{"message": reqErr.message, "status": reqErr.status})
+		return
+	}
+
+	// Serve the stored description when the PR has not changed since it was generated
+	description := app.cachedGeneration(r.Context(), genReq)
+	cached := description != nil
+
+	if !cached {
+		prData, reqErr := app.fetchPRData(r.Context(), genReq)
+		if reqErr != nil {
+			sse.send("error", map[string]any{"message": reqErr.message, "status": reqErr.status})
+			return
+		}
+
+		generation, err := app.llmService.StreamPRDescription(r.Context(), prData, genReq.settings,
+			func(done, total int) {
+				sse.send("progress", map[string]int{"done": done, "total": total})
+			},
+			func(token string) error {
+				return sse.send("token", map[string]string{"text": token})
+			},
+		)
+		if err != nil {
+			if r.Context().Err() != nil {
+				log.Printf("Description stream for %s#%d cancelled by client", prData.Repository, prData.PRNumber)
+				return
+			}
+			log.Printf("Error streaming description: %v", err)
+			sse.send("error", map[string]any{"message": "Failed to generate description. Please try again.", "status": http.StatusInternalServerError})
+			return
+		}
+		description = app.saveGeneration(r.Context(), prData, generation)
+	}
+
+	var html strings.Builder
+	if err := templates.PrDescriptionResult(description, cached).Render(r.Context(), &html); err != nil {
+		log.Printf("Error rendering description: %v", err)
+	}
+	sse.send("done", map[string]any{
+		"html":       html.String(),
+		"generation": GeneratePRDescriptionResponse{PRDescription: description, Cached: cached},
+	})
+}
+
+// handleParsePRRef handles GET /api/pr-ref. It resolves the ref query
+// parameter the same way generation requests are, so the form can show which
+// pull request a URL or shorthand refers to before it is submitted.
+func (app *Application) handleParsePRRef(w http.ResponseWriter, r *http.Request) {
+	ref, provider, err := app.codeHosts.ParseRef(r.URL.Query().Get("ref"))
 	if err != nil {
-		log.Printf("Error fetching GitHub PR data: %v", err)
-		http.Error(w, "Failed to fetch PR data", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// Generate description using OpenAI service
-	description, err := app.openaiService.GeneratePRDescription(prData)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(PRRefResponse{Ref: ref, Name: ref.String(), URL: provider.URL(ref), Provider: provider.Name()})
+}
+
+// describe returns the description for the pull request: the cached one when
+// the PR has not changed since it was generated, otherwise a newly generated
+// and stored one. cached reports which.
+func (app *Application) describe(ctx context.Context, req *generationRequest) (description *database.PRDescription, cached bool, reqErr *requestError) {
+	if description := app.cachedGeneration(ctx, req); description != nil {
+		return description, true, nil
+	}
+
+	prData, reqErr := app.fetchPRData(ctx, req)
+	if reqErr != nil {
+		return nil, false, reqErr
+	}
+
+	// Generate description using the configured LLM provider
+	generation, err := app.llmService.GeneratePRDescription(ctx, prData, req.settings, func(done, total int) {
+		log.Printf("Generating description for %s#%d: %d/%d stages done", prData.Repository, prData.PRNumber, done, total)
+	})
 	if err != nil {
 		log.Printf("Error generating description: %v", err)
-		http.Error(w, "Failed to generate description", http.StatusInternalServerError)
+		return nil, false, &requestError{status: http.StatusInternalServerError, message: "Failed to generate description. Please try again."}
+	}
+
+	return app.saveGeneration(ctx, prData, generation), false, nil
+}
+
+// generationRequest is a validated request to describe a pull request
+type generationRequest struct {
+	ref        codehost.Ref
+	settings   llm.Settings
+	regenerate bool // skip the cache
+}
+
+// requestError is a failure together with the HTTP status and user-facing message it maps to
+type requestError struct {
+	status     int
+	message    string
+	retryAfter int // seconds, only set for rate limiting
+}
+
+// parseGenerationRequest parses the PR reference and resolves the generation
+// settings for the repository and the current user
+func (app *Application) parseGenerationRequest(ctx context.Context, prUrl string, regenerate bool) (*generationRequest, *requestError) {
+	if strings.TrimSpace(prUrl) == "" {
+		return nil, &requestError{status: http.StatusBadRequest, message: "PR URL is required"}
+	}
+
+	// Parse the URL or shorthand to extract host, owner, repo, and PR number
+	ref, _, err := app.codeHosts.ParseRef(prUrl)
+	if err != nil {
+		return nil, &requestError{status: http.StatusBadRequest, message: fmt.Sprintf("Invalid pull request reference: %v", err)}
+	}
+	req := &generationRequest{ref: ref, regenerate: regenerate}
+
+	// Resolve the generation settings for this repository and user
+	var userID string
+	if user := GetUserFromContext(ctx); user != nil {
+		userID = user.ID
+	}
+	req.settings, err = app.resolveSettings(ctx, ref.Host, ref.Repository(), userID)
+	if err != nil {
+		log.Printf("Error resolving generation settings: %v", err)
+		return nil, &requestError{status: http.StatusInternalServerError, message: "Failed to load generation settings"}
+	}
+
+	return req, nil
+}
+
+// fetchPRData fetches the code host data needed to describe the pull request
+func (app *Application) fetchPRData(ctx context.Context, req *generationRequest) (*codehost.ChangeRequest, *requestError) {
+	prData, err := app.codeHosts.FetchChangeRequest(ctx, req.ref)
+	if err != nil {
+		log.Printf("Error fetching PR data: %v", err)
+		return nil, codeHostRequestError(err)
+	}
+	return prData, nil
+}
+
+// codeHostRequestError maps code host errors to HTTP status codes and user-facing messages
+func codeHostRequestError(err error) *requestError {
+	var rateLimitErr *codehost.RateLimitError
+	var networkErr *codehost.NetworkError
+
+	switch {
+	case errors.As(err, &rateLimitErr):
+		return &requestError{
+			status:     http.StatusTooManyRequests,
+			message:    fmt.Sprintf("The %s rate limit was exceeded. Please try again after %s.", rateLimitErr.Host, rateLimitErr.Reset.Local().Format("15:04:05")),
+			retryAfter: max(int(time.Until(rateLimitErr.Reset).Seconds()), 1),
+		}
+	case errors.Is(err, codehost.ErrUnknownHost):
+		return &requestError{status: http.StatusBadRequest, message: "This code host is not configured on the server."}
+	case errors.Is(err, githubsvc.ErrNotConnected):
+		return &requestError{status: http.StatusForbidden, message: "Connect your GitHub account on the Settings page to access pull requests."}
+	case errors.Is(err, codehost.ErrNotFound):
+		return &requestError{status: http.StatusNotFound, message: "Pull request not found. Check the URL, or make sure the configured token can access this repository."}
+	case errors.Is(err, codehost.ErrUnauthorized):
+		return &requestError{status: http.StatusBadGateway, message: "The code host rejected the server's credentials. Please check the configured token (GITHUB_TOKEN, GITLAB_TOKEN, ...)."}
+	case errors.Is(err, codehost.ErrForbidden):
+		return &requestError{status: http.StatusForbidden, message: "The code host denied access to this pull request with the configured credentials."}
+	case errors.As(err, &networkErr):
+		return &requestError{status: http.StatusServiceUnavailable, message: fmt.Sprintf("Could not reach %s. Please try again later.", networkErr.Host)}
+	default:
+		return &requestError{status: http.StatusInternalServerError, message: "Failed to fetch PR data"}
+	}
+}
+
+// writeRequestError writes a requestError, including the Retry-After header when set
+func (app *Application) writeRequestError(w http.ResponseWriter, r *http.Request, reqErr *requestError) {
+	if reqErr.retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(reqErr.retryAfter))
+	}
+	app.writeError(w, r, reqErr.message, reqErr.status)
+}
+
+// writeError renders an error fragment for Alpine AJAX requests and a plain-text error otherwise
+func (app *Application) writeError(w http.ResponseWriter, r *http.Request, message string, status int) {
+	if r.Header.Get("X-Alpine-Request") == "" {
+		http.Error(w, message, status)
 		return
 	}
 
-	// Return HTML for Alpine AJAX
 	w.Header().Set("Content-Type", "text/html")
-	component := templates.PrDescriptionResult(description)
+	w.WriteHeader(status)
+	component := templates.PrDescriptionError(message)
 	component.Render(r.Context(), w)
 }
@@ -0,0 +1,124 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/nahue/pr-toolbox-go/internal/database"
+	githubsvc "github.com/nahue/pr-toolbox-go/internal/github"
+	"github.com/nahue/pr-toolbox-go/templates"
+)
+
+// ReleaseNotesRequest is the body of POST /api/generate-release-notes: either
+// a compare URL, or a repository with the refs (usually tags) to compare
+type ReleaseNotesRequest struct {
+	CompareURL string `json:"compareUrl"`
+	Repository string `json:"repository"` // owner/repo, or host/owner/repo outside github.com
+	From       string `json:"from"`
+	To         string `json:"to"`
+}
+
+// handleReleaseNotesPage handles GET /release-notes
+func (app *Application) handleReleaseNotesPage(w http.ResponseWriter, r *http.Request) {
+	component := templates.ReleaseNotes()
+	component.Render(r.Context(), w)
+}
+
+// generateReleaseNotes handles POST /api/generate-release-notes. It writes a
+// changelog of the pull requests merged between two refs and stores it in the
+// history. Release notes are never served from the cache: the pull requests
+// of a range can change after the fact, when they are relabelled or retitled.
+func (app *Application) generateReleaseNotes(w http.ResponseWriter, r *http.Request) {
+	var req ReleaseNotesRequest
+	if err := r.ParseForm(); err == nil {
+		req.CompareURL = r.FormValue("compareUrl")
+		req.Repository = r.FormValue("repository")
+		req.From = r.FormValue("from")
+		req.To = r.FormValue("to")
+	}
+	if req.CompareURL == "" && req.Repository == "" {
+		json.NewDecoder(r.Body).Decode(&req)
+	}
+
+	var ref githubsvc.CompareRef
+	var err error
+	switch {
+	case strings.TrimSpace(req.CompareURL) != "":
+		ref, err = githubsvc.ParseCompareRef(req.CompareURL)
+	case strings.TrimSpace(req.Repository) != "":
+		ref, err = githubsvc.NewCompareRef(req.Repository, req.From, req.To)
+	default:
+		app.writeError(w, r, "A compare URL, or a repository with from and to refs, is required", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		app.writeError(w, r, fmt.Sprintf("Invalid release range: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	github, reqErr := app.githubFor(ref.Host)
+	if reqErr != nil {
+		app.writeRequestError(w, r, reqErr)
+		return
+	}
+	release, err := github.FetchRelease(r.Context(), ref)
+	if err != nil {
+		log.Printf("Error fetching release %s: %v", ref, err)
+		app.writeRequestError(w, r, compareRequestError(err))
+		return
+	}
+	log.Printf("Writing release notes for %s: %d commits, %d pull requests", ref, release.Commits, len(release.PullRequests))
+
+	var userID string
+	if user := GetUserFromContext(r.Context()); user != nil {
+		userID = user.ID
+	}
+	settings, err := app.resolveSettings(r.Context(), release.Host, release.Repository, userID)
+	if err != nil {
+		log.Printf("Error resolving generation settings: %v", err)
+		app.writeError(w, r, "Failed to load generation settings", http.StatusInternalServerError)
+		return
+	}
+
+	generation, err := app.llmService.GenerateReleaseNotes(r.Context(), release, settings)
+	if err != nil {
+		log.Printf("Error generating release notes: %v", err)
+		app.writeError(w, r, "Failed to generate release notes. Please try again.", http.StatusInternalServerError)
+		return
+	}
+
+	description := &database.PRDescription{
+		Kind:             database.GenerationKindReleaseNotes,
+		Host:             release.Host,
+		Repository:       release.Repository,
+		URL:              release.URL,
+		BaseRef:          release.From,
+		HeadRef:          release.To,
+		HeadSHA:          release.HeadSHA,
+		Provider:         generation.Provider,
+		Model:            generation.Settings.Model,
+		Temperature:      float32ToFloat64(generation.Settings.Temperature),
+		MaxTokens:        generation.Settings.MaxTokens,
+		PromptVersion:    generation.PromptVersion,
+		Description:      generation.Description,
+		PromptTokens:     generation.Usage.PromptTokens,
+		CompletionTokens: generation.Usage.CompletionTokens,
+		UserID:           userID,
+	}
+	if err := app.db.CreatePRDescription(description); err != nil {
+		log.Printf("Error saving release notes for %s: %v", ref, err)
+	}
+
+	// Return JSON for API clients that ask for it
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(GeneratePRDescriptionResponse{PRDescription: description})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	templates.ReleaseNotesResult(description).Render(r.Context(), w)
+}
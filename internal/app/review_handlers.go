@@ -0,0 +1,187 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+	githubsvc "github.com/nahue/pr-toolbox-go/internal/github"
+	"github.com/nahue/pr-toolbox-go/internal/llm"
+	"github.com/nahue/pr-toolbox-go/templates"
+)
+
+// defaultReviewBody is the review summary used when none is written, since
+// GitHub requires one for comment-only reviews
+const defaultReviewBody = "Comments from a first-pass review of the diff."
+
+// ReviewResponse is a generated review of a pull request. The comments are
+// written against HeadSHA, which submitting them should pass as the commit.
+type ReviewResponse struct {
+	codehost.Ref
+	URL     string `json:"url"`
+	HeadSHA string `json:"head_sha"`
+	*llm.Review
+}
+
+// SubmitReviewRequest is the body of POST /api/submit-review: the comments a
+// person accepted, possibly edited, from a generated review
+type SubmitReviewRequest struct {
+	PRUrl    string                    `json:"prUrl"`
+	CommitID string                    `json:"commitId"`
+	Body     string                    `json:"body"`
+	Comments []*codehost.ReviewComment `json:"comments"`
+}
+
+// SubmitReviewResponse is the review created on the pull request
+type SubmitReviewResponse struct {
+	URL      string `json:"url"`
+	Comments int    `json:"comments"`
+}
+
+// handleReviewPage handles GET /review
+func (app *Application) handleReviewPage(w http.ResponseWriter, r *http.Request) {
+	component := templates.Review()
+	component.Render(r.Context(), w)
+}
+
+// generateReview handles POST /api/generate-review. It asks the model for
+// issues in each changed file of a GitHub pull request and returns them as
+// line comments to be accepted or discarded; nothing is posted or stored.
+func (app *Application) generateReview(w http.ResponseWriter, r *http.Request) {
+	var req GeneratePRDescriptionRequest
+	if err := r.ParseForm(); err == nil {
+		req.PRUrl = r.FormValue("prUrl")
+	}
+	if req.PRUrl == "" {
+		json.NewDecoder(r.Body).Decode(&req)
+	}
+
+	genReq, reqErr := app.parseGenerationRequest(r.Context(), req.PRUrl, false)
+	if reqErr != nil {
+		app.writeRequestError(w, r, reqErr)
+		return
+	}
+	github, reqErr := app.githubFor(genReq.ref.Host)
+	if reqErr != nil {
+		app.writeRequestError(w, r, reqErr)
+		return
+	}
+	prData, reqErr := app.fetchPRData(r.Context(), genReq)
+	if reqErr != nil {
+		app.writeRequestError(w, r, reqErr)
+		return
+	}
+
+	review, err := app.llmService.GenerateReview(r.Context(), prData, genReq.settings, func(done, total int) {
+		log.Printf("Reviewing %s: %d/%d files done", genReq.ref, done, total)
+	})
+	if err != nil {
+		log.Printf("Error generating review: %v", err)
+		app.writeError(w, r, "Failed to generate the review. Please try again.", http.StatusInternalServerError)
+		return
+	}
+
+	// Return JSON for API clients that ask for it
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(ReviewResponse{Ref: genReq.ref, URL: prData.URL, HeadSHA: prData.HeadSHA, Review: review})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	templates.ReviewResult(templates.ReviewData{
+		PRUrl:    req.PRUrl,
+		Name:     genReq.ref.String(),
+		URL:      prData.URL,
+		HeadSHA:  prData.HeadSHA,
+		CanWrite: github.CheckWrite(r.Context(), genReq.ref.Host, genReq.ref.Owner) == nil,
+		Review:   review,
+	}).Render(r.Context(), w)
+}
+
+// handleSubmitReview handles POST /api/submit-review. The form posts the
+// indices of the accepted comments in "accept", and each comment's fields as
+// "path-N", "line-N", "side-N" and "body-N".
+func (app *Application) handleSubmitReview(w http.ResponseWriter, r *http.Request) {
+	var req SubmitReviewRequest
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			app.writeApplyError(w, r, &requestError{status: http.StatusBadRequest, message: "Invalid request"})
+			return
+		}
+	} else if err := r.ParseForm(); err == nil {
+		req.PRUrl = r.FormValue("prUrl")
+		req.CommitID = r.FormValue("commitId")
+		req.Body = r.FormValue("body")
+		for _, index := range r.Form["accept"] {
+			line, _ := strconv.Atoi(r.FormValue("line-" + index))
+			req.Comments = append(req.Comments, &codehost.ReviewComment{
+				Path: r.FormValue("path-" + index),
+				Line: line,
+				Side: r.FormValue("side-" + index),
+				Body: r.FormValue("body-" + index),
+			})
+		}
+	}
+
+	ref, _, err := app.codeHosts.ParseRef(req.PRUrl)
+	if err != nil {
+		app.writeApplyError(w, r, &requestError{status: http.StatusBadRequest, message: fmt.Sprintf("Invalid pull request reference: %v", err)})
+		return
+	}
+	if len(req.Comments) == 0 {
+		app.writeApplyError(w, r, &requestError{status: http.StatusBadRequest, message: "Accept at least one comment to submit a review."})
+		return
+	}
+	for _, comment := range req.Comments {
+		comment.Body = strings.TrimSpace(comment.Body)
+		if comment.Path == "" || comment.Line <= 0 || (comment.Side != codehost.SideLeft && comment.Side != codehost.SideRight) || comment.Body == "" {
+			app.writeApplyError(w, r, &requestError{status: http.StatusBadRequest, message: "Every accepted comment needs a path, a line, a side (LEFT or RIGHT) and some text."})
+			return
+		}
+	}
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		body = defaultReviewBody
+	}
+
+	github, reqErr := app.githubFor(ref.Host)
+	if reqErr != nil {
+		app.writeApplyError(w, r, reqErr)
+		return
+	}
+	url, err := github.CreateReview(r.Context(), ref, req.CommitID, body, req.Comments)
+	if err != nil {
+		log.Printf("Error creating review on %s: %v", ref, err)
+		app.writeApplyError(w, r, reviewRequestError(err))
+		return
+	}
+	log.Printf("Review with %d comments submitted on %s", len(req.Comments), ref)
+
+	response := SubmitReviewResponse{URL: url, Comments: len(req.Comments)}
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	component := templates.ReviewSubmitted(response.URL, response.Comments)
+	component.Render(r.Context(), w)
+}
+
+// reviewRequestError maps errors from submitting a review to HTTP status codes and user-facing messages
+func reviewRequestError(err error) *requestError {
+	switch {
+	case errors.Is(err, codehost.ErrReadOnly):
+		return &requestError{status: http.StatusForbidden, message: "Submitting reviews needs a token with write access to pull requests (GITHUB_TOKEN), and this server only has read access to this host."}
+	case errors.Is(err, githubsvc.ErrReviewRejected):
+		return &requestError{status: http.StatusUnprocessableEntity, message: err.Error()}
+	default:
+		return applyRequestError(err)
+	}
+}
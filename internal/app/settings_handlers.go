@@ -0,0 +1,455 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+	"github.com/nahue/pr-toolbox-go/internal/database"
+	githubsvc "github.com/nahue/pr-toolbox-go/internal/github"
+	"github.com/nahue/pr-toolbox-go/internal/llm"
+	"github.com/nahue/pr-toolbox-go/templates"
+)
+
+// SettingsRequest is the body of POST /api/settings. Empty fields are inherited
+// from the less specific scopes; a request with every field empty removes the override.
+// Without a host, the repository is on github.com unless its name starts with
+// a configured host.
+type SettingsRequest struct {
+	Scope       string `json:"scope"`
+	Host        string `json:"host"`
+	Repository  string `json:"repository"`
+	Model       string `json:"model"`
+	Temperature string `json:"temperature"`
+	MaxTokens   string `json:"max_tokens"`
+}
+
+// WebhookSettingsRequest is the body of POST /api/settings/webhooks. An empty
+// mode opts the repository out. Without a host, the repository is on
+// github.com unless it is named "host/owner/repo".
+type WebhookSettingsRequest struct {
+	Host       string `json:"host"`
+	Repository string `json:"repository"`
+	Mode       string `json:"mode"`
+}
+
+// EffectiveSettingsResponse is returned by GET /api/settings
+type EffectiveSettingsResponse struct {
+	Provider  string                         `json:"provider"`
+	Models    []string                       `json:"models"`
+	Defaults  llm.Settings                   `json:"defaults"`
+	Global    *database.GenerationSettings   `json:"global,omitempty"`
+	Repos     []*database.GenerationSettings `json:"repositories"`
+	User      *database.GenerationSettings   `json:"user,omitempty"`
+	Effective *llm.Settings                  `json:"effective,omitempty"`
+	Webhooks  []*database.WebhookSettings    `json:"webhooks"`
+}
+
+// resolveSettings merges the generation settings from least to most specific:
+// provider defaults, then global, repository and user overrides. Stored models
+// the provider no longer offers are ignored.
+func (app *Application) resolveSettings(ctx context.Context, host, repository, userID string) (llm.Settings, error) {
+	settings := app.llmService.DefaultSettings()
+
+	layers := []struct{ scope, key string }{
+		{database.SettingsScopeGlobal, ""},
+		{database.SettingsScopeRepository, settingsRepositoryKey(host, repository)},
+		{database.SettingsScopeUser, userID},
+	}
+
+	for _, layer := range layers {
+		override, err := app.db.GetGenerationSettings(layer.scope, layer.key)
+		if err != nil {
+			return settings, err
+		}
+		if override == nil {
+			continue
+		}
+
+		if override.Model != nil {
+			if err := app.llmService.ValidateModel(ctx, *override.Model); err != nil {
+				log.Printf("Warning: ignoring %s generation settings model: %v", layer.scope, err)
+			} else {
+				settings.Model = *override.Model
+			}
+		}
+		if override.Temperature != nil {
+			settings.Temperature = float32(*override.Temperature)
+		}
+		if override.MaxTokens != nil {
+			settings.MaxTokens = *override.MaxTokens
+		}
+	}
+
+	return settings, nil
+}
+
+// handleSettingsPage handles GET /settings
+func (app *Application) handleSettingsPage(w http.ResponseWriter, r *http.Request) {
+	user := GetUserFromContext(r.Context())
+	data, err := app.loadSettings(r.Context(), user, "", "")
+	if err != nil {
+		log.Printf("Error loading settings: %v", err)
+		http.Error(w, "Failed to load settings", http.StatusInternalServerError)
+		return
+	}
+
+	component := templates.SettingsPage(templates.SettingsPageData{
+		Provider: data.Provider,
+		Models:   data.Models,
+		Defaults: data.Defaults,
+		Global:   data.Global,
+		Repos:    data.Repos,
+		User:     data.User,
+		Webhooks: data.Webhooks,
+		GitHub:   app.loadGitHubAccount(user),
+		Error:    r.URL.Query().Get("error"),
+		Saved:    r.URL.Query().Get("saved") != "",
+	})
+	component.Render(r.Context(), w)
+}
+
+// handleGetSettings handles GET /api/settings
+func (app *Application) handleGetSettings(w http.ResponseWriter, r *http.Request) {
+	var host, repository string
+	if value := r.URL.Query().Get("repository"); value != "" {
+		var err error
+		host, repository, err = app.normalizeSettingsRepository(r.URL.Query().Get("host"), value)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	data, err := app.loadSettings(r.Context(), GetUserFromContext(r.Context()), host, repository)
+	if err != nil {
+		log.Printf("Error loading settings: %v", err)
+		http.Error(w, "Failed to load settings", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(data)
+}
+
+// handleSaveSettings handles POST /api/settings
+func (app *Application) handleSaveSettings(w http.ResponseWriter, r *http.Request) {
+	var req SettingsRequest
+	isJSON := strings.HasPrefix(r.Header.Get("Content-Type"), "application/json")
+	if isJSON {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+	} else {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+		req = SettingsRequest{
+			Scope:       r.FormValue("scope"),
+			Host:        r.FormValue("host"),
+			Repository:  r.FormValue("repository"),
+			Model:       r.FormValue("model"),
+			Temperature: r.FormValue("temperature"),
+			MaxTokens:   r.FormValue("max_tokens"),
+		}
+	}
+
+	if err := app.saveSettings(r.Context(), GetUserFromContext(r.Context()), req); err != nil {
+		if isJSON {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Redirect(w, r, "/settings?error="+url.QueryEscape(err.Error()), http.StatusSeeOther)
+		return
+	}
+
+	if isJSON {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	http.Redirect(w, r, "/settings?saved=1", http.StatusSeeOther)
+}
+
+// handleSaveWebhookSettings handles POST /api/settings/webhooks
+func (app *Application) handleSaveWebhookSettings(w http.ResponseWriter, r *http.Request) {
+	var req WebhookSettingsRequest
+	isJSON := strings.HasPrefix(r.Header.Get("Content-Type"), "application/json")
+	if isJSON {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+	} else {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+		req = WebhookSettingsRequest{
+			Host:       r.FormValue("host"),
+			Repository: r.FormValue("repository"),
+			Mode:       r.FormValue("mode"),
+		}
+	}
+
+	if reqErr := app.saveWebhookSettings(r.Context(), req); reqErr != nil {
+		if isJSON {
+			http.Error(w, reqErr.message, reqErr.status)
+			return
+		}
+		http.Redirect(w, r, "/settings?error="+url.QueryEscape(reqErr.message), http.StatusSeeOther)
+		return
+	}
+
+	if isJSON {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	http.Redirect(w, r, "/settings?saved=1", http.StatusSeeOther)
+}
+
+// loadSettings collects every settings layer visible to the user, and the
+// settings they would get for the repository when one is given
+func (app *Application) loadSettings(ctx context.Context, user *AuthUser, host, repository string) (*EffectiveSettingsResponse, error) {
+	models, err := app.llmService.Models(ctx)
+	if err != nil {
+		log.Printf("Warning: failed to list models: %v", err)
+		models = []string{app.llmService.DefaultSettings().Model}
+	}
+
+	global, err := app.db.GetGenerationSettings(database.SettingsScopeGlobal, "")
+	if err != nil {
+		return nil, err
+	}
+
+	repos, err := app.db.ListGenerationSettings(database.SettingsScopeRepository)
+	if err != nil {
+		return nil, err
+	}
+
+	webhooks, err := app.db.ListWebhookSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	data := &EffectiveSettingsResponse{
+		Provider: app.llmService.ProviderName(),
+		Models:   models,
+		Defaults: app.llmService.DefaultSettings(),
+		Global:   global,
+		Repos:    repos,
+		Webhooks: webhooks,
+	}
+
+	if user != nil {
+		data.User, err = app.db.GetGenerationSettings(database.SettingsScopeUser, user.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if repository != "" && user != nil {
+		effective, err := app.resolveSettings(ctx, host, repository, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		data.Effective = &effective
+	}
+
+	return data, nil
+}
+
+// saveWebhookSettings validates and stores a repository's webhook mode, or opts
+// it out when the mode is empty. Modes that write to pull requests act with
+// the server's credentials, so turning them on or off needs write access to
+// the repository, checked with the user's own token when they have one.
+func (app *Application) saveWebhookSettings(ctx context.Context, req WebhookSettingsRequest) *requestError {
+	host, repository, err := normalizeWebhookRepository(req.Host, req.Repository)
+	if err != nil {
+		return &requestError{status: http.StatusBadRequest, message: err.Error()}
+	}
+
+	switch req.Mode {
+	case "", database.WebhookModeComment, database.WebhookModeBody, database.WebhookModeStore:
+	default:
+		return &requestError{status: http.StatusBadRequest, message: fmt.Sprintf("invalid webhook mode %q (expected comment, body or store)", req.Mode)}
+	}
+
+	current, err := app.db.GetWebhookSettings(host, repository)
+	if err != nil {
+		log.Printf("Error loading webhook settings: %v", err)
+		return &requestError{status: http.StatusInternalServerError, message: "Failed to load webhook settings"}
+	}
+	if webhookModeWrites(req.Mode) || (current != nil && webhookModeWrites(current.Mode)) {
+		if reqErr := app.checkWebhookWrite(ctx, host, repository); reqErr != nil {
+			return reqErr
+		}
+	}
+
+	if req.Mode == "" {
+		err = app.db.DeleteWebhookSettings(host, repository)
+	} else {
+		err = app.db.UpsertWebhookSettings(host, repository, req.Mode)
+	}
+	if err != nil {
+		log.Printf("Error saving webhook settings: %v", err)
+		return &requestError{status: http.StatusInternalServerError, message: "Failed to save webhook settings"}
+	}
+	return nil
+}
+
+// webhookModeWrites reports whether a webhook mode writes to pull requests
+func webhookModeWrites(mode string) bool {
+	return mode == database.WebhookModeComment || mode == database.WebhookModeBody
+}
+
+// checkWebhookWrite requires write access to a repository before its webhook
+// mode can write to its pull requests
+func (app *Application) checkWebhookWrite(ctx context.Context, host, repository string) *requestError {
+	github, reqErr := app.githubFor(host)
+	if reqErr != nil {
+		return reqErr
+	}
+	owner, repo := codehost.SplitRepository(repository)
+	err := github.CheckRepositoryWrite(ctx, host, owner, repo)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, codehost.ErrReadOnly):
+		return &requestError{status: http.StatusForbidden, message: "Posting comments and writing PR bodies needs a GITHUB_TOKEN with write access, and this server only has read access to this host."}
+	case errors.Is(err, codehost.ErrForbidden), errors.Is(err, codehost.ErrNotFound):
+		return &requestError{status: http.StatusForbidden, message: fmt.Sprintf("Only users with write access to %s can change how its webhooks write to pull requests.", repository)}
+	default:
+		return codeHostRequestError(err)
+	}
+}
+
+// normalizeRepository validates an "owner/repo" name and lowercases it for use as a settings key
+func normalizeRepository(repository string) (string, error) {
+	repository = strings.ToLower(strings.TrimSpace(repository))
+	if parts := strings.Split(repository, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", fmt.Errorf("repository must be in the form owner/repo")
+	}
+	return repository, nil
+}
+
+// normalizeSettingsRepository validates a repository path of at least
+// "owner/repo", keeping GitLab subgroups, and lowercases it. Without a host,
+// a path starting with a configured host is split at it, and any other path
+// is on github.com.
+func (app *Application) normalizeSettingsRepository(host, repository string) (string, string, error) {
+	host = strings.ToLower(strings.TrimSpace(host))
+	repository = strings.ToLower(strings.Trim(strings.TrimSpace(repository), "/"))
+	if first, rest, ok := strings.Cut(repository, "/"); host == "" && ok {
+		for _, configured := range app.codeHosts.Hosts() {
+			if strings.EqualFold(first, configured) {
+				host, repository = first, rest
+				break
+			}
+		}
+	}
+	if host == "" {
+		host = codehost.DefaultHost
+	}
+
+	parts := strings.Split(repository, "/")
+	if len(parts) < 2 || slices.Contains(parts, "") {
+		return "", "", fmt.Errorf("repository must be in the form owner/repo, group/subgroup/repo or host/owner/repo")
+	}
+	return host, repository, nil
+}
+
+// settingsRepositoryKey keys a repository's generation settings by its host
+// and full path, so the same name on different hosts has its own settings
+func settingsRepositoryKey(host, repository string) string {
+	if host == "" {
+		host = codehost.DefaultHost
+	}
+	return strings.ToLower(host + "/" + repository)
+}
+
+// normalizeWebhookRepository splits the host off a "host/owner/repo" name when
+// no host is given, defaulting to github.com, and lowercases both
+func normalizeWebhookRepository(host, repository string) (string, string, error) {
+	host = strings.ToLower(strings.TrimSpace(host))
+	repository = strings.TrimSpace(repository)
+	if parts := strings.SplitN(repository, "/", 3); host == "" && len(parts) == 3 {
+		host, repository = strings.ToLower(parts[0]), parts[1]+"/"+parts[2]
+	}
+	if host == "" {
+		host = githubsvc.DefaultHost
+	}
+
+	repository, err := normalizeRepository(repository)
+	if err != nil {
+		return "", "", fmt.Errorf("repository must be in the form owner/repo or host/owner/repo")
+	}
+	return host, repository, nil
+}
+
+// saveSettings validates and stores an override, or removes it when every field is empty
+func (app *Application) saveSettings(ctx context.Context, user *AuthUser, req SettingsRequest) error {
+	var scopeKey string
+	switch req.Scope {
+	case database.SettingsScopeGlobal:
+	case database.SettingsScopeRepository:
+		host, repository, err := app.normalizeSettingsRepository(req.Host, req.Repository)
+		if err != nil {
+			return err
+		}
+		scopeKey = settingsRepositoryKey(host, repository)
+	case database.SettingsScopeUser:
+		if user == nil {
+			return fmt.Errorf("authentication required")
+		}
+		scopeKey = user.ID
+	default:
+		return fmt.Errorf("invalid scope %q", req.Scope)
+	}
+
+	var model *string
+	if value := strings.TrimSpace(req.Model); value != "" {
+		if err := app.llmService.ValidateModel(ctx, value); err != nil {
+			return err
+		}
+		model = &value
+	}
+
+	var temperature *float64
+	if value := strings.TrimSpace(req.Temperature); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("temperature must be a number")
+		}
+		if err := llm.ValidateTemperature(float32(parsed)); err != nil {
+			return err
+		}
+		temperature = &parsed
+	}
+
+	var maxTokens *int
+	if value := strings.TrimSpace(req.MaxTokens); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("max tokens must be a whole number")
+		}
+		if err := llm.ValidateMaxTokens(parsed); err != nil {
+			return err
+		}
+		maxTokens = &parsed
+	}
+
+	if model == nil && temperature == nil && maxTokens == nil {
+		return app.db.DeleteGenerationSettings(req.Scope, scopeKey)
+	}
+	return app.db.UpsertGenerationSettings(req.Scope, scopeKey, model, temperature, maxTokens)
+}
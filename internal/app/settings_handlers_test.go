@@ -0,0 +1,239 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+	"github.com/nahue/pr-toolbox-go/internal/database"
+	githubsvc "github.com/nahue/pr-toolbox-go/internal/github"
+	gitlabsvc "github.com/nahue/pr-toolbox-go/internal/gitlab"
+	"github.com/nahue/pr-toolbox-go/internal/llm"
+)
+
+// newTestDatabase creates a database in a temporary directory with every
+// migration applied. NewDatabase opens ./data/pr_toolbox.db, so the test
+// runs in that directory.
+func newTestDatabase(t *testing.T) *database.Database {
+	t.Helper()
+	migrations, err := filepath.Glob(filepath.Join("..", "..", "migrations", "*.sql"))
+	if err != nil || len(migrations) == 0 {
+		t.Fatalf("no migrations found: %v", err)
+	}
+	for i, migration := range migrations {
+		if migrations[i], err = filepath.Abs(migration); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Chdir(t.TempDir())
+	if err := os.MkdirAll("data", 0755); err != nil {
+		t.Fatal(err)
+	}
+	schema, err := sql.Open("sqlite3", "./data/pr_toolbox.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer schema.Close()
+	for _, migration := range migrations {
+		content, err := os.ReadFile(migration)
+		if err != nil {
+			t.Fatal(err)
+		}
+		up, _, _ := strings.Cut(string(content), "-- +goose Down")
+		if _, err := schema.Exec(strings.Replace(up, "-- +goose Up", "", 1)); err != nil {
+			t.Fatalf("failed to apply %s: %v", filepath.Base(migration), err)
+		}
+	}
+
+	db, err := database.NewDatabase()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestNormalizeWebhookRepository(t *testing.T) {
+	tests := []struct {
+		host, repository         string
+		wantHost, wantRepository string
+		wantErr                  bool
+	}{
+		{"", "Acme/Widgets", "github.com", "acme/widgets", false},
+		{"", " GHE.example.com/acme/widgets ", "ghe.example.com", "acme/widgets", false},
+		{"GHE.example.com", "acme/widgets", "ghe.example.com", "acme/widgets", false},
+		{"ghe.example.com", "other.example.com/acme/widgets", "", "", true},
+		{"", "acme", "", "", true},
+		{"", "acme/", "", "", true},
+	}
+
+	for _, tt := range tests {
+		host, repository, err := normalizeWebhookRepository(tt.host, tt.repository)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("normalizeWebhookRepository(%q, %q) error = %v, want error %v", tt.host, tt.repository, err, tt.wantErr)
+			continue
+		}
+		if host != tt.wantHost || repository != tt.wantRepository {
+			t.Errorf("normalizeWebhookRepository(%q, %q) = %q, %q, want %q, %q", tt.host, tt.repository, host, repository, tt.wantHost, tt.wantRepository)
+		}
+	}
+}
+
+func TestWebhookSettingsAreKeptPerHost(t *testing.T) {
+	db := newTestDatabase(t)
+	app := &Application{db: db}
+
+	for _, req := range []WebhookSettingsRequest{
+		{Repository: "acme/widgets", Mode: database.WebhookModeStore},
+		{Repository: "ghe.example.com/acme/widgets", Mode: database.WebhookModeStore},
+	} {
+		if reqErr := app.saveWebhookSettings(context.Background(), req); reqErr != nil {
+			t.Fatal(reqErr.message)
+		}
+	}
+
+	if reqErr := app.saveWebhookSettings(context.Background(), WebhookSettingsRequest{Repository: "acme/widgets"}); reqErr != nil {
+		t.Fatal(reqErr.message)
+	}
+
+	for host, wantSaved := range map[string]bool{"github.com": false, "ghe.example.com": true} {
+		settings, err := db.GetWebhookSettings(host, "acme/widgets")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (settings != nil) != wantSaved {
+			t.Errorf("GetWebhookSettings(%q) = %+v, want saved %v", host, settings, wantSaved)
+		}
+	}
+	if settings, err := db.GetWebhookSettings("other.example.com", "acme/widgets"); err != nil || settings != nil {
+		t.Errorf("GetWebhookSettings(other.example.com) = %+v, %v, want nothing", settings, err)
+	}
+}
+
+func TestWritingWebhookModesNeedRepositoryWrite(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v3/repos/acme/widgets":
+			fmt.Fprint(w, `{"full_name": "acme/widgets", "permissions": {"pull": true, "push": true}}`)
+		case "/api/v3/repos/acme/docs":
+			fmt.Fprint(w, `{"full_name": "acme/docs", "permissions": {"pull": true, "push": false}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GITHUB_DEMO_MODE", "")
+	t.Setenv("GITHUB_ENTERPRISE_HOSTS", "ghe.example.com="+server.URL+"/api/v3/")
+	db := newTestDatabase(t)
+	app := &Application{db: db, codeHosts: codehost.NewRegistry(githubsvc.NewService())}
+	withToken := githubsvc.WithUserToken(context.Background(), "ghe.example.com", "user-token")
+
+	if err := db.UpsertWebhookSettings("github.com", "acme/automated", database.WebhookModeComment); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		req        WebhookSettingsRequest
+		wantStatus int // 0 when the settings are saved
+	}{
+		{"store on a read-only host", context.Background(), WebhookSettingsRequest{Repository: "acme/widgets", Mode: database.WebhookModeStore}, 0},
+		{"body on a read-only host", context.Background(), WebhookSettingsRequest{Repository: "acme/widgets", Mode: database.WebhookModeBody}, http.StatusForbidden},
+		{"opting out of comments on a read-only host", context.Background(), WebhookSettingsRequest{Repository: "acme/automated"}, http.StatusForbidden},
+		{"body with push access", withToken, WebhookSettingsRequest{Repository: "ghe.example.com/acme/widgets", Mode: database.WebhookModeBody}, 0},
+		{"comment without push access", withToken, WebhookSettingsRequest{Repository: "ghe.example.com/acme/docs", Mode: database.WebhookModeComment}, http.StatusForbidden},
+		{"comment on an unknown repository", withToken, WebhookSettingsRequest{Repository: "ghe.example.com/acme/missing", Mode: database.WebhookModeComment}, http.StatusForbidden},
+		{"invalid mode", withToken, WebhookSettingsRequest{Repository: "acme/widgets", Mode: "push"}, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		reqErr := app.saveWebhookSettings(tt.ctx, tt.req)
+		switch {
+		case tt.wantStatus == 0 && reqErr != nil:
+			t.Errorf("%s: saveWebhookSettings() = %d %s, want it saved", tt.name, reqErr.status, reqErr.message)
+		case tt.wantStatus != 0 && (reqErr == nil || reqErr.status != tt.wantStatus):
+			t.Errorf("%s: saveWebhookSettings() = %+v, want status %d", tt.name, reqErr, tt.wantStatus)
+		}
+	}
+
+	if settings, _ := db.GetWebhookSettings("github.com", "acme/automated"); settings == nil || settings.Mode != database.WebhookModeComment {
+		t.Errorf("acme/automated settings = %+v, want them left in comment mode", settings)
+	}
+}
+
+func TestSaveSettingsValidates(t *testing.T) {
+	db := newTestDatabase(t)
+	app := &Application{db: db, codeHosts: codehost.NewRegistry(githubsvc.NewService(), gitlabsvc.NewService()), llmService: llm.NewService(llm.NewFakeProvider())}
+	user := &AuthUser{ID: "user-a"}
+
+	tests := []struct {
+		name    string
+		user    *AuthUser
+		req     SettingsRequest
+		wantErr bool
+	}{
+		{"global", user, SettingsRequest{Scope: database.SettingsScopeGlobal, Temperature: "0.2"}, false},
+		{"repository", user, SettingsRequest{Scope: database.SettingsScopeRepository, Repository: " Acme/Widgets ", Model: "fake-model-large"}, false},
+		{"user", user, SettingsRequest{Scope: database.SettingsScopeUser, MaxTokens: "2000"}, false},
+		{"user without a user", nil, SettingsRequest{Scope: database.SettingsScopeUser, MaxTokens: "2000"}, true},
+		{"unknown scope", user, SettingsRequest{Scope: "team"}, true},
+		{"subgroup on a configured host", user, SettingsRequest{Scope: database.SettingsScopeRepository, Repository: "gitlab.com/Acme/Platform/Widgets", MaxTokens: "3000"}, false},
+		{"repository with a host", user, SettingsRequest{Scope: database.SettingsScopeRepository, Host: "gitlab.com", Repository: "acme/widgets", Temperature: "1.5"}, false},
+		{"bad repository", user, SettingsRequest{Scope: database.SettingsScopeRepository, Repository: "acme", Model: "fake-model"}, true},
+		{"empty path segment", user, SettingsRequest{Scope: database.SettingsScopeRepository, Repository: "acme//widgets", Model: "fake-model"}, true},
+		{"unknown model", user, SettingsRequest{Scope: database.SettingsScopeGlobal, Model: "gpt-2"}, true},
+		{"temperature not a number", user, SettingsRequest{Scope: database.SettingsScopeGlobal, Temperature: "warm"}, true},
+		{"temperature too high", user, SettingsRequest{Scope: database.SettingsScopeGlobal, Temperature: "2.5"}, true},
+		{"negative temperature", user, SettingsRequest{Scope: database.SettingsScopeGlobal, Temperature: "-0.1"}, true},
+		{"max tokens not whole", user, SettingsRequest{Scope: database.SettingsScopeGlobal, MaxTokens: "1.5"}, true},
+		{"max tokens too low", user, SettingsRequest{Scope: database.SettingsScopeGlobal, MaxTokens: "0"}, true},
+		{"max tokens too high", user, SettingsRequest{Scope: database.SettingsScopeGlobal, MaxTokens: "100000"}, true},
+	}
+
+	for _, tt := range tests {
+		err := app.saveSettings(context.Background(), tt.user, tt.req)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: saveSettings() error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+
+	// The layers saved above apply from least to most specific
+	want := llm.Settings{Model: "fake-model-large", Temperature: 0.2, MaxTokens: 2000}
+	if got, err := app.resolveSettings(context.Background(), "github.com", "acme/widgets", "user-a"); err != nil || got != want {
+		t.Errorf("resolveSettings() = %+v, %v, want %+v", got, err, want)
+	}
+	want = llm.Settings{Model: "fake-model", Temperature: 0.2, MaxTokens: 1000}
+	if got, err := app.resolveSettings(context.Background(), "github.com", "acme/other", "user-b"); err != nil || got != want {
+		t.Errorf("resolveSettings() for another repository and user = %+v, %v, want %+v", got, err, want)
+	}
+
+	// Repositories are keyed by host and full path
+	want = llm.Settings{Model: "fake-model", Temperature: 0.2, MaxTokens: 3000}
+	if got, err := app.resolveSettings(context.Background(), "gitlab.com", "acme/platform/widgets", "user-b"); err != nil || got != want {
+		t.Errorf("resolveSettings() for a subgroup = %+v, %v, want %+v", got, err, want)
+	}
+	want = llm.Settings{Model: "fake-model", Temperature: 1.5, MaxTokens: 1000}
+	if got, err := app.resolveSettings(context.Background(), "gitlab.com", "acme/widgets", "user-b"); err != nil || got != want {
+		t.Errorf("resolveSettings() for the same name on another host = %+v, %v, want %+v", got, err, want)
+	}
+
+	// Saving a scope with every field empty removes its override
+	if err := app.saveSettings(context.Background(), user, SettingsRequest{Scope: database.SettingsScopeGlobal}); err != nil {
+		t.Fatal(err)
+	}
+	if global, err := db.GetGenerationSettings(database.SettingsScopeGlobal, ""); err != nil || global != nil {
+		t.Errorf("global settings = %+v, %v, want them removed", global, err)
+	}
+}
@@ -0,0 +1,242 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+	"github.com/nahue/pr-toolbox-go/internal/database"
+	githubsvc "github.com/nahue/pr-toolbox-go/internal/github"
+)
+
+const (
+	// maxWebhookPayload is the largest payload GitHub sends (25 MB)
+	maxWebhookPayload = 25 << 20
+
+	// webhookGenerationTimeout bounds the background work started by a delivery
+	webhookGenerationTimeout = 5 * time.Minute
+
+	// webhookWorkers is how many deliveries are processed at once
+	webhookWorkers = 4
+
+	// webhookQueueSize is how many accepted deliveries can wait for a worker
+	webhookQueueSize = 32
+
+	// webhookClaimStaleAfter is how long a delivery can stay claimed before a
+	// redelivery claims it again, assuming the process handling it died. It
+	// covers the longest a delivery can take: waiting behind a full queue, which
+	// the workers drain webhookQueueSize/webhookWorkers generations at a time,
+	// and behind the generations already running, then its own generation.
+	webhookClaimStaleAfter = (webhookQueueSize/webhookWorkers + 2) * webhookGenerationTimeout
+)
+
+// webhookJob is an accepted pull_request delivery waiting for a worker
+type webhookJob struct {
+	deliveryID string
+	event      *githubsvc.PullRequestEvent
+	mode       string
+}
+
+// startWebhookWorkers starts the workers processing accepted deliveries
+func (app *Application) startWebhookWorkers() {
+	app.webhookJobs = make(chan webhookJob, webhookQueueSize)
+	for range webhookWorkers {
+		go func() {
+			for job := range app.webhookJobs {
+				app.processWebhookJob(job)
+			}
+		}()
+	}
+}
+
+// processWebhookJob describes the pull request of a delivery and settles the
+// delivery, as failed when it can be retried
+func (app *Application) processWebhookJob(job webhookJob) {
+	status := database.WebhookDeliveryDone
+	if err := app.describeFromWebhook(job.deliveryID, job.event, job.mode); err != nil {
+		log.Printf("Webhook delivery %s for %s: %v", job.deliveryID, job.event.Ref(), err)
+		status = database.WebhookDeliveryFailed
+	}
+	app.settleWebhookDelivery(job.deliveryID, status)
+}
+
+// settleWebhookDelivery records the outcome of a claimed delivery
+func (app *Application) settleWebhookDelivery(deliveryID, status string) {
+	if err := app.db.SettleWebhookDelivery(deliveryID, status); err != nil {
+		log.Printf("Error settling webhook delivery %s: %v", deliveryID, err)
+	}
+}
+
+// handleGitHubWebhook handles POST /webhooks/github. Deliveries are verified
+// with the webhook secret and de-duplicated by delivery ID. Descriptions are
+// generated by a fixed pool of workers for pull requests in opted-in
+// repositories, so GitHub gets its answer well within its timeout. A delivery
+// only counts as handled once it has been processed: one that failed, or
+// could not be queued, is processed again when GitHub redelivers it.
+func (app *Application) handleGitHubWebhook(w http.ResponseWriter, r *http.Request) {
+	if len(app.webhookSecret) == 0 {
+		http.Error(w, "Webhooks are not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookPayload))
+	if err != nil {
+		http.Error(w, "Failed to read payload", http.StatusBadRequest)
+		return
+	}
+
+	if err := githubsvc.ValidateWebhookSignature(payload, r.Header.Get("X-Hub-Signature-256"), app.webhookSecret); err != nil {
+		log.Printf("Rejected webhook delivery %s: %v", r.Header.Get("X-GitHub-Delivery"), err)
+		http.Error(w, "Invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	event := r.Header.Get("X-GitHub-Event")
+	deliveryID := r.Header.Get("X-GitHub-Delivery")
+	if deliveryID == "" {
+		http.Error(w, "Missing X-GitHub-Delivery header", http.StatusBadRequest)
+		return
+	}
+
+	if event == "ping" {
+		fmt.Fprintln(w, "pong")
+		return
+	}
+
+	claimed, err := app.db.ClaimWebhookDelivery(deliveryID, event, webhookClaimStaleAfter)
+	if err != nil {
+		log.Printf("Error recording webhook delivery %s: %v", deliveryID, err)
+		http.Error(w, "Failed to record delivery", http.StatusInternalServerError)
+		return
+	}
+	if !claimed {
+		fmt.Fprintln(w, "duplicate delivery ignored")
+		return
+	}
+
+	if event != "pull_request" {
+		app.settleWebhookDelivery(deliveryID, database.WebhookDeliveryDone)
+		fmt.Fprintf(w, "%s events are ignored\n", event)
+		return
+	}
+
+	prEvent, err := githubsvc.ParsePullRequestEvent(payload)
+	if err != nil {
+		log.Printf("Error parsing webhook delivery %s: %v", deliveryID, err)
+		app.settleWebhookDelivery(deliveryID, database.WebhookDeliveryDone)
+		http.Error(w, "Invalid pull_request payload", http.StatusBadRequest)
+		return
+	}
+
+	settings, err := app.db.GetWebhookSettings(prEvent.Host, strings.ToLower(fmt.Sprintf("%s/%s", prEvent.Owner, prEvent.Repo)))
+	if err != nil {
+		log.Printf("Error loading webhook settings: %v", err)
+		app.settleWebhookDelivery(deliveryID, database.WebhookDeliveryFailed)
+		http.Error(w, "Failed to load webhook settings", http.StatusInternalServerError)
+		return
+	}
+	if settings == nil {
+		app.settleWebhookDelivery(deliveryID, database.WebhookDeliveryDone)
+		fmt.Fprintln(w, "repository has not opted in")
+		return
+	}
+
+	if reason := skipWebhookReason(prEvent, settings.Mode); reason != "" {
+		app.settleWebhookDelivery(deliveryID, database.WebhookDeliveryDone)
+		fmt.Fprintln(w, reason)
+		return
+	}
+
+	select {
+	case app.webhookJobs <- webhookJob{deliveryID: deliveryID, event: prEvent, mode: settings.Mode}:
+	default:
+		log.Printf("Webhook delivery %s for %s: queue is full", deliveryID, prEvent.Ref())
+		app.settleWebhookDelivery(deliveryID, database.WebhookDeliveryFailed)
+		http.Error(w, "Too many deliveries in progress, redeliver later", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	fmt.Fprintln(w, "generating description")
+}
+
+// skipWebhookReason explains why a pull_request event is ignored, or returns
+// "" when a description should be generated. Drafts are described once they
+// are marked ready for review, and new pushes refresh stored descriptions and
+// PR bodies but do not post another comment.
+func skipWebhookReason(event *githubsvc.PullRequestEvent, mode string) string {
+	switch event.Action {
+	case "opened", "synchronize":
+		if event.Draft {
+			return "draft pull requests are described when they are ready for review"
+		}
+		if event.Action == "synchronize" && mode == database.WebhookModeComment {
+			return "comments are only posted when a pull request is opened"
+		}
+		return ""
+	case "ready_for_review":
+		return ""
+	default:
+		return fmt.Sprintf("pull_request %s events are ignored", event.Action)
+	}
+}
+
+// describeFromWebhook generates a description for the pull request in the
+// event and delivers it according to the repository's webhook mode. It
+// returns an error when the delivery is worth retrying.
+func (app *Application) describeFromWebhook(deliveryID string, event *githubsvc.PullRequestEvent, mode string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookGenerationTimeout)
+	defer cancel()
+	// Act as the app installation the delivery came from, if any
+	ctx = githubsvc.WithInstallation(ctx, event.InstallationID)
+
+	req := &generationRequest{ref: event.Ref()}
+	logPrefix := fmt.Sprintf("Webhook delivery %s for %s", deliveryID, req.ref)
+
+	settings, err := app.resolveSettings(ctx, req.ref.Host, req.ref.Repository(), "")
+	if err != nil {
+		return fmt.Errorf("failed to resolve generation settings: %w", err)
+	}
+	req.settings = settings
+
+	// After a push, only refresh a body that still has its generated region;
+	// an author who removed it does not want it back
+	if event.Action == "synchronize" && mode == database.WebhookModeBody {
+		current, err := app.codeHosts.FetchBody(ctx, req.ref)
+		if err != nil {
+			return fmt.Errorf("failed to fetch PR body: %w", err)
+		}
+		if !codehost.HasManagedRegion(current.Body) {
+			log.Printf("%s: PR body has no generated region, leaving it untouched", logPrefix)
+			return nil
+		}
+	}
+
+	description, _, reqErr := app.describe(ctx, req)
+	if reqErr != nil {
+		return errors.New(reqErr.message)
+	}
+
+	switch mode {
+	case database.WebhookModeComment:
+		err = app.codeHosts.CreateComment(ctx, req.ref, description.Description)
+	case database.WebhookModeBody:
+		_, err = app.applyDescription(ctx, description, applyModeMerge, time.Time{}, "")
+	}
+	if err != nil {
+		if errors.Is(err, codehost.ErrReadOnly) {
+			log.Printf("%s: description stored, but it cannot be delivered without a GITHUB_TOKEN or an app installation with write access to pull requests", logPrefix)
+			return nil
+		}
+		return fmt.Errorf("failed to deliver description (%s): %w", mode, err)
+	}
+
+	log.Printf("%s: description generated on %s (%s)", logPrefix, event.Action, mode)
+	return nil
+}
@@ -0,0 +1,73 @@
+package app
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nahue/pr-toolbox-go/internal/database"
+)
+
+const testPullRequestPayload = `{
+	"action": "opened",
+	"number": 7,
+	"pull_request": {"draft": false, "head": {"sha": "abc123"}},
+	"repository": {"name": "widgets", "html_url": "https://github.com/acme/widgets", "owner": {"login": "acme"}}
+}`
+
+// deliverWebhook sends a signed delivery to the webhook handler
+func deliverWebhook(app *Application, deliveryID, event, payload string) *httptest.ResponseRecorder {
+	mac := hmac.New(sha256.New, app.webhookSecret)
+	mac.Write([]byte(payload))
+
+	r := httptest.NewRequest(http.MethodPost, "/webhooks/github", bytes.NewBufferString(payload))
+	r.Header.Set("X-GitHub-Delivery", deliveryID)
+	r.Header.Set("X-GitHub-Event", event)
+	r.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	w := httptest.NewRecorder()
+	app.handleGitHubWebhook(w, r)
+	return w
+}
+
+func TestWebhookDeliveriesAreSettledAfterProcessing(t *testing.T) {
+	db := newTestDatabase(t)
+	// Nothing reads the queue, so accepted deliveries stay claimed and a full queue refuses more
+	app := &Application{db: db, webhookSecret: []byte("secret"), webhookJobs: make(chan webhookJob, 1)}
+	if err := db.UpsertWebhookSettings("github.com", "acme/widgets", database.WebhookModeStore); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		deliveryID string
+		event      string
+		wantStatus int
+		wantBody   string
+	}{
+		{"ignored event", "delivery-1", "issues", http.StatusOK, "issues events are ignored"},
+		{"ignored event redelivered", "delivery-1", "issues", http.StatusOK, "duplicate delivery ignored"},
+		{"accepted", "delivery-2", "pull_request", http.StatusAccepted, "generating description"},
+		{"accepted while processing", "delivery-2", "pull_request", http.StatusOK, "duplicate delivery ignored"},
+		{"queue full", "delivery-3", "pull_request", http.StatusServiceUnavailable, "Too many deliveries"},
+		{"queue full redelivered", "delivery-3", "pull_request", http.StatusServiceUnavailable, "Too many deliveries"},
+	}
+
+	for _, tt := range tests {
+		w := deliverWebhook(app, tt.deliveryID, tt.event, testPullRequestPayload)
+		if w.Code != tt.wantStatus || !strings.Contains(w.Body.String(), tt.wantBody) {
+			t.Errorf("%s: got %d %q, want %d %q", tt.name, w.Code, w.Body.String(), tt.wantStatus, tt.wantBody)
+		}
+	}
+
+	// Once the queued delivery fails, a redelivery is processed again
+	<-app.webhookJobs
+	app.settleWebhookDelivery("delivery-2", database.WebhookDeliveryFailed)
+	if w := deliverWebhook(app, "delivery-2", "pull_request", testPullRequestPayload); w.Code != http.StatusAccepted {
+		t.Errorf("failed delivery redelivered: got %d %q, want %d", w.Code, w.Body.String(), http.StatusAccepted)
+	}
+}
@@ -0,0 +1,63 @@
+package codehost
+
+import "strings"
+
+// Markers delimiting the machine-owned region of a change request body. Only
+// the text between them is replaced when a description is regenerated;
+// anything the author writes outside them is left untouched.
+const (
+	ManagedRegionStart = "<!-- pr-toolbox:start -->"
+	ManagedRegionEnd   = "<!-- pr-toolbox:end -->"
+)
+
+// managedRegionNote is written inside the region so authors know where to edit
+const managedRegionNote = "<!-- Generated by PR Toolbox and replaced when the pull request changes. Edit outside these markers to keep your changes. -->"
+
+// managedRegionBounds returns the offsets of the start marker and of the end of
+// the end marker, or ok=false when the body has no complete region
+func managedRegionBounds(body string) (start, end int, ok bool) {
+	start = strings.Index(body, ManagedRegionStart)
+	if start < 0 {
+		return 0, 0, false
+	}
+	offset := strings.Index(body[start:], ManagedRegionEnd)
+	if offset < 0 {
+		return 0, 0, false
+	}
+	return start, start + offset + len(ManagedRegionEnd), true
+}
+
+// HasManagedRegion reports whether the body contains a machine-owned region
+func HasManagedRegion(body string) bool {
+	_, _, ok := managedRegionBounds(body)
+	return ok
+}
+
+// SetManagedRegion returns the body with the machine-owned region set to
+// content. The region is appended when the body does not have one yet.
+func SetManagedRegion(body, content string) string {
+	region := ManagedRegionStart + "\n" + managedRegionNote + "\n\n" + strings.TrimSpace(content) + "\n\n" + ManagedRegionEnd
+
+	if start, end, ok := managedRegionBounds(body); ok {
+		return body[:start] + region + body[end:]
+	}
+	if strings.TrimSpace(body) == "" {
+		return region
+	}
+	return strings.TrimRight(body, "\r\n ") + "\n\n" + region
+}
+
+// StripManagedRegion returns the body without its machine-owned region, i.e.
+// only the parts written by people
+func StripManagedRegion(body string) string {
+	start, end, ok := managedRegionBounds(body)
+	if !ok {
+		return body
+	}
+	before := strings.TrimSpace(body[:start])
+	after := strings.TrimSpace(body[end:])
+	if before == "" || after == "" {
+		return before + after
+	}
+	return before + "\n\n" + after
+}
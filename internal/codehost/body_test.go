@@ -0,0 +1,94 @@
+package codehost_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+)
+
+// region is the managed region SetManagedRegion writes for content
+func region(content string) string {
+	return codehost.SetManagedRegion("", content)
+}
+
+func TestSetManagedRegion(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		content string
+		want    string
+	}{
+		{"empty body", "", "New", region("New")},
+		{"blank body", " \n", "New", region("New")},
+		{"appended", "Written by the author.\n\n", "New", "Written by the author.\n\n" + region("New")},
+		{"replaced", "Intro\n\n" + region("Old") + "\n\nOutro", "New", "Intro\n\n" + region("New") + "\n\nOutro"},
+		{"only the region", region("Old"), " New \n", region("New")},
+		{
+			"start marker without an end",
+			"Intro " + codehost.ManagedRegionStart,
+			"New",
+			"Intro " + codehost.ManagedRegionStart + "\n\n" + region("New"),
+		},
+	}
+
+	for _, tt := range tests {
+		got := codehost.SetManagedRegion(tt.body, tt.content)
+		if got != tt.want {
+			t.Errorf("%s: SetManagedRegion() = %q, want %q", tt.name, got, tt.want)
+		}
+		if !codehost.HasManagedRegion(got) {
+			t.Errorf("%s: SetManagedRegion() = %q, which has no managed region", tt.name, got)
+		}
+	}
+
+	if r := region("New"); !strings.HasPrefix(r, codehost.ManagedRegionStart) || !strings.HasSuffix(r, codehost.ManagedRegionEnd) || !strings.Contains(r, "\n\nNew\n\n") {
+		t.Errorf("region = %q, want the content between the markers", r)
+	}
+}
+
+func TestStripManagedRegion(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"no region", "Written by the author.\n", "Written by the author.\n"},
+		{"only the region", region("Generated"), ""},
+		{"before", "Intro\n\n" + region("Generated"), "Intro"},
+		{"after", region("Generated") + "\n\nOutro\n", "Outro"},
+		{"around", "Intro\n" + region("Generated") + "\nOutro", "Intro\n\nOutro"},
+		{"incomplete region", codehost.ManagedRegionStart + " Intro", codehost.ManagedRegionStart + " Intro"},
+	}
+
+	for _, tt := range tests {
+		if got := codehost.StripManagedRegion(tt.body); got != tt.want {
+			t.Errorf("%s: StripManagedRegion() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBodyCheckUnchanged(t *testing.T) {
+	ref := codehost.Ref{Host: "github.com", Owner: "octo-org", Repo: "hello", Number: 1}
+	updatedAt := time.Date(2025, 9, 1, 12, 0, 0, 0, time.UTC)
+	current := &codehost.Body{Body: "Written by the author.", UpdatedAt: updatedAt}
+
+	tests := []struct {
+		name     string
+		latest   *codehost.Body
+		conflict bool
+	}{
+		{"unchanged", &codehost.Body{Body: "Written by the author.", UpdatedAt: updatedAt}, false},
+		{"updated later", &codehost.Body{Body: "Written by the author.", UpdatedAt: updatedAt.Add(time.Second)}, true},
+		{"edited within the same second", &codehost.Body{Body: "Rewritten by a reviewer.", UpdatedAt: updatedAt}, true},
+	}
+
+	for _, tt := range tests {
+		err := current.CheckUnchanged(ref, tt.latest)
+		if errors.Is(err, codehost.ErrConflict) != tt.conflict {
+			t.Errorf("%s: CheckUnchanged() = %v, want conflict %v", tt.name, err, tt.conflict)
+		}
+	}
+}
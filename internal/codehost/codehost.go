@@ -0,0 +1,188 @@
+// Package codehost defines the change-request model shared by the code hosts
+// PR Toolbox can read from (GitHub pull requests, GitLab merge requests, ...)
+// and the Provider interface each host implements.
+package codehost
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultHost is the host references without an explicit host refer to
+const DefaultHost = "github.com"
+
+// Ref identifies a change request (a pull request or merge request). Owner is
+// the namespace of the repository, which on GitLab may contain subgroups
+// ("group/subgroup").
+type Ref struct {
+	Host   string `json:"host"`
+	Owner  string `json:"owner"`
+	Repo   string `json:"repo"`
+	Number int    `json:"number"`
+}
+
+// Repository returns the "owner/repo" name of the change request's repository
+func (r Ref) Repository() string {
+	return r.Owner + "/" + r.Repo
+}
+
+// String returns the reference in the form "owner/repo#123", prefixed with the
+// host outside github.com
+func (r Ref) String() string {
+	if r.Host == DefaultHost {
+		return fmt.Sprintf("%s/%s#%d", r.Owner, r.Repo, r.Number)
+	}
+	return fmt.Sprintf("%s/%s/%s#%d", r.Host, r.Owner, r.Repo, r.Number)
+}
+
+// SplitRepository splits an "owner/repo" name, keeping any subgroups in the owner
+func SplitRepository(repository string) (owner, repo string) {
+	i := strings.LastIndex(repository, "/")
+	if i < 0 {
+		return "", repository
+	}
+	return repository[:i], repository[i+1:]
+}
+
+// ChangeRequest is everything needed to describe a change request, independent
+// of the code host it was fetched from
+type ChangeRequest struct {
+	Title             string         `json:"title"`
+	Body              string         `json:"body"`
+	User              *User          `json:"user"`
+	Assignees         []*User        `json:"assignees"`
+	Labels            []string       `json:"labels"`
+	State             string         `json:"state"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	ChangedFiles      []*File        `json:"changed_files"`       // includes the per-file Patch hunks
+	TotalChangedFiles int            `json:"total_changed_files"` // as reported by the host, may exceed len(ChangedFiles)
+	Commits           []Commit       `json:"commits"`
+	Reviews           []*Review      `json:"reviews"`
+	Additions         int            `json:"additions"`
+	Deletions         int            `json:"deletions"`
+	Host              string         `json:"host"`
+	Repository        string         `json:"repository"`
+	PRNumber          int            `json:"pr_number"`
+	URL               string         `json:"url"`                // web page of the change request
+	BaseRef           string         `json:"base_ref,omitempty"` // compared refs, only set for branch comparisons
+	HeadRef           string         `json:"head_ref,omitempty"`
+	HeadSHA           string         `json:"head_sha"` // commit the head pointed to when fetched
+	Contributors      []*Contributor `json:"contributors"`
+	Template          string         `json:"template,omitempty"`        // the repository's pull request template, if it has one
+	LinkedIssues      []*Issue       `json:"linked_issues,omitempty"`   // issues mentioned in the body and commit messages
+	Truncated         bool           `json:"truncated"`                 // set when any list above is incomplete
+	TruncatedLists    []string       `json:"truncated_lists,omitempty"` // names of the incomplete lists
+}
+
+// User is an account on the code host
+type User struct {
+	Login string `json:"login"`
+	Name  string `json:"name,omitempty"`
+}
+
+// File is a file changed by the change request
+type File struct {
+	Filename         string `json:"filename"`
+	PreviousFilename string `json:"previous_filename,omitempty"`
+	Status           string `json:"status"` // added, removed, modified or renamed
+	Additions        int    `json:"additions"`
+	Deletions        int    `json:"deletions"`
+	Changes          int    `json:"changes"`
+	Patch            string `json:"patch,omitempty"` // unified diff hunks, empty for binary or oversized files
+}
+
+// Review is a review or approval of the change request
+type Review struct {
+	User        string    `json:"user"`
+	State       string    `json:"state"` // e.g. APPROVED, CHANGES_REQUESTED, COMMENTED
+	SubmittedAt time.Time `json:"submitted_at"`
+}
+
+// Body is the current description of a change request together with the time
+// it was last updated, which serves as its version
+type Body struct {
+	Body      string    `json:"body"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CheckUnchanged returns ErrConflict when latest, read from the change request
+// named by ref, differs from b in its description or update time. Comparing
+// the description too catches edits made within the same second, which some
+// code hosts do not tell apart by updated_at alone.
+func (b *Body) CheckUnchanged(ref Ref, latest *Body) error {
+	if !latest.UpdatedAt.Equal(b.UpdatedAt) || latest.Body != b.Body {
+		return fmt.Errorf("%w: %s was updated at %s", ErrConflict, ref, latest.UpdatedAt.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// Provider reads and writes change requests on the hosts of one kind of code host
+type Provider interface {
+	// Name is the product name shown to users, e.g. "GitHub"
+	Name() string
+	// Hosts lists the hosts the provider serves
+	Hosts() []string
+	// ParseRef parses a URL or shorthand naming a change request. The host is
+	// not checked against Hosts; the Registry does that.
+	ParseRef(input string) (Ref, error)
+	// URL returns the web address of the change request
+	URL(ref Ref) string
+	// FetchHeadSHA returns the commit the change request head currently points
+	// to. It should be cheap, since it serves as a freshness check.
+	FetchHeadSHA(ctx context.Context, ref Ref) (string, error)
+	// FetchChangeRequest fetches the metadata, changes and commits of the change request
+	FetchChangeRequest(ctx context.Context, ref Ref) (*ChangeRequest, error)
+	// FetchBody returns the current description of the change request
+	FetchBody(ctx context.Context, ref Ref) (*Body, error)
+	// UpdateBody replaces the description of the change request. current is
+	// the description body was derived from, as returned by FetchBody. When it
+	// is set, the change request is read again right before the write, and
+	// nothing is written and ErrConflict is returned if it no longer matches.
+	// No code host offers a conditional update, so an edit landing between
+	// that read and the write is still overwritten.
+	UpdateBody(ctx context.Context, ref Ref, body string, current *Body) (*Body, error)
+	// CreateComment posts a comment on the change request
+	CreateComment(ctx context.Context, ref Ref, body string) error
+	// CheckWrite returns ErrReadOnly when no credentials able to write to the
+	// owner's repositories on host are available under ctx
+	CheckWrite(ctx context.Context, host, owner string) error
+}
+
+// Helper functions for formatting change request data in prompts
+func GetLabelsString(labels []string) string {
+	if len(labels) == 0 {
+		return "none"
+	}
+	return strings.Join(labels, ", ")
+}
+
+func GetUserString(user *User) string {
+	switch {
+	case user == nil:
+		return "unknown"
+	case user.Login != "":
+		return user.Login
+	case user.Name != "":
+		// Authors of local branches have no account
+		return user.Name
+	default:
+		return "unknown"
+	}
+}
+
+func GetAssigneesString(assignees []*User) string {
+	if len(assignees) == 0 {
+		return "none"
+	}
+
+	var assigneeNames []string
+	for _, assignee := range assignees {
+		if assignee.Login != "" {
+			assigneeNames = append(assigneeNames, assignee.Login)
+		}
+	}
+	return strings.Join(assigneeNames, ", ")
+}
@@ -0,0 +1,176 @@
+package codehost
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Commit is a commit of the change request with its authorship and line counts
+type Commit struct {
+	SHA         string     `json:"sha"`
+	Message     string     `json:"message"`
+	AuthorLogin string     `json:"author_login,omitempty"`
+	AuthorName  string     `json:"author_name"`
+	AuthorEmail string     `json:"author_email"`
+	CoAuthors   []CoAuthor `json:"co_authors,omitempty"`
+	Additions   int        `json:"additions"`
+	Deletions   int        `json:"deletions"`
+	HasStats    bool       `json:"has_stats"`
+}
+
+// CoAuthor is a person credited through a Co-authored-by trailer
+type CoAuthor struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// Contributor is a person who authored or co-authored commits in the change request
+type Contributor struct {
+	Login     string `json:"login,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Email     string `json:"email,omitempty"`
+	Commits   int    `json:"commits"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+}
+
+// DisplayName returns the best available identifier for the contributor
+func (c *Contributor) DisplayName() string {
+	switch {
+	case c.Login != "" && c.Name != "":
+		return fmt.Sprintf("%s (@%s)", c.Name, c.Login)
+	case c.Login != "":
+		return "@" + c.Login
+	case c.Name != "":
+		return c.Name
+	default:
+		return c.Email
+	}
+}
+
+var (
+	coAuthorTrailer = regexp.MustCompile(`(?im)^co-authored-by:\s*(.+?)\s*<([^>]+)>\s*$`)
+	noreplyEmail    = regexp.MustCompile(`^(?:\d+\+)?([A-Za-z0-9-]+)@users\.noreply\.github\.com$`)
+)
+
+// ParseCoAuthors extracts the Co-authored-by trailers from a commit message
+func ParseCoAuthors(message string) []CoAuthor {
+	var coAuthors []CoAuthor
+	for _, match := range coAuthorTrailer.FindAllStringSubmatch(message, -1) {
+		coAuthors = append(coAuthors, CoAuthor{Name: match[1], Email: strings.ToLower(match[2])})
+	}
+	return coAuthors
+}
+
+// loginFromEmail recovers the GitHub login from a users.noreply.github.com address
+func loginFromEmail(email string) string {
+	if match := noreplyEmail.FindStringSubmatch(email); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// BuildContributors derives the contributors from commit authors and
+// Co-authored-by trailers. Every author of a commit is credited with the
+// commit and its line counts once, even when a trailer names the commit's
+// author or repeats another trailer.
+func BuildContributors(commits []Commit) []*Contributor {
+	byKey := map[string]*Contributor{}
+	loginByEmail := map[string]string{}
+	var order []string
+
+	// Learn which emails belong to which logins so co-authors can be matched
+	for _, commit := range commits {
+		if commit.AuthorLogin != "" && commit.AuthorEmail != "" {
+			loginByEmail[commit.AuthorEmail] = commit.AuthorLogin
+		}
+	}
+
+	credit := func(login, name, email string, commit Commit, credited map[string]bool) {
+		if login == "" {
+			login = loginByEmail[email]
+		}
+		if login == "" {
+			login = loginFromEmail(email)
+		}
+
+		key := strings.ToLower(login)
+		if key == "" {
+			key = strings.ToLower(email)
+		}
+		if key == "" {
+			key = strings.ToLower(name)
+		}
+		if credited[key] {
+			return
+		}
+		credited[key] = true
+
+		contributor, ok := byKey[key]
+		if !ok {
+			contributor = &Contributor{Login: login, Name: name, Email: email}
+			byKey[key] = contributor
+			order = append(order, key)
+		}
+		if contributor.Name == "" {
+			contributor.Name = name
+		}
+		contributor.Commits++
+		contributor.Additions += commit.Additions
+		contributor.Deletions += commit.Deletions
+	}
+
+	for _, commit := range commits {
+		credited := map[string]bool{}
+		credit(commit.AuthorLogin, commit.AuthorName, commit.AuthorEmail, commit, credited)
+		for _, coAuthor := range commit.CoAuthors {
+			credit("", coAuthor.Name, coAuthor.Email, commit, credited)
+		}
+	}
+
+	contributors := make([]*Contributor, 0, len(order))
+	for _, key := range order {
+		contributors = append(contributors, byKey[key])
+	}
+	sort.SliceStable(contributors, func(a, b int) bool {
+		return contributors[a].Commits > contributors[b].Commits
+	})
+	return contributors
+}
+
+// GetCommitsString renders the commit messages for the prompt, oldest first
+func GetCommitsString(commits []Commit) string {
+	if len(commits) == 0 {
+		return "none"
+	}
+
+	var sb strings.Builder
+	for _, commit := range commits {
+		sha := commit.SHA
+		if len(sha) > 7 {
+			sha = sha[:7]
+		}
+		message := strings.TrimSpace(coAuthorTrailer.ReplaceAllString(commit.Message, ""))
+		if len(message) > 500 {
+			message = strings.ToValidUTF8(message[:500], "") + "..."
+		}
+		message = strings.ReplaceAll(message, "\n", "\n    ")
+		sb.WriteString(fmt.Sprintf("- %s %s\n", sha, message))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// GetContributorsString renders the contributors and their contribution counts for the prompt
+func GetContributorsString(contributors []*Contributor) string {
+	if len(contributors) == 0 {
+		return "none"
+	}
+
+	var lines []string
+	for _, c := range contributors {
+		lines = append(lines, fmt.Sprintf("- %s: %d commit(s), +%d/-%d lines", c.DisplayName(), c.Commits, c.Additions, c.Deletions))
+	}
+	return strings.Join(lines, "\n")
+}
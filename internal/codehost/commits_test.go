@@ -0,0 +1,111 @@
+package codehost_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+)
+
+func TestParseCoAuthors(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    []codehost.CoAuthor
+	}{
+		{"none", "Fix the parser", nil},
+		{
+			"trailers",
+			"Fix the parser\n\nCo-authored-by: Ada Lovelace <Ada@Example.com>\nco-authored-by:Grace Hopper  <grace@example.com>  ",
+			[]codehost.CoAuthor{{Name: "Ada Lovelace", Email: "ada@example.com"}, {Name: "Grace Hopper", Email: "grace@example.com"}},
+		},
+		{"not a trailer", "Mention Co-authored-by: Ada <ada@example.com> inline", nil},
+		{"without an email", "Fix\n\nCo-authored-by: Ada Lovelace", nil},
+	}
+
+	for _, tt := range tests {
+		if got := codehost.ParseCoAuthors(tt.message); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: ParseCoAuthors() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBuildContributors(t *testing.T) {
+	ada := codehost.CoAuthor{Name: "Ada Lovelace", Email: "ada@example.com"}
+	grace := codehost.CoAuthor{Name: "Grace Hopper", Email: "12345+ghopper@users.noreply.github.com"}
+
+	tests := []struct {
+		name    string
+		commits []codehost.Commit
+		want    []codehost.Contributor
+	}{
+		{
+			"authors and co-authors",
+			[]codehost.Commit{
+				{AuthorLogin: "ada", AuthorName: "Ada Lovelace", AuthorEmail: "ada@example.com", Additions: 10, Deletions: 2},
+				{AuthorLogin: "ada", AuthorName: "Ada Lovelace", AuthorEmail: "ada@example.com", Additions: 5, CoAuthors: []codehost.CoAuthor{grace}},
+				{AuthorName: "Linus", AuthorEmail: "linus@example.com", Deletions: 1},
+			},
+			[]codehost.Contributor{
+				{Login: "ada", Name: "Ada Lovelace", Email: "ada@example.com", Commits: 2, Additions: 15, Deletions: 2},
+				{Login: "ghopper", Name: "Grace Hopper", Email: grace.Email, Commits: 1, Additions: 5},
+				{Name: "Linus", Email: "linus@example.com", Commits: 1, Deletions: 1},
+			},
+		},
+		{
+			"co-author matched to a login by email",
+			[]codehost.Commit{
+				{AuthorLogin: "ada", AuthorName: "Ada Lovelace", AuthorEmail: "ada@example.com", Additions: 1},
+				{AuthorName: "Linus", AuthorEmail: "linus@example.com", Additions: 3, CoAuthors: []codehost.CoAuthor{ada}},
+			},
+			[]codehost.Contributor{
+				{Login: "ada", Name: "Ada Lovelace", Email: "ada@example.com", Commits: 2, Additions: 4},
+				{Name: "Linus", Email: "linus@example.com", Commits: 1, Additions: 3},
+			},
+		},
+		{
+			"trailer naming the author",
+			[]codehost.Commit{
+				{AuthorLogin: "ada", AuthorName: "Ada Lovelace", AuthorEmail: "ada@example.com", Additions: 7, CoAuthors: []codehost.CoAuthor{ada}},
+			},
+			[]codehost.Contributor{
+				{Login: "ada", Name: "Ada Lovelace", Email: "ada@example.com", Commits: 1, Additions: 7},
+			},
+		},
+		{
+			"repeated trailer",
+			[]codehost.Commit{
+				{AuthorName: "Linus", AuthorEmail: "linus@example.com", Additions: 2, CoAuthors: []codehost.CoAuthor{grace, grace}},
+			},
+			[]codehost.Contributor{
+				{Name: "Linus", Email: "linus@example.com", Commits: 1, Additions: 2},
+				{Login: "ghopper", Name: "Grace Hopper", Email: grace.Email, Commits: 1, Additions: 2},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		var got []codehost.Contributor
+		for _, contributor := range codehost.BuildContributors(tt.commits) {
+			got = append(got, *contributor)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: BuildContributors() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGetCommitsStringTruncatesOnRuneBoundaries(t *testing.T) {
+	// 499 bytes of ASCII put the 500-byte cut inside the two-byte "é"
+	message := strings.Repeat("a", 499) + "é and more"
+	got := codehost.GetCommitsString([]codehost.Commit{{SHA: "0123456789abcdef", Message: message}})
+
+	if !utf8.ValidString(got) {
+		t.Fatalf("GetCommitsString() = %q, want valid UTF-8", got)
+	}
+	if want := "- 0123456 " + strings.Repeat("a", 499) + "..."; got != want {
+		t.Errorf("GetCommitsString() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,40 @@
+package codehost
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// defaultMaxListItems matches the 3000 file cap GitHub applies to PR file listings
+const defaultMaxListItems = 3000
+
+// MaxListItemsFromEnv reads the upper bound on items fetched per list endpoint.
+// The variable keeps its original GITHUB_ name but applies to every code host.
+func MaxListItemsFromEnv() int {
+	maxItems := defaultMaxListItems
+	if maxStr := os.Getenv("GITHUB_MAX_LIST_ITEMS"); maxStr != "" {
+		if parsed, err := strconv.Atoi(maxStr); err == nil && parsed > 0 {
+			maxItems = parsed
+		} else {
+			log.Printf("Warning: Invalid GITHUB_MAX_LIST_ITEMS value '%s', defaulting to %d", maxStr, defaultMaxListItems)
+		}
+	}
+	return maxItems
+}
+
+// HostVariable names a per-host environment variable: prefix followed by the
+// host in upper case, with anything but letters and digits replaced by
+// underscores (GITHUB_ENTERPRISE_TOKEN_ and ghe.example.com give
+// GITHUB_ENTERPRISE_TOKEN_GHE_EXAMPLE_COM)
+func HostVariable(prefix, host string) string {
+	name := strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, host)
+	return prefix + name
+}
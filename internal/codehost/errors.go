@@ -0,0 +1,56 @@
+package codehost
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrNotFound means the change request does not exist or the credentials cannot see it
+	ErrNotFound = errors.New("change request not found")
+
+	// ErrUnauthorized means the code host rejected the configured credentials
+	ErrUnauthorized = errors.New("code host credentials are missing or invalid")
+
+	// ErrForbidden means the credentials are valid but lack permission for the operation
+	ErrForbidden = errors.New("code host denied access to this resource")
+
+	// ErrReadOnly means no credentials able to write to the code host are configured
+	ErrReadOnly = errors.New("code host write access is not configured")
+
+	// ErrConflict means the change request changed after the caller last read it
+	ErrConflict = errors.New("change request was modified concurrently")
+
+	// ErrUnknownHost means a change request lives on a host that is not configured
+	ErrUnknownHost = errors.New("code host is not configured")
+)
+
+// RateLimitError means the API rate limit of a code host was exceeded
+type RateLimitError struct {
+	Host  string
+	Reset time.Time
+	Err   error
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%s rate limit exceeded, resets at %s", e.Host, e.Reset.Format(time.RFC3339))
+}
+
+func (e *RateLimitError) Unwrap() error {
+	return e.Err
+}
+
+// NetworkError means a code host could not be reached
+type NetworkError struct {
+	Host string
+	Err  error
+}
+
+func (e *NetworkError) Error() string {
+	return fmt.Sprintf("failed to reach %s: %v", e.Host, e.Err)
+}
+
+func (e *NetworkError) Unwrap() error {
+	return e.Err
+}
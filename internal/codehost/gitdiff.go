@@ -0,0 +1,95 @@
+package codehost
+
+import "strings"
+
+// ParseGitDiff splits the output of git diff (or a forge's .diff endpoint) into
+// one File per changed file, with the file's hunks as its Patch and its line
+// counts taken from them. Binary files get an empty Patch.
+func ParseGitDiff(diff string) []*File {
+	var files []*File
+	var file *File
+	var patch []string
+	inHunks := false
+
+	flush := func() {
+		if file == nil {
+			return
+		}
+		file.Patch = strings.Join(patch, "\n")
+		file.Changes = file.Additions + file.Deletions
+		files = append(files, file)
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(diff, "\n"), "\n") {
+		if strings.HasPrefix(line, "diff --git ") {
+			flush()
+			file = &File{Status: "modified"}
+			file.Filename, file.PreviousFilename = diffGitPaths(line)
+			patch = nil
+			inHunks = false
+			continue
+		}
+		if file == nil {
+			continue
+		}
+
+		if inHunks {
+			switch {
+			case strings.HasPrefix(line, "+"):
+				file.Additions++
+			case strings.HasPrefix(line, "-"):
+				file.Deletions++
+			}
+			patch = append(patch, line)
+			continue
+		}
+
+		// Extended header lines, up to the first hunk
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			inHunks = true
+			patch = append(patch, line)
+		case strings.HasPrefix(line, "new file mode"):
+			file.Status = "added"
+		case strings.HasPrefix(line, "deleted file mode"):
+			file.Status = "removed"
+		case strings.HasPrefix(line, "rename from "):
+			file.Status = "renamed"
+			file.PreviousFilename = strings.TrimPrefix(line, "rename from ")
+		case strings.HasPrefix(line, "rename to "):
+			file.Filename = strings.TrimPrefix(line, "rename to ")
+		case strings.HasPrefix(line, "--- a/"):
+			file.PreviousFilename = diffHeaderPath(line, "--- a/")
+		case strings.HasPrefix(line, "+++ b/"):
+			file.Filename = diffHeaderPath(line, "+++ b/")
+		}
+	}
+	flush()
+
+	for _, file := range files {
+		if file.Status != "renamed" {
+			if file.Status == "removed" && file.Filename == "" {
+				file.Filename = file.PreviousFilename
+			}
+			file.PreviousFilename = ""
+		}
+	}
+	return files
+}
+
+// diffGitPaths reads the old and new paths from a "diff --git a/x b/y" line.
+// Paths containing " b/" are ambiguous there; the ---/+++ and rename lines
+// that follow correct them.
+func diffGitPaths(line string) (newPath, oldPath string) {
+	rest := strings.TrimPrefix(line, "diff --git ")
+	if i := strings.Index(rest, " b/"); i >= 0 {
+		return rest[i+len(" b/"):], strings.TrimPrefix(rest[:i], "a/")
+	}
+	return rest, rest
+}
+
+// diffHeaderPath reads the path from a ---/+++ line, which git ends with a tab
+// when the path contains spaces
+func diffHeaderPath(line, prefix string) string {
+	return strings.TrimRight(strings.TrimPrefix(line, prefix), "\t")
+}
@@ -0,0 +1,135 @@
+package codehost_test
+
+import (
+	"testing"
+
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+)
+
+func TestParseGitDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		diff string
+		want []codehost.File
+	}{
+		{
+			name: "modified",
+			diff: "diff --git a/main.go b/main.go\n" +
+				"index 1111111..2222222 100644\n" +
+				"--- a/main.go\n" +
+				"+++ b/main.go\n" +
+				"@@ -1,2 +1,2 @@\n" +
+				" package main\n" +
+				"-func main() {}\n" +
+				"+func main() { run() }\n",
+			want: []codehost.File{{Filename: "main.go", Status: "modified", Additions: 1, Deletions: 1, Changes: 2,
+				Patch: "@@ -1,2 +1,2 @@\n package main\n-func main() {}\n+func main() { run() }"}},
+		},
+		{
+			name: "added and removed",
+			diff: "diff --git a/new.go b/new.go\n" +
+				"new file mode 100644\n" +
+				"index 0000000..1111111\n" +
+				"--- /dev/null\n" +
+				"+++ b/new.go\n" +
+				"@@ -0,0 +1 @@\n" +
+				"+package new\n" +
+				"diff --git a/old.go b/old.go\n" +
+				"deleted file mode 100644\n" +
+				"index 1111111..0000000\n" +
+				"--- a/old.go\n" +
+				"+++ /dev/null\n" +
+				"@@ -1 +0,0 @@\n" +
+				"-package old\n",
+			want: []codehost.File{
+				{Filename: "new.go", Status: "added", Additions: 1, Changes: 1, Patch: "@@ -0,0 +1 @@\n+package new"},
+				{Filename: "old.go", Status: "removed", Deletions: 1, Changes: 1, Patch: "@@ -1 +0,0 @@\n-package old"},
+			},
+		},
+		{
+			name: "pure rename",
+			diff: "diff --git a/docs/old.md b/docs/new.md\n" +
+				"similarity index 100%\n" +
+				"rename from docs/old.md\n" +
+				"rename to docs/new.md\n",
+			want: []codehost.File{{Filename: "docs/new.md", PreviousFilename: "docs/old.md", Status: "renamed"}},
+		},
+		{
+			name: "rename with changes",
+			diff: "diff --git a/docs/old.md b/docs/new.md\n" +
+				"similarity index 90%\n" +
+				"rename from docs/old.md\n" +
+				"rename to docs/new.md\n" +
+				"index 1111111..2222222 100644\n" +
+				"--- a/docs/old.md\n" +
+				"+++ b/docs/new.md\n" +
+				"@@ -1 +1 @@\n" +
+				"-Old title\n" +
+				"+New title\n",
+			want: []codehost.File{{Filename: "docs/new.md", PreviousFilename: "docs/old.md", Status: "renamed", Additions: 1, Deletions: 1, Changes: 2,
+				Patch: "@@ -1 +1 @@\n-Old title\n+New title"}},
+		},
+		{
+			name: "binary",
+			diff: "diff --git a/logo.png b/logo.png\n" +
+				"index 1111111..2222222 100644\n" +
+				"Binary files a/logo.png and b/logo.png differ\n" +
+				"diff --git a/icon.png b/icon.png\n" +
+				"new file mode 100644\n" +
+				"index 0000000..3333333\n" +
+				"Binary files /dev/null and b/icon.png differ\n",
+			want: []codehost.File{
+				{Filename: "logo.png", Status: "modified"},
+				{Filename: "icon.png", Status: "added"},
+			},
+		},
+		{
+			name: "mode only",
+			diff: "diff --git a/scripts/build.sh b/scripts/build.sh\n" +
+				"old mode 100644\n" +
+				"new mode 100755\n",
+			want: []codehost.File{{Filename: "scripts/build.sh", Status: "modified"}},
+		},
+		{
+			name: "path containing b/",
+			diff: "diff --git a/notes a b/c.txt b/notes a b/c.txt\n" +
+				"index 1111111..2222222 100644\n" +
+				"--- a/notes a b/c.txt\t\n" +
+				"+++ b/notes a b/c.txt\t\n" +
+				"@@ -1 +1 @@\n" +
+				"-one\n" +
+				"+two\n",
+			want: []codehost.File{{Filename: "notes a b/c.txt", Status: "modified", Additions: 1, Deletions: 1, Changes: 2,
+				Patch: "@@ -1 +1 @@\n-one\n+two"}},
+		},
+		{
+			name: "hunk lines looking like headers",
+			diff: "diff --git a/schema.sql b/schema.sql\n" +
+				"--- a/schema.sql\n" +
+				"+++ b/schema.sql\n" +
+				"@@ -1,2 +1,2 @@\n" +
+				"--- old comment\n" +
+				"+++ new comment\n" +
+				" SELECT 1;\n",
+			want: []codehost.File{{Filename: "schema.sql", Status: "modified", Additions: 1, Deletions: 1, Changes: 2,
+				Patch: "@@ -1,2 +1,2 @@\n--- old comment\n+++ new comment\n SELECT 1;"}},
+		},
+		{
+			name: "empty",
+			diff: "",
+		},
+	}
+
+	for _, tt := range tests {
+		files := codehost.ParseGitDiff(tt.diff)
+		if len(files) != len(tt.want) {
+			t.Errorf("%s: ParseGitDiff() returned %d files, want %d", tt.name, len(files), len(tt.want))
+			continue
+		}
+		for i, file := range files {
+			if *file != tt.want[i] {
+				t.Errorf("%s: ParseGitDiff()[%d] = %+v, want %+v", tt.name, i, *file, tt.want[i])
+			}
+		}
+	}
+}
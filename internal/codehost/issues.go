@@ -0,0 +1,114 @@
+package codehost
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Issue is an issue the change request refers to, fetched so the description
+// can explain the motivation and link it instead of guessing
+type Issue struct {
+	Repository string   `json:"repository"` // owner/repo
+	Number     int      `json:"number"`
+	Title      string   `json:"title"`
+	Body       string   `json:"body"`
+	Labels     []string `json:"labels"`
+	State      string   `json:"state"`
+	URL        string   `json:"url"`
+	Closes     bool     `json:"closes"` // referenced with a closing keyword such as "Fixes"
+}
+
+// IssueReference is a mention of an issue in a change request body or commit message
+type IssueReference struct {
+	Repository string // owner/repo
+	Number     int
+	Closes     bool
+}
+
+// closingKeyword matches the keywords that close an issue when the change
+// request is merged, with an optional colon: "Fixes #1", "closes: #2"
+const closingKeyword = `(?:\b(close[sd]?|fix(?:e[sd])?|resolve[sd]?)\s*:?\s+)?`
+
+// issueShorthand matches "#123" and "owner/repo#123", not preceded by
+// anything that would make it part of a word, path or URL fragment
+var issueShorthand = regexp.MustCompile(`(?i)(?:^|[\s(\[,;])` + closingKeyword + `(?:([A-Za-z0-9][A-Za-z0-9-]*)/([A-Za-z0-9._-]+))?#(\d+)\b`)
+
+// ParseIssueReferences finds the issues mentioned in text: "#123" in
+// repository, "owner/repo#123" elsewhere, and issue URLs on host. A reference
+// preceded by a closing keyword (close, fixes, resolved, ...) is marked as
+// closing. Each issue is returned once, in order of first mention, and is
+// closing if any of its mentions is.
+func ParseIssueReferences(text, host, repository string) []IssueReference {
+	issueURL := regexp.MustCompile(`(?i)` + closingKeyword + `https?://(?:www\.)?` + regexp.QuoteMeta(host) + `/([A-Za-z0-9][A-Za-z0-9-]*)/([A-Za-z0-9._-]+)/issues/(\d+)\b`)
+
+	type mention struct {
+		start int
+		ref   IssueReference
+	}
+	var mentions []mention
+	for _, pattern := range []*regexp.Regexp{issueShorthand, issueURL} {
+		for _, match := range pattern.FindAllStringSubmatchIndex(text, -1) {
+			group := func(i int) string {
+				if match[2*i] < 0 {
+					return ""
+				}
+				return text[match[2*i]:match[2*i+1]]
+			}
+			number, err := strconv.Atoi(group(4))
+			if err != nil || number <= 0 {
+				continue
+			}
+			repo := repository
+			if group(2) != "" {
+				repo = group(2) + "/" + group(3)
+			}
+			mentions = append(mentions, mention{match[0], IssueReference{Repository: repo, Number: number, Closes: group(1) != ""}})
+		}
+	}
+
+	// Order by position, so shorthands and URLs interleave as written
+	sort.SliceStable(mentions, func(i, j int) bool { return mentions[i].start < mentions[j].start })
+
+	var refs []IssueReference
+	index := map[string]int{}
+	for _, m := range mentions {
+		key := strings.ToLower(fmt.Sprintf("%s#%d", m.ref.Repository, m.ref.Number))
+		if i, ok := index[key]; ok {
+			refs[i].Closes = refs[i].Closes || m.ref.Closes
+			continue
+		}
+		index[key] = len(refs)
+		refs = append(refs, m.ref)
+	}
+	return refs
+}
+
+// GetLinkedIssuesString renders the linked issues for the prompt, with their
+// bodies shortened to maxBodyLength characters
+func GetLinkedIssuesString(issues []*Issue, maxBodyLength int) string {
+	if len(issues) == 0 {
+		return "None"
+	}
+
+	var b strings.Builder
+	for _, issue := range issues {
+		relation := "referenced"
+		if issue.Closes {
+			relation = "closed by this pull request"
+		}
+		fmt.Fprintf(&b, "- %s#%d (%s, %s): %s\n  Link: [%s#%d](%s)\n", issue.Repository, issue.Number, relation, issue.State, issue.Title, issue.Repository, issue.Number, issue.URL)
+		if len(issue.Labels) > 0 {
+			fmt.Fprintf(&b, "  Labels: %s\n", strings.Join(issue.Labels, ", "))
+		}
+		if body := strings.TrimSpace(issue.Body); body != "" {
+			if len(body) > maxBodyLength {
+				body = strings.ToValidUTF8(body[:maxBodyLength], "") + "..."
+			}
+			fmt.Fprintf(&b, "  Description: %s\n", strings.ReplaceAll(body, "\n", "\n  "))
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
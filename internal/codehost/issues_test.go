@@ -0,0 +1,32 @@
+package codehost_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+)
+
+func TestParseIssueReferences(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []codehost.IssueReference
+	}{
+		{"closing keyword", "Fixes #12", []codehost.IssueReference{{Repository: "octo/app", Number: 12, Closes: true}}},
+		{"keyword with colon", "Add retries.\n\nresolves: #7", []codehost.IssueReference{{Repository: "octo/app", Number: 7, Closes: true}}},
+		{"plain mention", "Follow-up to #3, see also (#4)", []codehost.IssueReference{{Repository: "octo/app", Number: 3}, {Repository: "octo/app", Number: 4}}},
+		{"cross-repository", "Closes octo/api#99", []codehost.IssueReference{{Repository: "octo/api", Number: 99, Closes: true}}},
+		{"issue URL", "Fixed https://github.com/octo/web/issues/5 and https://github.com/octo/web/pull/6", []codehost.IssueReference{{Repository: "octo/web", Number: 5, Closes: true}}},
+		{"other host", "See https://gitlab.com/octo/web/issues/5", nil},
+		{"mentioned then closed", "Part of #8.\n\nFixes #8", []codehost.IssueReference{{Repository: "octo/app", Number: 8, Closes: true}}},
+		{"in order", "fix octo/api#2 after #1", []codehost.IssueReference{{Repository: "octo/api", Number: 2, Closes: true}, {Repository: "octo/app", Number: 1}}},
+		{"not a reference", "color: #fff; page.html#section; a#1", nil},
+	}
+	for _, tt := range tests {
+		got := codehost.ParseIssueReferences(tt.text, "github.com", "octo/app")
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: ParseIssueReferences(%q) = %+v, want %+v", tt.name, tt.text, got, tt.want)
+		}
+	}
+}
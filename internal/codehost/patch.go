@@ -0,0 +1,181 @@
+package codehost
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Sides of a diff, as review comments name them
+const (
+	SideLeft  = "LEFT"  // the base version of the file, where deleted lines are
+	SideRight = "RIGHT" // the head version of the file, where added and context lines are
+)
+
+// hunkHeader matches "@@ -12,7 +12,9 @@ optional section heading"; a missing count means 1
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@(.*)$`)
+
+// DiffLine is a line of a patch that review comments can be anchored to
+type DiffLine struct {
+	Position int    `json:"position"` // lines below the first hunk header, counting later hunk headers
+	Op       string `json:"op"`       // "+", "-" or " "
+	OldLine  int    `json:"old_line"` // line number in the base version, 0 for added lines
+	NewLine  int    `json:"new_line"` // line number in the head version, 0 for deleted lines
+	Text     string `json:"text"`
+}
+
+// Side returns the side of the diff a comment on the line belongs to: deleted
+// lines only exist in the base version, the others are commented on in the head
+func (l DiffLine) Side() string {
+	if l.Op == "-" {
+		return SideLeft
+	}
+	return SideRight
+}
+
+// Line returns the line number on the line's Side
+func (l DiffLine) Line() int {
+	if l.Op == "-" {
+		return l.OldLine
+	}
+	return l.NewLine
+}
+
+// Hunk is one "@@" section of a patch
+type Hunk struct {
+	OldStart int        `json:"old_start"`
+	OldLines int        `json:"old_lines"`
+	NewStart int        `json:"new_start"`
+	NewLines int        `json:"new_lines"`
+	Section  string     `json:"section,omitempty"` // the heading after the second "@@", usually the enclosing function
+	Lines    []DiffLine `json:"lines"`
+}
+
+// Patch is the parsed unified diff hunks of one file, as in File.Patch
+type Patch struct {
+	Hunks []*Hunk `json:"hunks"`
+}
+
+// ParsePatch parses the hunks of a file's patch, numbering each line in the
+// base and head versions and by its position in the patch. Lines before the
+// first hunk header, malformed headers and hunks with more lines than their
+// header announces are errors; "\ No newline at end of file" markers are
+// skipped but still take up a position.
+func ParsePatch(patch string) (*Patch, error) {
+	parsed := &Patch{}
+	if strings.TrimSpace(patch) == "" {
+		return parsed, nil
+	}
+
+	var hunk *Hunk
+	var oldLine, newLine, oldLeft, newLeft int
+	position := -1 // the first hunk header is position 0
+	for i, text := range strings.Split(strings.TrimSuffix(patch, "\n"), "\n") {
+		position++
+		text = strings.TrimSuffix(text, "\r")
+
+		if strings.HasPrefix(text, "@@") {
+			match := hunkHeader.FindStringSubmatch(text)
+			if match == nil {
+				return nil, fmt.Errorf("line %d: malformed hunk header %q", i+1, text)
+			}
+			hunk = &Hunk{
+				OldStart: atoiDefault(match[1], 0),
+				OldLines: atoiDefault(match[2], 1),
+				NewStart: atoiDefault(match[3], 0),
+				NewLines: atoiDefault(match[4], 1),
+				Section:  strings.TrimSpace(match[5]),
+			}
+			parsed.Hunks = append(parsed.Hunks, hunk)
+			oldLine, newLine = hunk.OldStart, hunk.NewStart
+			oldLeft, newLeft = hunk.OldLines, hunk.NewLines
+			continue
+		}
+		if hunk == nil {
+			return nil, fmt.Errorf("line %d: %q comes before the first hunk header", i+1, text)
+		}
+		if strings.HasPrefix(text, `\`) {
+			continue
+		}
+
+		line := DiffLine{Position: position, Op: " ", Text: text}
+		if text != "" {
+			line.Op, line.Text = text[:1], text[1:]
+		}
+		switch line.Op {
+		case "+":
+			line.NewLine = newLine
+			newLine++
+			newLeft--
+		case "-":
+			line.OldLine = oldLine
+			oldLine++
+			oldLeft--
+		case " ":
+			line.OldLine, line.NewLine = oldLine, newLine
+			oldLine++
+			newLine++
+			oldLeft--
+			newLeft--
+		default:
+			return nil, fmt.Errorf("line %d: unexpected line %q in hunk", i+1, text)
+		}
+		if oldLeft < 0 || newLeft < 0 {
+			return nil, fmt.Errorf("line %d: hunk is longer than its header %q announces", i+1, text)
+		}
+		hunk.Lines = append(hunk.Lines, line)
+	}
+	return parsed, nil
+}
+
+// atoiDefault parses a hunk header count, which is omitted when it is 1
+func atoiDefault(s string, fallback int) int {
+	if s == "" {
+		return fallback
+	}
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// Find returns the line with the given number on the given side of the diff,
+// or nil when the patch does not include it
+func (p *Patch) Find(side string, line int) *DiffLine {
+	for _, hunk := range p.Hunks {
+		for i := range hunk.Lines {
+			l := &hunk.Lines[i]
+			if l.Side() == side && l.Line() == line {
+				return l
+			}
+		}
+	}
+	return nil
+}
+
+// Annotate renders the patch with each line prefixed by the side and number a
+// review comment would use to refer to it, for instance "R12 +text" for an
+// added line 12 and "L7 -text" for a deleted line 7
+func (p *Patch) Annotate() string {
+	var b strings.Builder
+	for _, hunk := range p.Hunks {
+		fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@", hunk.OldStart, hunk.OldLines, hunk.NewStart, hunk.NewLines)
+		if hunk.Section != "" {
+			b.WriteString(" " + hunk.Section)
+		}
+		b.WriteString("\n")
+		for _, line := range hunk.Lines {
+			fmt.Fprintf(&b, "%s%d %s%s\n", line.Side()[:1], line.Line(), line.Op, line.Text)
+		}
+	}
+	return b.String()
+}
+
+// ReviewComment is a review comment on one line of a change request's diff
+type ReviewComment struct {
+	Path     string `json:"path"`
+	Line     int    `json:"line"` // line number on Side
+	Side     string `json:"side"` // SideLeft or SideRight
+	Severity string `json:"severity,omitempty"`
+	Body     string `json:"body"`
+	DiffLine string `json:"diff_line,omitempty"` // the commented line as it appears in the patch, for display
+}
@@ -0,0 +1,86 @@
+package codehost_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+)
+
+const samplePatch = `@@ -1,4 +1,5 @@ package main
+ package main
+-import "fmt"
++import (
++	"fmt"
++)
+ 
+@@ -10,3 +11,2 @@ func main() {
+ 	x := 1
+-	y := 2
+ 	fmt.Println(x)
+\ No newline at end of file`
+
+func TestParsePatch(t *testing.T) {
+	patch, err := codehost.ParsePatch(samplePatch)
+	if err != nil {
+		t.Fatalf("ParsePatch() error = %v", err)
+	}
+	if len(patch.Hunks) != 2 || patch.Hunks[0].Section != "package main" || patch.Hunks[1].NewStart != 11 {
+		t.Fatalf("ParsePatch() hunks = %+v", patch.Hunks)
+	}
+
+	tests := []struct {
+		side     string
+		line     int
+		position int
+		text     string
+	}{
+		{codehost.SideRight, 1, 1, "package main"},
+		{codehost.SideLeft, 2, 2, `import "fmt"`},
+		{codehost.SideRight, 2, 3, "import ("},
+		{codehost.SideRight, 4, 5, ")"},
+		{codehost.SideRight, 5, 6, ""},
+		{codehost.SideRight, 11, 8, "\tx := 1"},
+		{codehost.SideLeft, 11, 9, "\ty := 2"},
+		{codehost.SideRight, 12, 10, "\tfmt.Println(x)"},
+	}
+	for _, tt := range tests {
+		line := patch.Find(tt.side, tt.line)
+		if line == nil {
+			t.Errorf("Find(%s, %d) = nil, want position %d", tt.side, tt.line, tt.position)
+			continue
+		}
+		if line.Position != tt.position || line.Text != tt.text {
+			t.Errorf("Find(%s, %d) = position %d %q, want position %d %q", tt.side, tt.line, line.Position, line.Text, tt.position, tt.text)
+		}
+	}
+
+	for _, missing := range []struct {
+		side string
+		line int
+	}{{codehost.SideRight, 9}, {codehost.SideLeft, 3}, {codehost.SideRight, 13}} {
+		if line := patch.Find(missing.side, missing.line); line != nil {
+			t.Errorf("Find(%s, %d) = %+v, want nil", missing.side, missing.line, line)
+		}
+	}
+
+	annotated := patch.Annotate()
+	for _, want := range []string{"L2 -import \"fmt\"\n", "R3 +\t\"fmt\"\n", "R12  \tfmt.Println(x)\n", "@@ -10,3 +11,2 @@ func main() {\n"} {
+		if !strings.Contains(annotated, want) {
+			t.Errorf("Annotate() = %q, want it to contain %q", annotated, want)
+		}
+	}
+}
+
+func TestParsePatchErrors(t *testing.T) {
+	for _, patch := range []string{
+		" context before any hunk",
+		"@@ -1 +1 @@\n-a\n+b\n+c",
+		"@@ broken @@\n a",
+		"@@ -1,2 +1,2 @@\n a\n*b",
+	} {
+		if _, err := codehost.ParsePatch(patch); err == nil {
+			t.Errorf("ParsePatch(%q) error = nil, want an error", patch)
+		}
+	}
+}
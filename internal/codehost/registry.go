@@ -0,0 +1,153 @@
+package codehost
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Registry routes change requests to the provider serving their host
+type Registry struct {
+	providers []Provider
+	byHost    map[string]Provider // keyed by lowercase host
+}
+
+// NewRegistry creates a registry of the providers. When several providers
+// claim the same host, the first one wins, and input that no provider
+// recognizes is reported with the first provider's parse error.
+func NewRegistry(providers ...Provider) *Registry {
+	byHost := map[string]Provider{}
+	for _, provider := range providers {
+		for _, host := range provider.Hosts() {
+			host = strings.ToLower(host)
+			if _, taken := byHost[host]; !taken {
+				byHost[host] = provider
+			}
+		}
+	}
+	return &Registry{providers: providers, byHost: byHost}
+}
+
+// Provider returns the provider serving host, or ErrUnknownHost
+func (r *Registry) Provider(host string) (Provider, error) {
+	if host == "" {
+		host = DefaultHost
+	}
+	provider, ok := r.byHost[strings.ToLower(host)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownHost, host)
+	}
+	return provider, nil
+}
+
+// Hosts returns every configured host, grouped by provider in registration order
+func (r *Registry) Hosts() []string {
+	var hosts []string
+	for _, provider := range r.providers {
+		for _, host := range provider.Hosts() {
+			if r.byHost[strings.ToLower(host)] == provider {
+				hosts = append(hosts, host)
+			}
+		}
+	}
+	return hosts
+}
+
+// ParseRef parses a URL or shorthand naming a change request on any configured
+// host. Each provider tries the input in turn, and the first reference whose
+// host is served by the provider that parsed it is returned.
+func (r *Registry) ParseRef(input string) (Ref, Provider, error) {
+	errs := map[Provider]error{}
+	var unsupportedHost string
+
+	for _, provider := range r.providers {
+		ref, err := provider.ParseRef(input)
+		if err != nil {
+			errs[provider] = err
+			continue
+		}
+		if served, _ := r.Provider(ref.Host); served == provider {
+			return ref, provider, nil
+		}
+		if unsupportedHost == "" {
+			unsupportedHost = ref.Host
+		}
+	}
+
+	if unsupportedHost != "" {
+		// A host served by another provider gets that provider's explanation
+		if served, err := r.Provider(unsupportedHost); err == nil && errs[served] != nil {
+			return Ref{}, nil, errs[served]
+		}
+		return Ref{}, nil, fmt.Errorf("unsupported host %s (expected one of %s)", unsupportedHost, strings.Join(r.Hosts(), ", "))
+	}
+	if len(r.providers) == 0 {
+		return Ref{}, nil, fmt.Errorf("no code hosts are configured")
+	}
+	return Ref{}, nil, errs[r.providers[0]]
+}
+
+// The methods below dispatch to the provider serving the reference's host
+
+// URL returns the web address of the change request, or "" for an unknown host
+func (r *Registry) URL(ref Ref) string {
+	provider, err := r.Provider(ref.Host)
+	if err != nil {
+		return ""
+	}
+	return provider.URL(ref)
+}
+
+func (r *Registry) FetchHeadSHA(ctx context.Context, ref Ref) (string, error) {
+	provider, err := r.Provider(ref.Host)
+	if err != nil {
+		return "", err
+	}
+	return provider.FetchHeadSHA(ctx, ref)
+}
+
+func (r *Registry) FetchChangeRequest(ctx context.Context, ref Ref) (*ChangeRequest, error) {
+	provider, err := r.Provider(ref.Host)
+	if err != nil {
+		return nil, err
+	}
+	return provider.FetchChangeRequest(ctx, ref)
+}
+
+func (r *Registry) FetchBody(ctx context.Context, ref Ref) (*Body, error) {
+	provider, err := r.Provider(ref.Host)
+	if err != nil {
+		return nil, err
+	}
+	return provider.FetchBody(ctx, ref)
+}
+
+func (r *Registry) UpdateBody(ctx context.Context, ref Ref, body string, current *Body) (*Body, error) {
+	provider, err := r.Provider(ref.Host)
+	if err != nil {
+		return nil, err
+	}
+	return provider.UpdateBody(ctx, ref, body, current)
+}
+
+func (r *Registry) CreateComment(ctx context.Context, ref Ref, body string) error {
+	provider, err := r.Provider(ref.Host)
+	if err != nil {
+		return err
+	}
+	return provider.CreateComment(ctx, ref, body)
+}
+
+func (r *Registry) CheckWrite(ctx context.Context, host, owner string) error {
+	provider, err := r.Provider(host)
+	if err != nil {
+		return err
+	}
+	return provider.CheckWrite(ctx, host, owner)
+}
+
+// CanWrite reports whether write calls to the owner's repositories on host
+// can be attempted under ctx
+func (r *Registry) CanWrite(ctx context.Context, host, owner string) bool {
+	return r.CheckWrite(ctx, host, owner) == nil
+}
@@ -0,0 +1,85 @@
+package codehost_test
+
+import (
+	"testing"
+
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+	"github.com/nahue/pr-toolbox-go/internal/gitea"
+	"github.com/nahue/pr-toolbox-go/internal/github"
+	"github.com/nahue/pr-toolbox-go/internal/gitlab"
+)
+
+func newTestRegistry(t *testing.T) *codehost.Registry {
+	t.Setenv("GITHUB_ENTERPRISE_HOSTS", "ghe.example.com")
+	t.Setenv("GITLAB_HOSTS", "gitlab.example.com")
+	t.Setenv("GITEA_HOSTS", "code.example.com")
+	return codehost.NewRegistry(github.NewService(), gitlab.NewService(), gitea.NewService())
+}
+
+func TestRegistryParseRefRoutesByHost(t *testing.T) {
+	registry := newTestRegistry(t)
+
+	tests := []struct {
+		input    string
+		provider string
+		want     codehost.Ref
+	}{
+		{"https://github.com/octo-org/hello.world/pull/123", "GitHub", codehost.Ref{Host: "github.com", Owner: "octo-org", Repo: "hello.world", Number: 123}},
+		{"octo-org/hello.world#123", "GitHub", codehost.Ref{Host: "github.com", Owner: "octo-org", Repo: "hello.world", Number: 123}},
+		{"https://ghe.example.com/team/service/pull/7", "GitHub", codehost.Ref{Host: "ghe.example.com", Owner: "team", Repo: "service", Number: 7}},
+		{"https://gitlab.com/group/project/-/merge_requests/42", "GitLab", codehost.Ref{Host: "gitlab.com", Owner: "group", Repo: "project", Number: 42}},
+		{"https://gitlab.com/group/sub/project/-/merge_requests/42/diffs", "GitLab", codehost.Ref{Host: "gitlab.com", Owner: "group/sub", Repo: "project", Number: 42}},
+		{"group/sub/project!42", "GitLab", codehost.Ref{Host: "gitlab.com", Owner: "group/sub", Repo: "project", Number: 42}},
+		{"https://gitlab.example.com/team/service/merge_requests/7", "GitLab", codehost.Ref{Host: "gitlab.example.com", Owner: "team", Repo: "service", Number: 7}},
+		{"gitlab.example.com/team/service!7", "GitLab", codehost.Ref{Host: "gitlab.example.com", Owner: "team", Repo: "service", Number: 7}},
+		{"https://code.example.com/team/service/pulls/7/files", "Gitea", codehost.Ref{Host: "code.example.com", Owner: "team", Repo: "service", Number: 7}},
+		{"code.example.com/team/service#7", "Gitea", codehost.Ref{Host: "code.example.com", Owner: "team", Repo: "service", Number: 7}},
+	}
+
+	for _, tt := range tests {
+		ref, provider, err := registry.ParseRef(tt.input)
+		if err != nil {
+			t.Errorf("ParseRef(%q) returned error: %v", tt.input, err)
+			continue
+		}
+		if ref != tt.want || provider.Name() != tt.provider {
+			t.Errorf("ParseRef(%q) = %+v via %s, want %+v via %s", tt.input, ref, provider.Name(), tt.want, tt.provider)
+		}
+	}
+}
+
+func TestRegistryParseRefChecksHost(t *testing.T) {
+	registry := newTestRegistry(t)
+
+	for _, input := range []string{
+		"https://ghe.other.com/team/service/pull/7",
+		"https://gitlab.other.com/team/service/-/merge_requests/7",
+		"https://gitlab.com/team/service/pull/7",
+		"https://github.com/team/service/-/merge_requests/7",
+		"https://code.example.com/team/service/-/merge_requests/7",
+	} {
+		if ref, _, err := registry.ParseRef(input); err == nil {
+			t.Errorf("ParseRef(%q) = %+v, want an error", input, ref)
+		}
+	}
+}
+
+func TestRegistryURL(t *testing.T) {
+	registry := newTestRegistry(t)
+
+	tests := []struct {
+		ref  codehost.Ref
+		want string
+	}{
+		{codehost.Ref{Host: "github.com", Owner: "octo-org", Repo: "hello.world", Number: 123}, "https://github.com/octo-org/hello.world/pull/123"},
+		{codehost.Ref{Host: "gitlab.com", Owner: "group/sub", Repo: "project", Number: 42}, "https://gitlab.com/group/sub/project/-/merge_requests/42"},
+		{codehost.Ref{Host: "code.example.com", Owner: "team", Repo: "service", Number: 7}, "https://code.example.com/team/service/pulls/7"},
+		{codehost.Ref{Host: "unknown.example.com", Owner: "o", Repo: "r", Number: 1}, ""},
+	}
+
+	for _, tt := range tests {
+		if got := registry.URL(tt.ref); got != tt.want {
+			t.Errorf("URL(%+v) = %q, want %q", tt.ref, got, tt.want)
+		}
+	}
+}
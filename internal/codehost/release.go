@@ -0,0 +1,125 @@
+package codehost
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Release is the set of pull requests merged between two refs (tags,
+// branches or commits) of a repository
+type Release struct {
+	Host           string                `json:"host"`
+	Repository     string                `json:"repository"`
+	From           string                `json:"from"`
+	To             string                `json:"to"`
+	URL            string                `json:"url"`      // web page comparing the two refs
+	HeadSHA        string                `json:"head_sha"` // commit To pointed to when fetched
+	Commits        int                   `json:"commits"`  // commits between the refs
+	PullRequests   []*ReleasePullRequest `json:"pull_requests"`
+	Truncated      bool                  `json:"truncated"`
+	TruncatedLists []string              `json:"truncated_lists,omitempty"`
+}
+
+// ReleasePullRequest is a merged pull request that is part of a release
+type ReleasePullRequest struct {
+	Number   int       `json:"number"`
+	Title    string    `json:"title"`
+	Body     string    `json:"body"`
+	Author   string    `json:"author"`
+	Labels   []string  `json:"labels"`
+	URL      string    `json:"url"`
+	MergedAt time.Time `json:"merged_at"`
+}
+
+// Release note sections, in the order they appear in the changelog
+const (
+	SectionBreaking = "Breaking Changes"
+	SectionFeatures = "Features"
+	SectionFixes    = "Fixes"
+	SectionChores   = "Chores"
+	SectionOther    = "Other Changes"
+)
+
+// ReleaseSections lists the release note sections in changelog order
+var ReleaseSections = []string{SectionBreaking, SectionFeatures, SectionFixes, SectionChores, SectionOther}
+
+var (
+	// labelSections maps label names, without a "type:" or "kind/" prefix, to sections
+	labelSections = map[string]string{
+		"breaking": SectionBreaking, "breaking change": SectionBreaking, "breaking-change": SectionBreaking, "semver-major": SectionBreaking,
+		"feature": SectionFeatures, "feat": SectionFeatures, "enhancement": SectionFeatures, "new feature": SectionFeatures,
+		"bug": SectionFixes, "fix": SectionFixes, "bugfix": SectionFixes, "regression": SectionFixes,
+		"chore": SectionChores, "dependencies": SectionChores, "ci": SectionChores, "build": SectionChores, "refactor": SectionChores,
+		"documentation": SectionChores, "docs": SectionChores, "maintenance": SectionChores, "test": SectionChores, "tests": SectionChores,
+	}
+
+	// commitTypeSections maps conventional commit types to sections
+	commitTypeSections = map[string]string{
+		"feat": SectionFeatures, "perf": SectionFeatures,
+		"fix": SectionFixes, "revert": SectionFixes,
+		"chore": SectionChores, "ci": SectionChores, "build": SectionChores, "docs": SectionChores,
+		"refactor": SectionChores, "style": SectionChores, "test": SectionChores, "deps": SectionChores,
+	}
+
+	// conventionalTitle matches "type(scope)!: subject"
+	conventionalTitle = regexp.MustCompile(`^([A-Za-z]+)(?:\(([^)]*)\))?(!)?:\s*(.+)$`)
+
+	// breakingFooter matches the conventional commit footer announcing a breaking change
+	breakingFooter = regexp.MustCompile(`(?m)^BREAKING[ -]CHANGE:`)
+)
+
+// ReleaseEntry is a pull request placed in a release note section
+type ReleaseEntry struct {
+	*ReleasePullRequest
+	Scope   string // conventional commit scope, if any
+	Summary string // the title without its conventional commit prefix
+}
+
+// GroupReleasePullRequests places each pull request in a section: breaking
+// changes first, from a label, a "!" after the conventional commit type or a
+// BREAKING CHANGE footer, then the section of its first recognised label,
+// then that of its conventional commit type. Pull requests matching none are
+// other changes. Empty sections are left out.
+func GroupReleasePullRequests(pullRequests []*ReleasePullRequest) map[string][]*ReleaseEntry {
+	sections := map[string][]*ReleaseEntry{}
+	for _, pr := range pullRequests {
+		entry := &ReleaseEntry{ReleasePullRequest: pr, Summary: strings.TrimSpace(pr.Title)}
+		var commitType string
+		breaking := breakingFooter.MatchString(pr.Body)
+		if match := conventionalTitle.FindStringSubmatch(entry.Summary); match != nil {
+			commitType = strings.ToLower(match[1])
+			if _, known := commitTypeSections[commitType]; known || match[3] != "" {
+				entry.Scope, entry.Summary = match[2], match[4]
+				breaking = breaking || match[3] != ""
+			}
+		}
+
+		section := ""
+		for _, label := range pr.Labels {
+			if s := labelSections[labelKind(label)]; s != "" && (section == "" || s == SectionBreaking) {
+				section = s
+			}
+		}
+		if breaking {
+			section = SectionBreaking
+		}
+		if section == "" {
+			section = commitTypeSections[commitType]
+		}
+		if section == "" {
+			section = SectionOther
+		}
+		sections[section] = append(sections[section], entry)
+	}
+	return sections
+}
+
+// labelKind lowercases a label and drops a "type:" or "kind/" style prefix
+func labelKind(label string) string {
+	label = strings.ToLower(strings.TrimSpace(label))
+	if i := strings.LastIndexAny(label, ":/"); i >= 0 {
+		label = strings.TrimSpace(label[i+1:])
+	}
+	return label
+}
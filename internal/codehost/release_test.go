@@ -0,0 +1,40 @@
+package codehost_test
+
+import (
+	"testing"
+
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+)
+
+func TestGroupReleasePullRequests(t *testing.T) {
+	tests := []struct {
+		name        string
+		pr          codehost.ReleasePullRequest
+		wantSection string
+		wantScope   string
+		wantSummary string
+	}{
+		{"feature label", codehost.ReleasePullRequest{Title: "Add dark mode", Labels: []string{"enhancement"}}, codehost.SectionFeatures, "", "Add dark mode"},
+		{"prefixed label", codehost.ReleasePullRequest{Title: "Crash on start", Labels: []string{"type: bug"}}, codehost.SectionFixes, "", "Crash on start"},
+		{"commit type", codehost.ReleasePullRequest{Title: "fix(api): handle empty pages"}, codehost.SectionFixes, "api", "handle empty pages"},
+		{"label wins over commit type", codehost.ReleasePullRequest{Title: "feat: bump the linter", Labels: []string{"dependencies"}}, codehost.SectionChores, "", "bump the linter"},
+		{"breaking marker", codehost.ReleasePullRequest{Title: "feat(cli)!: drop --world", Labels: []string{"enhancement"}}, codehost.SectionBreaking, "cli", "drop --world"},
+		{"breaking footer", codehost.ReleasePullRequest{Title: "refactor: rename config keys", Body: "Renames keys.\n\nBREAKING CHANGE: old keys are ignored"}, codehost.SectionBreaking, "", "rename config keys"},
+		{"breaking label", codehost.ReleasePullRequest{Title: "Remove v1 endpoints", Labels: []string{"bug", "breaking-change"}}, codehost.SectionBreaking, "", "Remove v1 endpoints"},
+		{"unknown prefix kept", codehost.ReleasePullRequest{Title: "Docs: fix typo"}, codehost.SectionChores, "", "fix typo"},
+		{"not a commit type", codehost.ReleasePullRequest{Title: "Note: this is a title"}, codehost.SectionOther, "", "Note: this is a title"},
+		{"other", codehost.ReleasePullRequest{Title: "Tidy up"}, codehost.SectionOther, "", "Tidy up"},
+	}
+	for _, tt := range tests {
+		pr := tt.pr
+		sections := codehost.GroupReleasePullRequests([]*codehost.ReleasePullRequest{&pr})
+		entries := sections[tt.wantSection]
+		if len(sections) != 1 || len(entries) != 1 {
+			t.Errorf("%s: GroupReleasePullRequests(%q) = %v, want it in %s", tt.name, pr.Title, sections, tt.wantSection)
+			continue
+		}
+		if entries[0].Scope != tt.wantScope || entries[0].Summary != tt.wantSummary {
+			t.Errorf("%s: entry = (%q, %q), want (%q, %q)", tt.name, entries[0].Scope, entries[0].Summary, tt.wantScope, tt.wantSummary)
+		}
+	}
+}
@@ -39,6 +39,133 @@ type Session struct {
 	CreatedAt    time.Time `json:"created_at"`
 }
 
+// Generation settings scopes, from least to most specific
+const (
+	SettingsScopeGlobal     = "global"
+	SettingsScopeRepository = "repository"
+	SettingsScopeUser       = "user"
+)
+
+// GenerationSettings overrides the LLM settings for a scope. Nil fields are
+// inherited from the less specific scopes.
+type GenerationSettings struct {
+	ID          string    `json:"id"`
+	Scope       string    `json:"scope"`
+	ScopeKey    string    `json:"scope_key"`
+	Model       *string   `json:"model,omitempty"`
+	Temperature *float64  `json:"temperature,omitempty"`
+	MaxTokens   *int      `json:"max_tokens,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Kinds of stored generations
+const (
+	GenerationKindDescription  = "description"   // a pull request description
+	GenerationKindReleaseNotes = "release_notes" // release notes for the changes between two refs
+)
+
+// PRDescription is a stored generated pull request description, or another
+// kind of generation stored alongside them
+type PRDescription struct {
+	ID               string    `json:"id"`
+	Kind             string    `json:"kind"`
+	UserID           string    `json:"user_id,omitempty"`
+	UserEmail        string    `json:"user_email,omitempty"`
+	Host             string    `json:"host"`
+	Repository       string    `json:"repository"`
+	PRNumber         int       `json:"pr_number"`
+	URL              string    `json:"url"`                // web page of the pull or merge request
+	BaseRef          string    `json:"base_ref,omitempty"` // compared refs, set for branch comparisons and release notes
+	HeadRef          string    `json:"head_ref,omitempty"`
+	HeadSHA          string    `json:"head_sha"`
+	Provider         string    `json:"provider"`
+	Model            string    `json:"model"`
+	Temperature      float64   `json:"temperature"`
+	MaxTokens        int       `json:"max_tokens"`
+	PromptVersion    string    `json:"prompt_version"`
+	Description      string    `json:"description"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// PRDescriptionFilter narrows ListPRDescriptions. Zero fields are not applied.
+type PRDescriptionFilter struct {
+	UserID     string
+	Repository string
+	Since      time.Time // inclusive
+	Until      time.Time // exclusive
+	Limit      int
+}
+
+// PRDescriptionApplication records a stored description being written to its pull request
+type PRDescriptionApplication struct {
+	ID              string    `json:"id"`
+	PRDescriptionID string    `json:"pr_description_id"`
+	UserID          string    `json:"user_id,omitempty"`
+	Host            string    `json:"host"`
+	Repository      string    `json:"repository"`
+	PRNumber        int       `json:"pr_number"`
+	URL             string    `json:"url"`
+	Mode            string    `json:"mode"`
+	PreviousBody    string    `json:"previous_body"`
+	NewBody         string    `json:"new_body"`
+	AppliedAt       time.Time `json:"applied_at"`
+}
+
+// Webhook modes controlling what happens to descriptions generated from webhook events
+const (
+	WebhookModeComment = "comment" // post the description as a PR comment
+	WebhookModeBody    = "body"    // write the description to the PR body
+	WebhookModeStore   = "store"   // only store the description
+)
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryProcessing = "processing" // claimed and not yet settled
+	WebhookDeliveryDone       = "done"       // handled, or deliberately ignored
+	WebhookDeliveryFailed     = "failed"     // may be claimed again when redelivered
+)
+
+// WebhookSettings opts a repository in to generating descriptions from webhook events
+type WebhookSettings struct {
+	Host       string    `json:"host"` // github.com or a GitHub Enterprise host
+	Repository string    `json:"repository"`
+	Mode       string    `json:"mode"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// GitHubConnection is a user's GitHub account connected through OAuth. The
+// access token is stored encrypted.
+type GitHubConnection struct {
+	UserID         string    `json:"user_id"`
+	Host           string    `json:"host"` // the token is only ever sent to this host
+	Login          string    `json:"login"`
+	EncryptedToken string    `json:"-"`
+	Scopes         string    `json:"scopes"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// PRDescriptionCacheKey identifies generations that would produce the same
+// description: same PR head, same prompts and same model settings
+type PRDescriptionCacheKey struct {
+	Host          string
+	Repository    string
+	PRNumber      int
+	HeadSHA       string
+	PromptVersion string
+	Provider      string
+	Model         string
+	Temperature   float64
+	MaxTokens     int
+
+	// PreferUserID is not part of the key: among matching rows, those of this
+	// user are returned first
+	PreferUserID string
+}
+
 func NewDatabase() (*Database, error) {
 	// Ensure data directory exists
 	if err := os.MkdirAll("data", 0755); err != nil {
@@ -282,7 +409,524 @@ func (d *Database) CleanupExpiredSessions() error {
 	return nil
 }
 
+// Generation settings operations
+func (d *Database) GetGenerationSettings(scope, scopeKey string) (*GenerationSettings, error) {
+	query := `SELECT id, scope, scope_key, model, temperature, max_tokens, updated_at FROM generation_settings WHERE scope = ? AND scope_key = ?`
+
+	settings, err := scanGenerationSettings(d.db.QueryRow(query, scope, scopeKey))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get generation settings: %w", err)
+	}
+
+	return settings, nil
+}
+
+func (d *Database) ListGenerationSettings(scope string) ([]*GenerationSettings, error) {
+	query := `SELECT id, scope, scope_key, model, temperature, max_tokens, updated_at FROM generation_settings WHERE scope = ? ORDER BY scope_key`
+
+	rows, err := d.db.Query(query, scope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list generation settings: %w", err)
+	}
+	defer rows.Close()
+
+	var list []*GenerationSettings
+	for rows.Next() {
+		settings, err := scanGenerationSettings(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan generation settings: %w", err)
+		}
+		list = append(list, settings)
+	}
+
+	return list, rows.Err()
+}
+
+func (d *Database) UpsertGenerationSettings(scope, scopeKey string, model *string, temperature *float64, maxTokens *int) error {
+	query := `INSERT INTO generation_settings (id, scope, scope_key, model, temperature, max_tokens, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (scope, scope_key) DO UPDATE SET
+			model = excluded.model,
+			temperature = excluded.temperature,
+			max_tokens = excluded.max_tokens,
+			updated_at = excluded.updated_at`
+	_, err := d.db.Exec(query, generateUUID(), scope, scopeKey, model, temperature, maxTokens, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to save generation settings: %w", err)
+	}
+	return nil
+}
+
+func (d *Database) DeleteGenerationSettings(scope, scopeKey string) error {
+	query := `DELETE FROM generation_settings WHERE scope = ? AND scope_key = ?`
+	_, err := d.db.Exec(query, scope, scopeKey)
+	if err != nil {
+		return fmt.Errorf("failed to delete generation settings: %w", err)
+	}
+	return nil
+}
+
+// PR description operations
+func (d *Database) CreatePRDescription(description *PRDescription) error {
+	descriptionID := generateUUID()
+	now := time.Now().UTC()
+	if description.Kind == "" {
+		description.Kind = GenerationKindDescription
+	}
+
+	var userID sql.NullString
+	if description.UserID != "" {
+		userID = sql.NullString{String: description.UserID, Valid: true}
+	}
+
+	query := `INSERT INTO pr_descriptions (id, kind, user_id, host, repository, pr_number, url, base_ref, head_ref, head_sha, provider, model, temperature, max_tokens, prompt_version, description, prompt_tokens, completion_tokens, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := d.db.Exec(query,
+		descriptionID,
+		description.Kind,
+		userID,
+		description.Host,
+		description.Repository,
+		description.PRNumber,
+		description.URL,
+		description.BaseRef,
+		description.HeadRef,
+		description.HeadSHA,
+		description.Provider,
+		description.Model,
+		description.Temperature,
+		description.MaxTokens,
+		description.PromptVersion,
+		description.Description,
+		description.PromptTokens,
+		description.CompletionTokens,
+		now,
+		now,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create PR description: %w", err)
+	}
+
+	description.ID = descriptionID
+	description.CreatedAt = now
+	description.UpdatedAt = now
+	return nil
+}
+
+// prDescriptionColumns selects a PR description together with the email of the user who generated it
+const prDescriptionColumns = `d.id, d.kind, d.user_id, u.email, d.host, d.repository, d.pr_number, d.url, d.base_ref, d.head_ref, d.head_sha, d.provider, d.model, d.temperature, d.max_tokens,
+	d.prompt_version, d.description, d.prompt_tokens, d.completion_tokens, d.created_at, d.updated_at
+	FROM pr_descriptions d LEFT JOIN users u ON u.id = d.user_id`
+
+func (d *Database) GetPRDescription(id string) (*PRDescription, error) {
+	query := `SELECT ` + prDescriptionColumns + ` WHERE d.id = ?`
+
+	description, err := scanPRDescription(d.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get PR description: %w", err)
+	}
+
+	return description, nil
+}
+
+// GetCachedPRDescription returns the newest description stored for the cache key
+func (d *Database) GetCachedPRDescription(key PRDescriptionCacheKey) (*PRDescription, error) {
+	query := `SELECT ` + prDescriptionColumns + `
+		WHERE d.host = ? COLLATE NOCASE AND d.repository = ? COLLATE NOCASE AND d.pr_number = ? AND d.head_sha = ? AND d.prompt_version = ?
+			AND d.provider = ? AND d.model = ? AND d.temperature = ? AND d.max_tokens = ?
+		ORDER BY COALESCE(d.user_id, '') = ? DESC, d.created_at DESC LIMIT 1`
+
+	description, err := scanPRDescription(d.db.QueryRow(query,
+		key.Host,
+		key.Repository,
+		key.PRNumber,
+		key.HeadSHA,
+		key.PromptVersion,
+		key.Provider,
+		key.Model,
+		key.Temperature,
+		key.MaxTokens,
+		key.PreferUserID,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get cached PR description: %w", err)
+	}
+
+	return description, nil
+}
+
+// ListPRDescriptions returns the stored descriptions matching the filter, newest first
+func (d *Database) ListPRDescriptions(filter PRDescriptionFilter) ([]*PRDescription, error) {
+	query := `SELECT ` + prDescriptionColumns + ` WHERE 1 = 1`
+	var args []any
+
+	if filter.UserID != "" {
+		query += ` AND d.user_id = ?`
+		args = append(args, filter.UserID)
+	}
+	if filter.Repository != "" {
+		query += ` AND d.repository = ? COLLATE NOCASE`
+		args = append(args, filter.Repository)
+	}
+	if !filter.Since.IsZero() {
+		query += ` AND d.created_at >= ?`
+		args = append(args, filter.Since.UTC())
+	}
+	if !filter.Until.IsZero() {
+		query += ` AND d.created_at < ?`
+		args = append(args, filter.Until.UTC())
+	}
+	query += ` ORDER BY d.created_at DESC`
+	if filter.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list PR descriptions: %w", err)
+	}
+	defer rows.Close()
+
+	var list []*PRDescription
+	for rows.Next() {
+		description, err := scanPRDescription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan PR description: %w", err)
+		}
+		list = append(list, description)
+	}
+
+	return list, rows.Err()
+}
+
+// ListPRDescriptionRepositories returns every repository with descriptions
+// stored by the user, or by anyone when userID is empty
+func (d *Database) ListPRDescriptionRepositories(userID string) ([]string, error) {
+	query := `SELECT DISTINCT repository FROM pr_descriptions`
+	var args []any
+	if userID != "" {
+		query += ` WHERE user_id = ?`
+		args = append(args, userID)
+	}
+	query += ` ORDER BY repository`
+
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list PR description repositories: %w", err)
+	}
+	defer rows.Close()
+
+	var repositories []string
+	for rows.Next() {
+		var repository string
+		if err := rows.Scan(&repository); err != nil {
+			return nil, fmt.Errorf("failed to scan repository: %w", err)
+		}
+		repositories = append(repositories, repository)
+	}
+
+	return repositories, rows.Err()
+}
+
+// PR description application operations
+func (d *Database) CreatePRDescriptionApplication(application *PRDescriptionApplication) error {
+	applicationID := generateUUID()
+	now := time.Now().UTC()
+
+	var userID sql.NullString
+	if application.UserID != "" {
+		userID = sql.NullString{String: application.UserID, Valid: true}
+	}
+
+	query := `INSERT INTO pr_description_applications (id, pr_description_id, user_id, host, repository, pr_number, url, mode, previous_body, new_body, applied_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := d.db.Exec(query,
+		applicationID,
+		application.PRDescriptionID,
+		userID,
+		application.Host,
+		application.Repository,
+		application.PRNumber,
+		application.URL,
+		application.Mode,
+		application.PreviousBody,
+		application.NewBody,
+		now,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create PR description application: %w", err)
+	}
+
+	application.ID = applicationID
+	application.AppliedAt = now
+	return nil
+}
+
+// Webhook operations
+
+// ClaimWebhookDelivery records a delivery as being processed and reports
+// whether the caller should process it. GitHub retries deliveries and they can
+// be redelivered by hand, so a delivery already done or being processed is not
+// claimed again; one that failed, or has been processing for longer than
+// staleAfter (the process handling it died), is.
+func (d *Database) ClaimWebhookDelivery(deliveryID, event string, staleAfter time.Duration) (bool, error) {
+	now := time.Now().UTC()
+	query := `INSERT INTO webhook_deliveries (delivery_id, event, received_at, status) VALUES (?, ?, ?, ?)
+		ON CONFLICT (delivery_id) DO UPDATE SET status = excluded.status, received_at = excluded.received_at
+		WHERE webhook_deliveries.status = ? OR (webhook_deliveries.status = ? AND webhook_deliveries.received_at < ?)`
+	result, err := d.db.Exec(query, deliveryID, event, now, WebhookDeliveryProcessing,
+		WebhookDeliveryFailed, WebhookDeliveryProcessing, now.Add(-staleAfter))
+	if err != nil {
+		return false, fmt.Errorf("failed to claim webhook delivery: %w", err)
+	}
+
+	claimed, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim webhook delivery: %w", err)
+	}
+	return claimed > 0, nil
+}
+
+// SettleWebhookDelivery records the outcome of a claimed delivery
+func (d *Database) SettleWebhookDelivery(deliveryID, status string) error {
+	query := `UPDATE webhook_deliveries SET status = ? WHERE delivery_id = ?`
+	_, err := d.db.Exec(query, status, deliveryID)
+	if err != nil {
+		return fmt.Errorf("failed to settle webhook delivery: %w", err)
+	}
+	return nil
+}
+
+func (d *Database) GetWebhookSettings(host, repository string) (*WebhookSettings, error) {
+	query := `SELECT host, repository, mode, updated_at FROM webhook_settings WHERE host = ? AND repository = ?`
+
+	var settings WebhookSettings
+	err := d.db.QueryRow(query, host, repository).Scan(&settings.Host, &settings.Repository, &settings.Mode, &settings.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get webhook settings: %w", err)
+	}
+
+	return &settings, nil
+}
+
+func (d *Database) ListWebhookSettings() ([]*WebhookSettings, error) {
+	query := `SELECT host, repository, mode, updated_at FROM webhook_settings ORDER BY host, repository`
+
+	rows, err := d.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook settings: %w", err)
+	}
+	defer rows.Close()
+
+	var list []*WebhookSettings
+	for rows.Next() {
+		var settings WebhookSettings
+		if err := rows.Scan(&settings.Host, &settings.Repository, &settings.Mode, &settings.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook settings: %w", err)
+		}
+		list = append(list, &settings)
+	}
+
+	return list, rows.Err()
+}
+
+func (d *Database) UpsertWebhookSettings(host, repository, mode string) error {
+	query := `INSERT INTO webhook_settings (host, repository, mode, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (host, repository) DO UPDATE SET mode = excluded.mode, updated_at = excluded.updated_at`
+	_, err := d.db.Exec(query, host, repository, mode, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to save webhook settings: %w", err)
+	}
+	return nil
+}
+
+func (d *Database) DeleteWebhookSettings(host, repository string) error {
+	query := `DELETE FROM webhook_settings WHERE host = ? AND repository = ?`
+	_, err := d.db.Exec(query, host, repository)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook settings: %w", err)
+	}
+	return nil
+}
+
+// GitHub connection operations
+
+const githubConnectionColumns = `user_id, host, login, encrypted_token, scopes, created_at, updated_at`
+
+// GetGitHubConnection returns the user's connection on host, or nil when they
+// have not connected an account there
+func (d *Database) GetGitHubConnection(userID, host string) (*GitHubConnection, error) {
+	query := `SELECT ` + githubConnectionColumns + ` FROM github_connections WHERE user_id = ? AND host = ?`
+
+	connection, err := scanGitHubConnection(d.db.QueryRow(query, userID, host))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get GitHub connection: %w", err)
+	}
+
+	return connection, nil
+}
+
+// ListGitHubConnections returns the user's connections on every host
+func (d *Database) ListGitHubConnections(userID string) ([]*GitHubConnection, error) {
+	query := `SELECT ` + githubConnectionColumns + ` FROM github_connections WHERE user_id = ? ORDER BY host`
+
+	rows, err := d.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list GitHub connections: %w", err)
+	}
+	defer rows.Close()
+
+	var connections []*GitHubConnection
+	for rows.Next() {
+		connection, err := scanGitHubConnection(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan GitHub connection: %w", err)
+		}
+		connections = append(connections, connection)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list GitHub connections: %w", err)
+	}
+
+	return connections, nil
+}
+
+func (d *Database) UpsertGitHubConnection(connection *GitHubConnection) error {
+	now := time.Now()
+	query := `INSERT INTO github_connections (user_id, host, login, encrypted_token, scopes, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (user_id, host) DO UPDATE SET
+			login = excluded.login,
+			encrypted_token = excluded.encrypted_token,
+			scopes = excluded.scopes,
+			updated_at = excluded.updated_at`
+	_, err := d.db.Exec(query, connection.UserID, connection.Host, connection.Login, connection.EncryptedToken, connection.Scopes, now, now)
+	if err != nil {
+		return fmt.Errorf("failed to save GitHub connection: %w", err)
+	}
+
+	connection.UpdatedAt = now
+	return nil
+}
+
+func (d *Database) DeleteGitHubConnection(userID, host string) error {
+	query := `DELETE FROM github_connections WHERE user_id = ? AND host = ?`
+	_, err := d.db.Exec(query, userID, host)
+	if err != nil {
+		return fmt.Errorf("failed to delete GitHub connection: %w", err)
+	}
+	return nil
+}
+
+func scanGitHubConnection(row rowScanner) (*GitHubConnection, error) {
+	var connection GitHubConnection
+	err := row.Scan(
+		&connection.UserID,
+		&connection.Host,
+		&connection.Login,
+		&connection.EncryptedToken,
+		&connection.Scopes,
+		&connection.CreatedAt,
+		&connection.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &connection, nil
+}
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanGenerationSettings(row rowScanner) (*GenerationSettings, error) {
+	var settings GenerationSettings
+	var model sql.NullString
+	var temperature sql.NullFloat64
+	var maxTokens sql.NullInt64
+
+	err := row.Scan(
+		&settings.ID,
+		&settings.Scope,
+		&settings.ScopeKey,
+		&model,
+		&temperature,
+		&maxTokens,
+		&settings.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if model.Valid {
+		settings.Model = &model.String
+	}
+	if temperature.Valid {
+		settings.Temperature = &temperature.Float64
+	}
+	if maxTokens.Valid {
+		value := int(maxTokens.Int64)
+		settings.MaxTokens = &value
+	}
+
+	return &settings, nil
+}
+
 // Helper function to generate UUID (simplified for SQLite)
 func generateUUID() string {
 	return fmt.Sprintf("%d", time.Now().UnixNano())
 }
+
+func scanPRDescription(row rowScanner) (*PRDescription, error) {
+	var description PRDescription
+	var userID, userEmail sql.NullString
+
+	err := row.Scan(
+		&description.ID,
+		&description.Kind,
+		&userID,
+		&userEmail,
+		&description.Host,
+		&description.Repository,
+		&description.PRNumber,
+		&description.URL,
+		&description.BaseRef,
+		&description.HeadRef,
+		&description.HeadSHA,
+		&description.Provider,
+		&description.Model,
+		&description.Temperature,
+		&description.MaxTokens,
+		&description.PromptVersion,
+		&description.Description,
+		&description.PromptTokens,
+		&description.CompletionTokens,
+		&description.CreatedAt,
+		&description.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	description.UserID = userID.String
+	description.UserEmail = userEmail.String
+
+	return &description, nil
+}
@@ -0,0 +1,208 @@
+package gitea
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+)
+
+const (
+	// perPage is the page size requested from list endpoints, matching the
+	// default of Gitea's MAX_RESPONSE_ITEMS cap
+	perPage = 50
+
+	// maxDiffBytes caps how much of a pull request's diff is downloaded
+	maxDiffBytes = 10 << 20
+)
+
+// hostClient talks to the REST API of one Gitea or Forgejo instance. Its token
+// never leaves the host it is configured for.
+type hostClient struct {
+	host       string
+	apiURL     string // API root ending in /api/v1
+	token      string // empty for anonymous access to public repositories
+	httpClient *http.Client
+}
+
+// pullPath returns the API path of the pull request named by ref
+func pullPath(ref codehost.Ref) string {
+	return fmt.Sprintf("/repos/%s/%s/pulls/%d", url.PathEscape(ref.Owner), url.PathEscape(ref.Repo), ref.Number)
+}
+
+// newRequest builds an authenticated request to the API
+func (c *hostClient) newRequest(ctx context.Context, method, path string, query url.Values, body any) (*http.Request, error) {
+	endpoint := c.apiURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "token "+c.token)
+	}
+	return req, nil
+}
+
+// send performs the request, mapping transport failures and unsuccessful
+// responses onto the codehost error taxonomy. The caller closes the body.
+func (c *hostClient) send(req *http.Request) (*http.Response, error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, c.classifyError(err)
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		return nil, c.responseError(resp)
+	}
+	return resp, nil
+}
+
+// do sends a JSON request to the API and decodes the JSON response into out,
+// which may be nil
+func (c *hostClient) do(ctx context.Context, method, path string, query url.Values, body, out any) (*http.Response, error) {
+	req, err := c.newRequest(ctx, method, path, query, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.send(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp, fmt.Errorf("failed to decode Gitea response: %w", err)
+		}
+	}
+	return resp, nil
+}
+
+// getText fetches a plain-text endpoint, reading at most limit bytes. The
+// boolean result reports whether the text was cut at the limit.
+func (c *hostClient) getText(ctx context.Context, path string, limit int64) (string, bool, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return "", false, err
+	}
+
+	resp, err := c.send(req)
+	if err != nil {
+		return "", false, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return "", false, c.classifyError(err)
+	}
+	if int64(len(data)) > limit {
+		return string(data[:limit]), true, nil
+	}
+	return string(data), false, nil
+}
+
+// responseError maps an unsuccessful response onto the codehost error taxonomy
+func (c *hostClient) responseError(resp *http.Response) error {
+	var apiErr struct {
+		Message string `json:"message"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	message := strings.TrimSpace(string(data))
+	if json.Unmarshal(data, &apiErr) == nil && apiErr.Message != "" {
+		message = apiErr.Message
+	}
+	err := fmt.Errorf("Gitea API returned %s: %s", resp.Status, message)
+
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return fmt.Errorf("%w: %v", codehost.ErrNotFound, err)
+	case http.StatusUnauthorized:
+		return fmt.Errorf("%w: %v", codehost.ErrUnauthorized, err)
+	case http.StatusForbidden:
+		return fmt.Errorf("%w: %v", codehost.ErrForbidden, err)
+	case http.StatusTooManyRequests:
+		reset := time.Now().Add(time.Minute)
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			reset = time.Now().Add(time.Duration(seconds) * time.Second)
+		}
+		return &codehost.RateLimitError{Host: c.host, Reset: reset, Err: err}
+	}
+	return err
+}
+
+// classifyError maps a transport error onto the codehost error taxonomy
+func (c *hostClient) classifyError(err error) error {
+	// Cancellation comes from our own caller, not from the forge
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	var urlErr *url.Error
+	var netErr net.Error
+	if errors.As(err, &urlErr) || errors.As(err, &netErr) {
+		return &codehost.NetworkError{Host: c.host, Err: err}
+	}
+	return err
+}
+
+// listAll follows the pagination of a Gitea list endpoint until every page has
+// been fetched or limit items have been collected. The boolean result reports
+// whether items were left out because of the limit.
+func listAll[T any](ctx context.Context, c *hostClient, path string, limit int) ([]T, bool, error) {
+	query := url.Values{"limit": {strconv.Itoa(perPage)}}
+	var all []T
+
+	for page := 1; ; page++ {
+		query.Set("page", strconv.Itoa(page))
+
+		var items []T
+		resp, err := c.do(ctx, http.MethodGet, path, query, nil, &items)
+		if err != nil {
+			return all, false, err
+		}
+		all = append(all, items...)
+
+		// X-Total-Count is authoritative; without it a short page is the last
+		more := len(items) >= perPage
+		if total, err := strconv.Atoi(resp.Header.Get("X-Total-Count")); err == nil {
+			more = len(items) > 0 && len(all) < total
+		}
+
+		if len(all) >= limit {
+			truncated := len(all) > limit || more
+			return all[:limit], truncated, nil
+		}
+		if !more {
+			return all, false, nil
+		}
+	}
+}
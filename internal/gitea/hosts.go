@@ -0,0 +1,89 @@
+package gitea
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+)
+
+// instance is a Gitea or Forgejo instance listed in GITEA_HOSTS
+type instance struct {
+	host   string // as it appears in pull request URLs
+	apiURL string // API root, /api/v1 is appended when missing
+}
+
+// instancesFromEnv parses GITEA_HOSTS, a comma-separated list of hosts, each
+// optionally followed by "=<API URL>" when the API is not served from
+// https://<host>/api/v1
+func instancesFromEnv() ([]instance, error) {
+	var instances []instance
+	for _, entry := range strings.Split(os.Getenv("GITEA_HOSTS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		host, apiURL, _ := strings.Cut(entry, "=")
+		host = strings.ToLower(strings.TrimSpace(host))
+		if host == "" || strings.ContainsAny(host, "/ ") {
+			return nil, fmt.Errorf("invalid GITEA_HOSTS entry %q", entry)
+		}
+
+		apiURL = strings.TrimSpace(apiURL)
+		if apiURL == "" {
+			apiURL = "https://" + host
+		}
+		instances = append(instances, instance{host: host, apiURL: apiURL})
+	}
+	return instances, nil
+}
+
+// newHostClient creates the client for a Gitea host
+func newHostClient(host, apiURL, token string) (*hostClient, error) {
+	u, err := url.Parse(apiURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, fmt.Errorf("invalid API URL for %s: %q", host, apiURL)
+	}
+	apiURL = strings.TrimRight(apiURL, "/")
+	if !strings.HasSuffix(apiURL, "/api/v1") {
+		apiURL += "/api/v1"
+	}
+
+	return &hostClient{
+		host:       host,
+		apiURL:     apiURL,
+		token:      token,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// tokenVariable names the environment variable holding the token for host,
+// e.g. GITEA_TOKEN_CODE_EXAMPLE_COM
+func tokenVariable(host string) string {
+	return codehost.HostVariable("GITEA_TOKEN_", host)
+}
+
+// hostClientFor returns the client for host, or codehost.ErrUnknownHost
+func (s *Service) hostClientFor(host string) (*hostClient, error) {
+	h, ok := s.hosts[strings.ToLower(host)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", codehost.ErrUnknownHost, host)
+	}
+	return h, nil
+}
+
+// Hosts returns the configured hosts in alphabetical order
+func (s *Service) Hosts() []string {
+	hosts := make([]string, 0, len(s.hosts))
+	for host := range s.hosts {
+		hosts = append(hosts, host)
+	}
+	slices.Sort(hosts)
+	return hosts
+}
@@ -0,0 +1,107 @@
+package gitea
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+)
+
+var (
+	// namePattern matches Gitea user, organization and repository names
+	namePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+	// shorthandPattern matches "host/owner/repo#123"
+	shorthandPattern = regexp.MustCompile(`^([^/\s#]+)/([^/\s#]+)/([^/\s#]+)#(\d+)$`)
+)
+
+// ParsePRRef parses a reference to a pull request on a Gitea or Forgejo
+// instance. It accepts:
+//
+//   - URLs, with or without scheme, and with any sub-path, query or fragment:
+//     https://code.example.com/owner/repo/pulls/123/files
+//   - the shorthand host/owner/repo#123; there is no default host
+//
+// The host is not checked against the configured hosts; see codehost.Registry.
+func ParsePRRef(input string) (codehost.Ref, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return codehost.Ref{}, fmt.Errorf("pull request reference is empty")
+	}
+	if len(strings.Fields(input)) > 1 {
+		return codehost.Ref{}, fmt.Errorf("unrecognized pull request reference %q", input)
+	}
+
+	if match := shorthandPattern.FindStringSubmatch(input); match != nil {
+		return newPRRef(match[1], match[2], match[3], match[4])
+	}
+
+	return parsePRURL(input)
+}
+
+// parsePRURL parses a pull request URL, adding https:// when the scheme is missing
+func parsePRURL(input string) (codehost.Ref, error) {
+	if !strings.Contains(input, "://") {
+		input = "https://" + input
+	}
+
+	u, err := url.Parse(input)
+	if err != nil {
+		return codehost.Ref{}, fmt.Errorf("invalid pull request URL: %w", err)
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return codehost.Ref{}, fmt.Errorf("unsupported URL scheme %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return codehost.Ref{}, fmt.Errorf("pull request URL has no host")
+	}
+
+	// owner/repo/pulls/123, followed by anything (files, commits, ...)
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(segments) < 4 || (segments[2] != "pulls" && segments[2] != "pull") {
+		return codehost.Ref{}, fmt.Errorf("expected a pull request URL like https://%s/owner/repo/pulls/123", u.Host)
+	}
+	return newPRRef(u.Host, segments[0], segments[1], segments[3])
+}
+
+// newPRRef validates and normalizes the parts of a reference. Hosts are
+// lowercased and lose a leading "www.".
+func newPRRef(host, owner, repo, number string) (codehost.Ref, error) {
+	host = strings.TrimPrefix(strings.ToLower(host), "www.")
+	if host == "" {
+		return codehost.Ref{}, fmt.Errorf("pull request reference has no host")
+	}
+	if !namePattern.MatchString(owner) || owner == "." || owner == ".." {
+		return codehost.Ref{}, fmt.Errorf("invalid repository owner %q", owner)
+	}
+	repo = strings.TrimSuffix(repo, ".git")
+	if !namePattern.MatchString(repo) || repo == "." || repo == ".." {
+		return codehost.Ref{}, fmt.Errorf("invalid repository name %q", repo)
+	}
+
+	prNumber, err := strconv.Atoi(number)
+	if err != nil || prNumber <= 0 {
+		return codehost.Ref{}, fmt.Errorf("invalid PR number: %s", number)
+	}
+
+	return codehost.Ref{Host: host, Owner: owner, Repo: repo, Number: prNumber}, nil
+}
+
+// PullRequestURL returns the web address of a pull request on a Gitea or
+// Forgejo instance
+func PullRequestURL(ref codehost.Ref) string {
+	return fmt.Sprintf("https://%s/%s/%s/pulls/%d", ref.Host, ref.Owner, ref.Repo, ref.Number)
+}
+
+// ParseRef parses a reference to a pull request, see ParsePRRef
+func (s *Service) ParseRef(input string) (codehost.Ref, error) {
+	return ParsePRRef(input)
+}
+
+// URL returns the web address of the pull request
+func (s *Service) URL(ref codehost.Ref) string {
+	return PullRequestURL(ref)
+}
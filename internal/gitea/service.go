@@ -0,0 +1,367 @@
+package gitea
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+)
+
+// Service reads and writes pull requests on Gitea and Forgejo instances, which
+// share one API. It implements codehost.Provider.
+type Service struct {
+	hosts        map[string]*hostClient // keyed by lowercase host
+	maxListItems int
+}
+
+// user is a Gitea account as embedded in API responses
+type user struct {
+	Login    string `json:"login"`
+	FullName string `json:"full_name"`
+}
+
+// pullRequest holds the fields of a Gitea pull request we use
+type pullRequest struct {
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	State     string    `json:"state"`
+	Merged    bool      `json:"merged"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	User      *user     `json:"user"`
+	Assignees []*user   `json:"assignees"`
+	Labels    []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+	Head struct {
+		SHA string `json:"sha"`
+	} `json:"head"`
+	Base struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+	HTMLURL      string `json:"html_url"`
+	ChangedFiles *int   `json:"changed_files"` // missing before Gitea 1.17
+}
+
+// changedFile is one changed file of a pull request
+type changedFile struct {
+	Filename         string `json:"filename"`
+	PreviousFilename string `json:"previous_filename"`
+	Status           string `json:"status"`
+	Additions        int    `json:"additions"`
+	Deletions        int    `json:"deletions"`
+	Changes          int    `json:"changes"`
+}
+
+// commit is a commit of a pull request
+type commit struct {
+	SHA    string `json:"sha"`
+	Commit struct {
+		Message string `json:"message"`
+		Author  struct {
+			Name  string `json:"name"`
+			Email string `json:"email"`
+		} `json:"author"`
+	} `json:"commit"`
+	Author *user `json:"author"` // nil when the author email matches no account
+	Stats  *struct {
+		Additions int `json:"additions"`
+		Deletions int `json:"deletions"`
+	} `json:"stats"`
+}
+
+// review is a review of a pull request
+type review struct {
+	User        *user     `json:"user"`
+	State       string    `json:"state"`
+	SubmittedAt time.Time `json:"submitted_at"`
+}
+
+func NewService() *Service {
+	hosts := map[string]*hostClient{}
+
+	instances, err := instancesFromEnv()
+	if err != nil {
+		log.Printf("Warning: %v, Gitea hosts are disabled", err)
+	}
+	for _, instance := range instances {
+		h, err := newHostClient(instance.host, instance.apiURL, os.Getenv(tokenVariable(instance.host)))
+		if err != nil {
+			log.Printf("Warning: %v, skipping Gitea host %s", err, instance.host)
+			continue
+		}
+		if h.token == "" {
+			log.Printf("Warning: %s not provided, only public repositories on %s can be read", tokenVariable(h.host), h.host)
+		}
+		log.Printf("Gitea host %s enabled (API %s)", h.host, h.apiURL)
+		hosts[h.host] = h
+	}
+
+	return &Service{
+		hosts:        hosts,
+		maxListItems: codehost.MaxListItemsFromEnv(),
+	}
+}
+
+// Name returns the product name shown to users
+func (s *Service) Name() string {
+	return "Gitea"
+}
+
+// fetchPullRequest fetches the metadata of the pull request
+func (s *Service) fetchPullRequest(ctx context.Context, h *hostClient, ref codehost.Ref) (*pullRequest, error) {
+	var pr pullRequest
+	if _, err := h.do(ctx, http.MethodGet, pullPath(ref), nil, nil, &pr); err != nil {
+		return nil, fmt.Errorf("failed to fetch pull request %s: %w", ref, err)
+	}
+	return &pr, nil
+}
+
+// FetchHeadSHA returns the commit the pull request head currently points to.
+// It costs a single API call, which makes it a cheap freshness check.
+func (s *Service) FetchHeadSHA(ctx context.Context, ref codehost.Ref) (string, error) {
+	h, err := s.hostClientFor(ref.Host)
+	if err != nil {
+		return "", err
+	}
+
+	pr, err := s.fetchPullRequest(ctx, h, ref)
+	if err != nil {
+		return "", err
+	}
+	return pr.Head.SHA, nil
+}
+
+// FetchChangeRequest fetches the pull request with its labels, files, patch,
+// commits and reviews
+func (s *Service) FetchChangeRequest(ctx context.Context, ref codehost.Ref) (*codehost.ChangeRequest, error) {
+	h, err := s.hostClientFor(ref.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, err := s.fetchPullRequest(ctx, h, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	var truncatedLists []string
+	markTruncated := func(list string, truncated bool, err error) {
+		if err != nil {
+			log.Printf("Error fetching %s: %v", list, err)
+		}
+		if truncated || err != nil {
+			truncatedLists = append(truncatedLists, list)
+		}
+	}
+
+	// Fetch additional data, following pagination up to the configured limit
+	files, truncated, err := s.fetchFiles(ctx, h, ref)
+	totalFiles := len(files)
+	if pr.ChangedFiles != nil {
+		totalFiles = *pr.ChangedFiles
+	}
+	markTruncated("files", truncated || len(files) < totalFiles, err)
+
+	prCommits, truncated, err := listAll[commit](ctx, h, pullPath(ref)+"/commits", s.maxListItems)
+	markTruncated("commits", truncated, err)
+
+	prReviews, truncated, err := listAll[review](ctx, h, pullPath(ref)+"/reviews", s.maxListItems)
+	markTruncated("reviews", truncated, err)
+
+	additions, deletions := 0, 0
+	for _, file := range files {
+		additions += file.Additions
+		deletions += file.Deletions
+	}
+
+	// Derive the contributors from the commit authors and Co-authored-by trailers
+	commits := convertCommits(prCommits)
+	contributors := codehost.BuildContributors(commits)
+	if len(contributors) == 0 && pr.User != nil {
+		contributors = append(contributors, &codehost.Contributor{Login: pr.User.Login, Name: pr.User.FullName})
+	}
+
+	labels := make([]string, 0, len(pr.Labels))
+	for _, label := range pr.Labels {
+		labels = append(labels, label.Name)
+	}
+
+	state := pr.State
+	if pr.Merged {
+		state = "merged"
+	}
+
+	// Descriptions follow the repository's template as of the base branch.
+	// Without it generation falls back to the built-in sections, so a failure
+	// is logged rather than returned.
+	template, err := fetchTemplate(ctx, h, ref, pr.Base.Ref)
+	if err != nil {
+		log.Printf("Error fetching pull request template of %s: %v", ref.Repository(), err)
+	}
+
+	webURL := pr.HTMLURL
+	if webURL == "" {
+		webURL = PullRequestURL(ref)
+	}
+
+	return &codehost.ChangeRequest{
+		Title:             pr.Title,
+		Body:              pr.Body,
+		User:              convertUser(pr.User),
+		Assignees:         convertUsers(pr.Assignees),
+		Labels:            labels,
+		State:             state,
+		CreatedAt:         pr.CreatedAt,
+		UpdatedAt:         pr.UpdatedAt,
+		ChangedFiles:      files,
+		TotalChangedFiles: totalFiles,
+		Commits:           commits,
+		Reviews:           convertReviews(prReviews),
+		Additions:         additions,
+		Deletions:         deletions,
+		Host:              ref.Host,
+		Repository:        ref.Repository(),
+		PRNumber:          ref.Number,
+		URL:               webURL,
+		HeadSHA:           pr.Head.SHA,
+		Contributors:      contributors,
+		Template:          template,
+		Truncated:         len(truncatedLists) > 0,
+		TruncatedLists:    truncatedLists,
+	}, nil
+}
+
+// fetchFiles lists the changed files of the pull request with their patches.
+// The files endpoint has the authoritative list and line counts but no
+// patches, so those come from the pull request's diff. Instances older than
+// Gitea 1.17 lack the files endpoint, and the diff alone is used there.
+func (s *Service) fetchFiles(ctx context.Context, h *hostClient, ref codehost.Ref) ([]*codehost.File, bool, error) {
+	diff, diffTruncated, diffErr := h.getText(ctx, pullPath(ref)+".diff", maxDiffBytes)
+	if diffErr != nil {
+		log.Printf("Error fetching diff of %s: %v", ref, diffErr)
+	}
+	patched := codehost.ParseGitDiff(diff)
+	if diffTruncated && len(patched) > 0 {
+		// The last file was cut off mid-hunk
+		patched[len(patched)-1].Patch = ""
+	}
+
+	listed, truncated, err := listAll[changedFile](ctx, h, pullPath(ref)+"/files", s.maxListItems)
+	if errors.Is(err, codehost.ErrNotFound) {
+		if diffErr != nil {
+			return nil, false, diffErr
+		}
+		if len(patched) > s.maxListItems {
+			return patched[:s.maxListItems], true, nil
+		}
+		return patched, diffTruncated, nil
+	}
+
+	patches := make(map[string]string, len(patched))
+	for _, file := range patched {
+		patches[file.Filename] = file.Patch
+	}
+
+	files := make([]*codehost.File, 0, len(listed))
+	for _, f := range listed {
+		file := &codehost.File{
+			Filename:  f.Filename,
+			Status:    convertStatus(f.Status),
+			Additions: f.Additions,
+			Deletions: f.Deletions,
+			Changes:   f.Changes,
+			Patch:     patches[f.Filename],
+		}
+		if file.Status == "renamed" {
+			file.PreviousFilename = f.PreviousFilename
+		}
+		files = append(files, file)
+	}
+	return files, truncated, err
+}
+
+// convertStatus maps a Gitea file status to the codehost vocabulary
+func convertStatus(status string) string {
+	switch status {
+	case "added", "removed", "renamed":
+		return status
+	case "deleted":
+		return "removed"
+	default:
+		// "changed", "modified" and "copied"
+		return "modified"
+	}
+}
+
+// convertUser maps a Gitea user to a codehost.User
+func convertUser(u *user) *codehost.User {
+	if u == nil {
+		return nil
+	}
+	return &codehost.User{Login: u.Login, Name: u.FullName}
+}
+
+// convertUsers maps Gitea users to codehost.User values
+func convertUsers(users []*user) []*codehost.User {
+	converted := make([]*codehost.User, 0, len(users))
+	for _, u := range users {
+		converted = append(converted, convertUser(u))
+	}
+	return converted
+}
+
+// convertCommits maps the pull request commits, which Gitea lists oldest
+// first, to codehost.Commit values
+func convertCommits(prCommits []commit) []codehost.Commit {
+	commits := make([]codehost.Commit, 0, len(prCommits))
+	for _, c := range prCommits {
+		converted := codehost.Commit{
+			SHA:         c.SHA,
+			Message:     c.Commit.Message,
+			AuthorName:  c.Commit.Author.Name,
+			AuthorEmail: strings.ToLower(c.Commit.Author.Email),
+		}
+		if c.Author != nil {
+			converted.AuthorLogin = c.Author.Login
+		}
+		if c.Stats != nil {
+			converted.Additions = c.Stats.Additions
+			converted.Deletions = c.Stats.Deletions
+			converted.HasStats = true
+		}
+		converted.CoAuthors = codehost.ParseCoAuthors(converted.Message)
+		commits = append(commits, converted)
+	}
+	return commits
+}
+
+// convertReviews maps the submitted pull request reviews to codehost.Review
+// values, using GitHub's names for the review states
+func convertReviews(prReviews []review) []*codehost.Review {
+	var reviews []*codehost.Review
+	for _, r := range prReviews {
+		state := r.State
+		switch state {
+		case "PENDING":
+			continue
+		case "REQUEST_CHANGES":
+			state = "CHANGES_REQUESTED"
+		case "COMMENT":
+			state = "COMMENTED"
+		}
+
+		converted := &codehost.Review{State: state, SubmittedAt: r.SubmittedAt}
+		if r.User != nil {
+			converted.User = r.User.Login
+		}
+		reviews = append(reviews, converted)
+	}
+	return reviews
+}
@@ -0,0 +1,267 @@
+package gitea_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+	"github.com/nahue/pr-toolbox-go/internal/gitea"
+)
+
+const testDiff = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,4 @@
+ package main
++import "fmt"
+-func main() {}
++func main() { fmt.Println("hi") }
+diff --git a/docs/old.md b/docs/new.md
+similarity index 90%
+rename from docs/old.md
+rename to docs/new.md
+--- a/docs/old.md
++++ b/docs/new.md
+@@ -1 +1 @@
+-Old title
++New title
+`
+
+// newTestServer stands in for a Gitea instance serving pull request team/service#7
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	updatedAt := time.Date(2025, 9, 1, 12, 0, 0, 0, time.UTC)
+	body := "Original description"
+	writeJSON := func(w http.ResponseWriter, v any) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(v)
+	}
+	pullRequest := func() map[string]any {
+		return map[string]any{
+			"title":         "Add greeting",
+			"body":          body,
+			"state":         "open",
+			"merged":        false,
+			"created_at":    updatedAt.Add(-time.Hour),
+			"updated_at":    updatedAt,
+			"user":          map[string]any{"login": "alice", "full_name": "Alice Doe"},
+			"assignees":     []any{map[string]any{"login": "bob"}},
+			"labels":        []any{map[string]any{"name": "enhancement"}, map[string]any{"name": "docs"}},
+			"head":          map[string]any{"sha": "abc123"},
+			"base":          map[string]any{"ref": "main"},
+			"html_url":      "https://code.example.com/team/service/pulls/7",
+			"changed_files": 2,
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/repos/team/service/pulls/7", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, pullRequest())
+	})
+	mux.HandleFunc("PATCH /api/v1/repos/team/service/pulls/7", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token secret" {
+			http.Error(w, `{"message":"token is required"}`, http.StatusUnauthorized)
+			return
+		}
+		var update struct {
+			Body string `json:"body"`
+		}
+		json.NewDecoder(r.Body).Decode(&update)
+		body = update.Body
+		updatedAt = updatedAt.Add(time.Minute)
+		writeJSON(w, pullRequest())
+	})
+	mux.HandleFunc("GET /api/v1/repos/team/service/pulls/7.diff", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testDiff))
+	})
+	mux.HandleFunc("GET /api/v1/repos/team/service/pulls/7/files", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Total-Count", "2")
+		writeJSON(w, []any{
+			map[string]any{"filename": "main.go", "status": "changed", "additions": 2, "deletions": 1, "changes": 3},
+			map[string]any{"filename": "docs/new.md", "previous_filename": "docs/old.md", "status": "renamed", "additions": 1, "deletions": 1, "changes": 2},
+		})
+	})
+	mux.HandleFunc("GET /api/v1/repos/team/service/pulls/7/commits", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Total-Count", "1")
+		writeJSON(w, []any{map[string]any{
+			"sha": "abc123",
+			"commit": map[string]any{
+				"message": "Add greeting\n\nCo-authored-by: Carol <carol@example.com>",
+				"author":  map[string]any{"name": "Alice Doe", "email": "Alice@example.com"},
+			},
+			"author": map[string]any{"login": "alice"},
+			"stats":  map[string]any{"additions": 3, "deletions": 2},
+		}})
+	})
+	mux.HandleFunc("GET /api/v1/repos/team/service/pulls/7/reviews", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, []any{
+			map[string]any{"user": map[string]any{"login": "bob"}, "state": "REQUEST_CHANGES"},
+			map[string]any{"user": map[string]any{"login": "dave"}, "state": "PENDING"},
+		})
+	})
+
+	// The template in .gitea takes precedence over the one in .github
+	contents := map[string][]any{
+		"":        {map[string]any{"name": "README.md", "path": "README.md", "type": "file"}, map[string]any{"name": ".gitea", "path": ".gitea", "type": "dir"}},
+		".gitea":  {map[string]any{"name": "pull_request_template.md", "path": ".gitea/pull_request_template.md", "type": "file"}},
+		".github": {map[string]any{"name": "PULL_REQUEST_TEMPLATE.md", "path": ".github/PULL_REQUEST_TEMPLATE.md", "type": "file"}},
+	}
+	listContents := func(w http.ResponseWriter, r *http.Request) {
+		entries, ok := contents[r.PathValue("path")]
+		if !ok || r.URL.Query().Get("ref") != "main" {
+			http.Error(w, `{"message":"GetContentsOrList"}`, http.StatusNotFound)
+			return
+		}
+		writeJSON(w, entries)
+	}
+	mux.HandleFunc("GET /api/v1/repos/team/service/contents", listContents)
+	mux.HandleFunc("GET /api/v1/repos/team/service/contents/{path...}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("path") != ".gitea/pull_request_template.md" {
+			listContents(w, r)
+			return
+		}
+		content := base64.StdEncoding.EncodeToString([]byte("## Summary\r\n\r\n## Testing\r\n"))
+		writeJSON(w, map[string]any{"name": "pull_request_template.md", "type": "file", "encoding": "base64", "content": content})
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestService(t *testing.T, token string) *gitea.Service {
+	server := newTestServer(t)
+	t.Setenv("GITEA_HOSTS", "code.example.com="+server.URL)
+	t.Setenv("GITEA_TOKEN_CODE_EXAMPLE_COM", token)
+	return gitea.NewService()
+}
+
+var testRef = codehost.Ref{Host: "code.example.com", Owner: "team", Repo: "service", Number: 7}
+
+func TestFetchChangeRequest(t *testing.T) {
+	service := newTestService(t, "")
+
+	pr, err := service.FetchChangeRequest(context.Background(), testRef)
+	if err != nil {
+		t.Fatalf("FetchChangeRequest returned error: %v", err)
+	}
+
+	if pr.Title != "Add greeting" || pr.Body != "Original description" || pr.HeadSHA != "abc123" {
+		t.Errorf("unexpected metadata: %q, %q, %q", pr.Title, pr.Body, pr.HeadSHA)
+	}
+	if pr.User == nil || pr.User.Login != "alice" || pr.User.Name != "Alice Doe" {
+		t.Errorf("unexpected author: %+v", pr.User)
+	}
+	if got := codehost.GetLabelsString(pr.Labels); got != "enhancement, docs" {
+		t.Errorf("labels = %q", got)
+	}
+	if pr.Truncated {
+		t.Errorf("unexpected truncated lists: %v", pr.TruncatedLists)
+	}
+
+	if len(pr.ChangedFiles) != 2 || pr.TotalChangedFiles != 2 {
+		t.Fatalf("got %d of %d files, want 2 of 2", len(pr.ChangedFiles), pr.TotalChangedFiles)
+	}
+	main, docs := pr.ChangedFiles[0], pr.ChangedFiles[1]
+	if main.Status != "modified" || main.Patch == "" || main.Additions != 2 || main.Deletions != 1 {
+		t.Errorf("unexpected main.go: %+v", main)
+	}
+	if docs.Status != "renamed" || docs.PreviousFilename != "docs/old.md" || docs.Patch != "@@ -1 +1 @@\n-Old title\n+New title" {
+		t.Errorf("unexpected docs/new.md: %+v", docs)
+	}
+	if pr.Additions != 3 || pr.Deletions != 2 {
+		t.Errorf("line counts = +%d -%d, want +3 -2", pr.Additions, pr.Deletions)
+	}
+
+	if len(pr.Commits) != 1 {
+		t.Fatalf("got %d commits, want 1", len(pr.Commits))
+	}
+	commit := pr.Commits[0]
+	if commit.AuthorLogin != "alice" || commit.AuthorEmail != "alice@example.com" || !commit.HasStats || len(commit.CoAuthors) != 1 {
+		t.Errorf("unexpected commit: %+v", commit)
+	}
+	if len(pr.Contributors) != 2 {
+		t.Errorf("got %d contributors, want 2", len(pr.Contributors))
+	}
+
+	if len(pr.Reviews) != 1 || pr.Reviews[0].User != "bob" || pr.Reviews[0].State != "CHANGES_REQUESTED" {
+		t.Errorf("unexpected reviews: %+v", pr.Reviews)
+	}
+	if pr.Template != "## Summary\n\n## Testing" {
+		t.Errorf("template = %q", pr.Template)
+	}
+}
+
+func TestUpdateBody(t *testing.T) {
+	service := newTestService(t, "secret")
+	ctx := context.Background()
+
+	current, err := service.FetchBody(ctx, testRef)
+	if err != nil {
+		t.Fatalf("FetchBody returned error: %v", err)
+	}
+
+	updated, err := service.UpdateBody(ctx, testRef, "New description", current)
+	if err != nil {
+		t.Fatalf("UpdateBody returned error: %v", err)
+	}
+	if updated.Body != "New description" {
+		t.Errorf("body = %q, want the new description", updated.Body)
+	}
+
+	// The first write moved updated_at, so a second write based on the old
+	// timestamp must not overwrite it
+	if _, err := service.UpdateBody(ctx, testRef, "Stale description", current); !errors.Is(err, codehost.ErrConflict) {
+		t.Errorf("stale UpdateBody error = %v, want ErrConflict", err)
+	}
+}
+
+func TestUpdateBodyWithoutToken(t *testing.T) {
+	service := newTestService(t, "")
+
+	if _, err := service.UpdateBody(context.Background(), testRef, "New description", nil); !errors.Is(err, codehost.ErrReadOnly) {
+		t.Errorf("UpdateBody error = %v, want ErrReadOnly", err)
+	}
+}
+
+func TestParsePRRef(t *testing.T) {
+	tests := []struct {
+		input string
+		want  codehost.Ref
+	}{
+		{"https://code.example.com/team/service/pulls/7", testRef},
+		{"https://code.example.com/team/service/pulls/7/files?style=split", testRef},
+		{"code.example.com/team/service/pulls/7", testRef},
+		{"code.example.com/team/service#7", testRef},
+		{"https://Code.Example.com/team/service.git/pulls/7", testRef},
+	}
+	for _, tt := range tests {
+		got, err := gitea.ParsePRRef(tt.input)
+		if err != nil {
+			t.Errorf("ParsePRRef(%q) returned error: %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParsePRRef(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+
+	for _, input := range []string{
+		"",
+		"team/service#7",
+		"https://code.example.com/team/service/issues/7",
+		"https://code.example.com/team/service/pulls/0",
+	} {
+		if got, err := gitea.ParsePRRef(input); err == nil {
+			t.Errorf("ParsePRRef(%q) = %+v, want an error", input, got)
+		}
+	}
+}
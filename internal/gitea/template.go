@@ -0,0 +1,92 @@
+package gitea
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+)
+
+// templateLocations are the directories Gitea looks for a pull request
+// template in, in order of precedence: the repository root, .gitea and .github
+var templateLocations = []string{"", ".gitea", ".github"}
+
+// templateNames are the file names Gitea accepts for a Markdown pull request
+// template. Unlike GitHub, Gitea matches them case-sensitively.
+var templateNames = []string{"PULL_REQUEST_TEMPLATE.md", "pull_request_template.md"}
+
+// contentEntry is a file or directory as returned by the contents API. For
+// files fetched by path, Content holds their text in the given encoding.
+type contentEntry struct {
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	Type     string `json:"type"` // "file", "dir", "symlink" or "submodule"
+	Content  string `json:"content"`
+	Encoding string `json:"encoding"`
+}
+
+// contentsPath returns the API path of a file or directory of the repository
+func contentsPath(ref codehost.Ref, filePath string) string {
+	apiPath := fmt.Sprintf("/repos/%s/%s/contents", url.PathEscape(ref.Owner), url.PathEscape(ref.Repo))
+	for _, segment := range strings.Split(filePath, "/") {
+		if segment != "" {
+			apiPath += "/" + url.PathEscape(segment)
+		}
+	}
+	return apiPath
+}
+
+// findTemplate looks through the entries of one template location for a
+// template file
+func findTemplate(entries []contentEntry) string {
+	for _, name := range templateNames {
+		for _, entry := range entries {
+			if entry.Type == "file" && entry.Name == name {
+				return entry.Path
+			}
+		}
+	}
+	return ""
+}
+
+// fetchTemplate returns the pull request template of the repository at branch
+// (the base branch of the pull request), or "" when it has none
+func fetchTemplate(ctx context.Context, h *hostClient, ref codehost.Ref, branch string) (string, error) {
+	query := url.Values{"ref": {branch}}
+
+	var file string
+	for _, location := range templateLocations {
+		var entries []contentEntry
+		if _, err := h.do(ctx, http.MethodGet, contentsPath(ref, location), query, nil, &entries); err != nil {
+			if errors.Is(err, codehost.ErrNotFound) {
+				continue
+			}
+			return "", fmt.Errorf("failed to list %q: %w", location, err)
+		}
+		if file = findTemplate(entries); file != "" {
+			break
+		}
+	}
+	if file == "" {
+		return "", nil
+	}
+
+	var content contentEntry
+	if _, err := h.do(ctx, http.MethodGet, contentsPath(ref, file), query, nil, &content); err != nil {
+		return "", fmt.Errorf("failed to fetch %s: %w", file, err)
+	}
+	template := content.Content
+	if content.Encoding == "base64" {
+		data, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(content.Content, "\n", ""))
+		if err != nil {
+			return "", fmt.Errorf("failed to decode %s: %w", file, err)
+		}
+		template = string(data)
+	}
+	return strings.TrimSpace(strings.ReplaceAll(template, "\r\n", "\n")), nil
+}
@@ -0,0 +1,88 @@
+package gitea
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+)
+
+// CheckWrite returns codehost.ErrReadOnly when no token is configured for
+// host. The token is the same for every owner.
+func (s *Service) CheckWrite(ctx context.Context, host, owner string) error {
+	h, err := s.hostClientFor(host)
+	if err != nil {
+		return err
+	}
+	if h.token == "" {
+		return codehost.ErrReadOnly
+	}
+	return nil
+}
+
+// FetchBody returns the current description of the pull request
+func (s *Service) FetchBody(ctx context.Context, ref codehost.Ref) (*codehost.Body, error) {
+	h, err := s.hostClientFor(ref.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, err := s.fetchPullRequest(ctx, h, ref)
+	if err != nil {
+		return nil, err
+	}
+	return &codehost.Body{Body: pr.Body, UpdatedAt: pr.UpdatedAt}, nil
+}
+
+// UpdateBody replaces the description of the pull request. When current is
+// set and the pull request no longer matches it, nothing is written and
+// codehost.ErrConflict is returned; see codehost.Provider. It returns
+// codehost.ErrReadOnly when no token is configured for the host.
+func (s *Service) UpdateBody(ctx context.Context, ref codehost.Ref, body string, current *codehost.Body) (*codehost.Body, error) {
+	if err := s.CheckWrite(ctx, ref.Host, ref.Owner); err != nil {
+		return nil, err
+	}
+	h, err := s.hostClientFor(ref.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	if current != nil {
+		latest, err := s.FetchBody(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+		if err := current.CheckUnchanged(ref, latest); err != nil {
+			return nil, err
+		}
+	}
+
+	var pr pullRequest
+	update := map[string]string{"body": body}
+	if _, err := h.do(ctx, http.MethodPatch, pullPath(ref), nil, update, &pr); err != nil {
+		return nil, fmt.Errorf("failed to update pull request %s: %w", ref, err)
+	}
+	return &codehost.Body{Body: pr.Body, UpdatedAt: pr.UpdatedAt}, nil
+}
+
+// CreateComment posts a comment on the pull request, which Gitea stores as an
+// issue comment. It returns codehost.ErrReadOnly when no token is configured
+// for the host.
+func (s *Service) CreateComment(ctx context.Context, ref codehost.Ref, body string) error {
+	if err := s.CheckWrite(ctx, ref.Host, ref.Owner); err != nil {
+		return err
+	}
+	h, err := s.hostClientFor(ref.Host)
+	if err != nil {
+		return err
+	}
+
+	comment := map[string]string{"body": body}
+	path := fmt.Sprintf("/repos/%s/%s/issues/%d/comments", url.PathEscape(ref.Owner), url.PathEscape(ref.Repo), ref.Number)
+	if _, err := h.do(ctx, http.MethodPost, path, nil, comment, nil); err != nil {
+		return fmt.Errorf("failed to comment on pull request %s: %w", ref, err)
+	}
+	return nil
+}
@@ -0,0 +1,271 @@
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v62/github"
+)
+
+const (
+	// appJWTLifetime stays below the 10 minute maximum GitHub accepts
+	appJWTLifetime = 9 * time.Minute
+
+	// tokenExpiryMargin renews installation tokens shortly before they expire
+	tokenExpiryMargin = time.Minute
+
+	// installationCacheTTL bounds how long the installation for an owner (or
+	// the absence of one) is remembered
+	installationCacheTTL = time.Hour
+)
+
+// errNoInstallation means the GitHub App is not installed for the owner
+var errNoInstallation = errors.New("GitHub App is not installed for this owner")
+
+// appAuth authenticates as a GitHub App: it signs JWTs with the app's private
+// key, finds the installation for each repository owner and mints installation
+// access tokens, caching both until they expire
+type appAuth struct {
+	appID int64
+	key   *rsa.PrivateKey
+	base  *github.Client // unauthenticated client the app and installation clients derive from
+
+	mu            sync.Mutex
+	installations map[string]cachedInstallation // keyed by lowercase owner
+	tokens        map[int64]cachedToken         // keyed by installation ID
+}
+
+type cachedInstallation struct {
+	id        int64 // 0 when the app is not installed for the owner
+	checkedAt time.Time
+}
+
+type cachedToken struct {
+	token     string
+	expiresAt time.Time
+	canWrite  bool // the installation may write to pull requests
+}
+
+type installationKey struct{}
+
+// WithInstallation returns a context under which the service authenticates to
+// github.com as the GitHub App installation with the given ID, such as the one
+// a webhook delivery came from, instead of looking up the installation on the
+// repository owner's account. An ID of 0 leaves ctx unchanged.
+func WithInstallation(ctx context.Context, installationID int64) context.Context {
+	if installationID == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, installationKey{}, installationID)
+}
+
+// installationFromContext returns the installation ID set by WithInstallation, if any
+func installationFromContext(ctx context.Context) (int64, bool) {
+	installationID, ok := ctx.Value(installationKey{}).(int64)
+	return installationID, ok
+}
+
+// newAppAuthFromEnv reads GITHUB_APP_ID and the private key from
+// GITHUB_APP_PRIVATE_KEY (PEM contents) or GITHUB_APP_PRIVATE_KEY_PATH.
+// It returns nil when no app is configured.
+func newAppAuthFromEnv(base *github.Client) (*appAuth, error) {
+	appIDStr := os.Getenv("GITHUB_APP_ID")
+	if appIDStr == "" {
+		return nil, nil
+	}
+
+	appID, err := strconv.ParseInt(appIDStr, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GITHUB_APP_ID %q: %w", appIDStr, err)
+	}
+
+	keyPEM := []byte(os.Getenv("GITHUB_APP_PRIVATE_KEY"))
+	if len(keyPEM) == 0 {
+		path := os.Getenv("GITHUB_APP_PRIVATE_KEY_PATH")
+		if path == "" {
+			return nil, fmt.Errorf("GITHUB_APP_ID is set but neither GITHUB_APP_PRIVATE_KEY nor GITHUB_APP_PRIVATE_KEY_PATH is")
+		}
+		if keyPEM, err = os.ReadFile(path); err != nil {
+			return nil, fmt.Errorf("failed to read GitHub App private key: %w", err)
+		}
+	}
+
+	key, err := parsePrivateKey(keyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	return &appAuth{
+		appID:         appID,
+		key:           key,
+		base:          base,
+		installations: make(map[string]cachedInstallation),
+		tokens:        make(map[int64]cachedToken),
+	}, nil
+}
+
+// parsePrivateKey decodes a PEM encoded RSA key in PKCS#1 (as downloaded from
+// GitHub) or PKCS#8 form. Escaped newlines are accepted so the key fits in an
+// environment variable.
+func parsePrivateKey(keyPEM []byte) (*rsa.PrivateKey, error) {
+	keyPEM = []byte(strings.ReplaceAll(string(keyPEM), `\n`, "\n"))
+
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("GitHub App private key is not PEM encoded")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub App private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("GitHub App private key is not an RSA key")
+	}
+	return key, nil
+}
+
+// jwt returns a JWT identifying the app, signed with RS256
+func (a *appAuth) jwt(now time.Time) (string, error) {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]any{
+		"iat": now.Add(-time.Minute).Unix(), // allow for clock drift
+		"exp": now.Add(appJWTLifetime).Unix(),
+		"iss": strconv.FormatInt(a.appID, 10),
+	})
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, a.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign GitHub App JWT: %w", err)
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// appClient returns a client authenticated as the app itself
+func (a *appAuth) appClient() (*github.Client, error) {
+	token, err := a.jwt(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return a.base.WithAuthToken(token), nil
+}
+
+// installationToken returns an access token for the app's installation on the
+// owner's account, or errNoInstallation when the app is not installed there.
+// An installation set with WithInstallation is used without a lookup.
+func (a *appAuth) installationToken(ctx context.Context, owner string) (cachedToken, error) {
+	if installationID, ok := installationFromContext(ctx); ok {
+		return a.installationTokenByID(ctx, installationID)
+	}
+
+	installationID, err := a.installationID(ctx, owner)
+	if err != nil {
+		return cachedToken{}, err
+	}
+
+	token, err := a.installationTokenByID(ctx, installationID)
+	if err != nil {
+		// The installation may have been removed; look it up again next time
+		a.mu.Lock()
+		delete(a.installations, strings.ToLower(owner))
+		a.mu.Unlock()
+		return cachedToken{}, err
+	}
+	return token, nil
+}
+
+// installationTokenByID returns an access token for the installation, and
+// whether the permissions granted to the installation let it write to pull
+// requests
+func (a *appAuth) installationTokenByID(ctx context.Context, installationID int64) (cachedToken, error) {
+	a.mu.Lock()
+	cached, ok := a.tokens[installationID]
+	a.mu.Unlock()
+	if ok && time.Now().Add(tokenExpiryMargin).Before(cached.expiresAt) {
+		return cached, nil
+	}
+
+	client, err := a.appClient()
+	if err != nil {
+		return cachedToken{}, err
+	}
+	token, _, err := client.Apps.CreateInstallationToken(ctx, installationID, nil)
+	if err != nil {
+		return cachedToken{}, fmt.Errorf("failed to create token for installation %d: %w", installationID, classifyError(DefaultHost, err))
+	}
+
+	cached = cachedToken{
+		token:     token.GetToken(),
+		expiresAt: token.GetExpiresAt().Time,
+		canWrite:  token.GetPermissions().GetPullRequests() == "write",
+	}
+	a.mu.Lock()
+	a.tokens[installationID] = cached
+	a.mu.Unlock()
+	return cached, nil
+}
+
+// installationID finds the app installation for an organization or user
+func (a *appAuth) installationID(ctx context.Context, owner string) (int64, error) {
+	key := strings.ToLower(owner)
+
+	a.mu.Lock()
+	cached, ok := a.installations[key]
+	a.mu.Unlock()
+	if ok && time.Since(cached.checkedAt) < installationCacheTTL {
+		if cached.id == 0 {
+			return 0, errNoInstallation
+		}
+		return cached.id, nil
+	}
+
+	client, err := a.appClient()
+	if err != nil {
+		return 0, err
+	}
+
+	installation, _, err := client.Apps.FindOrganizationInstallation(ctx, owner)
+	if isNotFound(err) {
+		installation, _, err = client.Apps.FindUserInstallation(ctx, owner)
+	}
+	if err != nil && !isNotFound(err) {
+		return 0, fmt.Errorf("failed to find GitHub App installation for %s: %w", owner, classifyError(DefaultHost, err))
+	}
+
+	a.mu.Lock()
+	a.installations[key] = cachedInstallation{id: installation.GetID(), checkedAt: time.Now()}
+	a.mu.Unlock()
+
+	if installation.GetID() == 0 {
+		return 0, errNoInstallation
+	}
+	return installation.GetID(), nil
+}
+
+// isNotFound reports whether a go-github error is a 404 response
+func isNotFound(err error) bool {
+	var responseErr *github.ErrorResponse
+	return errors.As(err, &responseErr) && responseErr.Response != nil && responseErr.Response.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,158 @@
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v62/github"
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+)
+
+func TestParsePrivateKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs1 := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	pkcs8Bytes, _ := x509.MarshalPKCS8PrivateKey(key)
+	pkcs8 := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8Bytes})
+
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ecBytes, _ := x509.MarshalPKCS8PrivateKey(ecKey)
+	ec := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: ecBytes})
+
+	tests := []struct {
+		name    string
+		keyPEM  string
+		wantErr bool
+	}{
+		{"PKCS#1", string(pkcs1), false},
+		{"PKCS#8", string(pkcs8), false},
+		{"escaped newlines", strings.ReplaceAll(string(pkcs1), "\n", `\n`), false},
+		{"not PEM", "not a key", true},
+		{"not a key", string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("garbage")})), true},
+		{"not RSA", string(ec), true},
+	}
+
+	for _, tt := range tests {
+		parsed, err := parsePrivateKey([]byte(tt.keyPEM))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: parsePrivateKey() error = %v, want error %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err == nil && !parsed.Equal(key) {
+			t.Errorf("%s: parsePrivateKey() returned another key", tt.name)
+		}
+	}
+}
+
+func TestAppJWT(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := &appAuth{appID: 12345, key: key}
+	now := time.Unix(1700000000, 0)
+
+	token, err := app.jwt(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("jwt() = %q, want three dot-separated parts", token)
+	}
+
+	var header map[string]string
+	var claims map[string]any
+	for i, target := range []any{&header, &claims} {
+		decoded, err := base64.RawURLEncoding.DecodeString(parts[i])
+		if err != nil {
+			t.Fatalf("part %d is not base64url: %v", i, err)
+		}
+		if err := json.Unmarshal(decoded, target); err != nil {
+			t.Fatalf("part %d is not JSON: %v", i, err)
+		}
+	}
+
+	if header["alg"] != "RS256" || header["typ"] != "JWT" {
+		t.Errorf("header = %v, want RS256 JWT", header)
+	}
+	if claims["iss"] != "12345" || claims["iat"] != float64(now.Unix()-60) || claims["exp"] != float64(now.Add(appJWTLifetime).Unix()) {
+		t.Errorf("claims = %v, want iss 12345, iat a minute back and exp %s ahead", claims, appJWTLifetime)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+		t.Errorf("signature does not verify: %v", err)
+	}
+}
+
+func TestCheckWriteWithAppInstallations(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		expiresAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+		switch r.URL.Path {
+		case "/orgs/acme/installation":
+			fmt.Fprint(w, `{"id": 1}`)
+		case "/orgs/writers/installation":
+			fmt.Fprint(w, `{"id": 2}`)
+		case "/app/installations/1/access_tokens":
+			fmt.Fprintf(w, `{"token": "read-token", "expires_at": %q, "permissions": {"pull_requests": "read"}}`, expiresAt)
+		case "/app/installations/2/access_tokens":
+			fmt.Fprintf(w, `{"token": "write-token", "expires_at": %q, "permissions": {"pull_requests": "write"}}`, expiresAt)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := github.NewClient(nil)
+	base.BaseURL, _ = url.Parse(server.URL + "/")
+	app := &appAuth{appID: 12345, key: key, base: base, installations: map[string]cachedInstallation{}, tokens: map[int64]cachedToken{}}
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		owner    string
+		canWrite bool // whether a GITHUB_TOKEN is configured
+		want     error
+	}{
+		{"read-only installation", context.Background(), "acme", true, codehost.ErrReadOnly},
+		{"installation with write access", context.Background(), "writers", false, nil},
+		{"no installation, token", context.Background(), "solo", true, nil},
+		{"no installation, no token", context.Background(), "solo", false, codehost.ErrReadOnly},
+		{"installation from a webhook", WithInstallation(context.Background(), 2), "acme", false, nil},
+	}
+
+	for _, tt := range tests {
+		s := &Service{hosts: map[string]*hostClient{DefaultHost: {host: DefaultHost, base: base, client: base, app: app, canWrite: tt.canWrite}}}
+		if err := s.CheckWrite(tt.ctx, DefaultHost, tt.owner); !errors.Is(err, tt.want) {
+			t.Errorf("%s: CheckWrite() = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package github
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/v62/github"
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+)
+
+const (
+	// maxCommitStats caps how many commits get their line counts fetched
+	// individually per pull request, each costing an API call. Counts already
+	// cached do not count towards it.
+	maxCommitStats = 30
+
+	// commitStatsConcurrency limits parallel requests for commit line counts
+	commitStatsConcurrency = 8
+
+	// maxCachedCommitStats bounds how many commits' line counts are remembered
+	maxCachedCommitStats = 10000
+)
+
+// commitStatsCache remembers the line counts of commits, which never change
+// for a SHA, so describing a pull request again only fetches those of its new
+// commits
+type commitStatsCache struct {
+	mu    sync.Mutex
+	stats map[string]commitStats // keyed by host, repository and SHA
+}
+
+type commitStats struct {
+	additions int
+	deletions int
+}
+
+// commitStatsKey keys a commit's line counts by the repository it was read from
+func commitStatsKey(host, owner, repo, sha string) string {
+	return strings.ToLower(host + "/" + owner + "/" + repo + "@" + sha)
+}
+
+func (c *commitStatsCache) get(key string) (commitStats, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats, ok := c.stats[key]
+	return stats, ok
+}
+
+// put stores a commit's line counts, dropping an arbitrary entry when the
+// cache is full
+func (c *commitStatsCache) put(key string, stats commitStats) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stats == nil {
+		c.stats = make(map[string]commitStats)
+	}
+	if len(c.stats) >= maxCachedCommitStats {
+		for evicted := range c.stats {
+			delete(c.stats, evicted)
+			break
+		}
+	}
+	c.stats[key] = stats
+}
+
+// convertCommits maps the GitHub API commits to codehost.Commit values
+func convertCommits(repoCommits []*github.RepositoryCommit) []codehost.Commit {
+	commits := make([]codehost.Commit, 0, len(repoCommits))
+	for _, rc := range repoCommits {
+		commit := codehost.Commit{
+			SHA:         rc.GetSHA(),
+			Message:     rc.GetCommit().GetMessage(),
+			AuthorLogin: rc.GetAuthor().GetLogin(),
+			AuthorName:  rc.GetCommit().GetAuthor().GetName(),
+			AuthorEmail: strings.ToLower(rc.GetCommit().GetAuthor().GetEmail()),
+		}
+		commit.CoAuthors = codehost.ParseCoAuthors(commit.Message)
+		commits = append(commits, commit)
+	}
+	return commits
+}
+
+// fetchCommitStats fills in the line counts of the commits, since the PR
+// commit listing does not include them. Cached counts are reused, and up to
+// maxCommitStats others are fetched. It reports whether any commit was skipped.
+func (s *Service) fetchCommitStats(ctx context.Context, client *github.Client, host, owner, repo string, commits []codehost.Commit) bool {
+	sem := make(chan struct{}, commitStatsConcurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	skipped := false
+	fetched := 0
+
+	for i := range commits {
+		key := commitStatsKey(host, owner, repo, commits[i].SHA)
+		if stats, ok := s.commitStats.get(key); ok {
+			commits[i].Additions = stats.additions
+			commits[i].Deletions = stats.deletions
+			commits[i].HasStats = true
+			continue
+		}
+		if fetched == maxCommitStats {
+			skipped = true
+			continue
+		}
+		fetched++
+
+		wg.Add(1)
+		go func(commit *codehost.Commit, key string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			rc, _, err := client.Repositories.GetCommit(ctx, owner, repo, commit.SHA, nil)
+			if err != nil {
+				log.Printf("Error fetching stats for commit %s: %v", commit.SHA, err)
+				mu.Lock()
+				skipped = true
+				mu.Unlock()
+				return
+			}
+			commit.Additions = rc.GetStats().GetAdditions()
+			commit.Deletions = rc.GetStats().GetDeletions()
+			commit.HasStats = true
+			s.commitStats.put(key, commitStats{additions: commit.Additions, deletions: commit.Deletions})
+		}(&commits[i], key)
+	}
+
+	wg.Wait()
+	return skipped
+}
@@ -0,0 +1,67 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-github/v62/github"
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+)
+
+func TestFetchCommitStatsCapsAndCachesLookups(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sha, ok := strings.CutPrefix(r.URL.Path, "/repos/acme/widgets/commits/")
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		calls.Add(1)
+		fmt.Fprintf(w, `{"sha": %q, "stats": {"additions": 3, "deletions": 1}}`, sha)
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	s := &Service{}
+
+	newCommits := func() []codehost.Commit {
+		commits := make([]codehost.Commit, maxCommitStats+10)
+		for i := range commits {
+			commits[i].SHA = fmt.Sprintf("sha%d", i)
+		}
+		return commits
+	}
+
+	// The first pass fetches up to the cap and reports the rest as skipped
+	commits := newCommits()
+	if skipped := s.fetchCommitStats(context.Background(), client, DefaultHost, "acme", "widgets", commits); !skipped {
+		t.Errorf("first fetchCommitStats() skipped = false, want true")
+	}
+	if got := calls.Load(); got != maxCommitStats {
+		t.Errorf("first fetchCommitStats() made %d calls, want %d", got, maxCommitStats)
+	}
+	if !commits[0].HasStats || commits[0].Additions != 3 || commits[len(commits)-1].HasStats {
+		t.Errorf("first fetchCommitStats() = %+v ... %+v, want the first commits counted only", commits[0], commits[len(commits)-1])
+	}
+
+	// The second pass reuses the cached counts and fetches only the others
+	commits = newCommits()
+	if skipped := s.fetchCommitStats(context.Background(), client, DefaultHost, "acme", "widgets", commits); skipped {
+		t.Errorf("second fetchCommitStats() skipped = true, want false")
+	}
+	if got := calls.Load(); got != maxCommitStats+10 {
+		t.Errorf("fetchCommitStats() made %d calls in total, want %d", got, maxCommitStats+10)
+	}
+	for _, commit := range commits {
+		if !commit.HasStats || commit.Additions != 3 || commit.Deletions != 1 {
+			t.Errorf("second fetchCommitStats() commit %s = %+v, want its line counts", commit.SHA, commit)
+		}
+	}
+}
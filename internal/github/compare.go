@@ -0,0 +1,292 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/go-github/v62/github"
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+)
+
+var (
+	// ErrNothingToCompare means the head of a comparison has no commits that
+	// are not already on its base
+	ErrNothingToCompare = errors.New("there is nothing to compare")
+
+	// ErrPullRequestRejected means GitHub refused to open a pull request, for
+	// example because one already exists for the branch
+	ErrPullRequestRejected = errors.New("GitHub did not create the pull request")
+
+	// ErrReviewRejected means GitHub refused a review, for example because a
+	// comment is on a line outside the pull request's diff
+	ErrReviewRejected = errors.New("GitHub did not accept the review")
+)
+
+// compareFileLimit is the number of files the compare API returns at most
+const compareFileLimit = 300
+
+// CompareRef identifies a comparison of two branches (or tags or commits) of
+// a repository, the changes a pull request from head into base would contain.
+// Head may name a branch of a fork as "user:branch".
+type CompareRef struct {
+	Host  string `json:"host"`
+	Owner string `json:"owner"`
+	Repo  string `json:"repo"`
+	Base  string `json:"base"`
+	Head  string `json:"head"`
+}
+
+// Repository returns "owner/repo"
+func (r CompareRef) Repository() string {
+	return r.Owner + "/" + r.Repo
+}
+
+// String returns "owner/repo base...head", prefixed with the host outside github.com
+func (r CompareRef) String() string {
+	name := r.Repository()
+	if r.Host != DefaultHost {
+		name = r.Host + "/" + name
+	}
+	return fmt.Sprintf("%s %s...%s", name, r.Base, r.Head)
+}
+
+// CompareURL returns the web address of a comparison, where GitHub offers to
+// open a pull request for it
+func CompareURL(ref CompareRef) string {
+	return fmt.Sprintf("https://%s/%s/%s/compare/%s...%s", ref.Host, ref.Owner, ref.Repo, escapeRef(ref.Base), escapeRef(ref.Head))
+}
+
+// escapeRef escapes a branch, tag or commit name for a URL path. The slashes
+// of names like feature/login are kept, since GitHub expects them unescaped.
+func escapeRef(name string) string {
+	segments := strings.Split(name, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// ParseCompareRef parses a compare URL such as
+// https://github.com/owner/repo/compare/main...feature/login, with or without
+// scheme and query. Two-dot comparisons (base..head) are accepted as well.
+func ParseCompareRef(input string) (CompareRef, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return CompareRef{}, fmt.Errorf("compare URL is empty")
+	}
+	if !strings.Contains(input, "://") {
+		input = "https://" + input
+	}
+
+	u, err := url.Parse(input)
+	if err != nil {
+		return CompareRef{}, fmt.Errorf("invalid compare URL: %w", err)
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return CompareRef{}, fmt.Errorf("unsupported URL scheme %q", u.Scheme)
+	}
+
+	// owner/repo/compare/base...head, where the branch names may contain slashes
+	segments := strings.SplitN(strings.Trim(u.Path, "/"), "/", 4)
+	if u.Host == "" || len(segments) < 4 || segments[2] != "compare" {
+		return CompareRef{}, fmt.Errorf("expected a compare URL like https://github.com/owner/repo/compare/main...feature")
+	}
+	spec := segments[3]
+	base, head, ok := strings.Cut(spec, "...")
+	if !ok {
+		base, head, ok = strings.Cut(spec, "..")
+	}
+	if !ok {
+		return CompareRef{}, fmt.Errorf("compare URL must name both branches, as base...head")
+	}
+	return NewCompareRef(u.Host+"/"+segments[0]+"/"+segments[1], base, head)
+}
+
+// NewCompareRef validates the parts of a comparison. repository is
+// "owner/repo", or "host/owner/repo" outside github.com.
+func NewCompareRef(repository, base, head string) (CompareRef, error) {
+	parts := strings.Split(strings.Trim(strings.TrimSpace(repository), "/"), "/")
+	host := DefaultHost
+	switch len(parts) {
+	case 2:
+	case 3:
+		host, parts = parts[0], parts[1:]
+	default:
+		return CompareRef{}, fmt.Errorf("invalid repository %q, expected [host/]owner/repo", repository)
+	}
+
+	// Reuse the pull request checks for the host, owner and repository name
+	prRef, err := newPRRef(host, parts[0], parts[1], "1")
+	if err != nil {
+		return CompareRef{}, err
+	}
+
+	ref := CompareRef{Host: prRef.Host, Owner: prRef.Owner, Repo: prRef.Repo, Base: strings.TrimSpace(base), Head: strings.TrimSpace(head)}
+	for _, branch := range []string{ref.Base, ref.Head} {
+		if branch == "" || strings.HasPrefix(branch, "-") || strings.ContainsAny(branch, " \t\n~^?*[\\") {
+			return CompareRef{}, fmt.Errorf("invalid ref name %q", branch)
+		}
+	}
+	return ref, nil
+}
+
+// FetchComparison fetches the commits and files a pull request from head into
+// base would contain, in the shape of a pull request that has not been opened
+// yet: it has no number, body, reviews or labels.
+func (s *Service) FetchComparison(ctx context.Context, ref CompareRef) (*codehost.ChangeRequest, error) {
+	if s.demoMode {
+		return getMockComparison(ref), nil
+	}
+
+	client, err := s.clientFor(ctx, ref.Host, ref.Owner)
+	if err != nil {
+		return nil, err
+	}
+
+	// Without paging options GitHub returns up to 250 commits, ending with the head
+	comparison, _, err := client.Repositories.CompareCommits(ctx, ref.Owner, ref.Repo, ref.Base, ref.Head, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare %s: %w", ref, classifyError(ref.Host, err))
+	}
+	if comparison.GetAheadBy() == 0 || len(comparison.Commits) == 0 {
+		return nil, fmt.Errorf("%w: %s already contains %s", ErrNothingToCompare, ref.Base, ref.Head)
+	}
+
+	var truncatedLists []string
+	repoCommits := comparison.Commits
+	if comparison.GetTotalCommits() > len(repoCommits) || len(repoCommits) > s.maxListItems {
+		truncatedLists = append(truncatedLists, "commits")
+	}
+	repoCommits = repoCommits[max(len(repoCommits)-s.maxListItems, 0):]
+
+	files := comparison.Files
+	if len(files) >= compareFileLimit || len(files) > s.maxListItems {
+		truncatedLists = append(truncatedLists, "files")
+	}
+	additions, deletions := 0, 0
+	for _, file := range files {
+		additions += file.GetAdditions()
+		deletions += file.GetDeletions()
+	}
+	totalFiles := len(files)
+	files = files[:min(len(files), s.maxListItems)]
+
+	commits := convertCommits(repoCommits)
+	if s.fetchCommitStats(ctx, client, ref.Host, ref.Owner, ref.Repo, commits) {
+		truncatedLists = append(truncatedLists, "commit line counts")
+	}
+
+	var messages []string
+	for _, commit := range commits {
+		messages = append(messages, commit.Message)
+	}
+	linkedIssues, incomplete := s.fetchLinkedIssues(ctx, client, ref.Host, ref.Repository(), 0, messages...)
+	if incomplete {
+		truncatedLists = append(truncatedLists, "linked issues")
+	}
+
+	template, err := fetchTemplate(ctx, client, ref.Host, ref.Owner, ref.Repo, ref.Base)
+	if err != nil {
+		log.Printf("Error fetching pull request template of %s: %v", ref.Repository(), err)
+	}
+
+	// Like GitHub, title the pull request after its only commit or its branch
+	title := ref.Head
+	if len(commits) == 1 {
+		title, _, _ = strings.Cut(commits[0].Message, "\n")
+	}
+
+	first, last := repoCommits[0], repoCommits[len(repoCommits)-1]
+	author := convertUser(last.Author)
+	if author == nil {
+		author = &codehost.User{Name: last.GetCommit().GetAuthor().GetName()}
+	}
+	return &codehost.ChangeRequest{
+		Title:             title,
+		User:              author,
+		State:             "not opened",
+		CreatedAt:         first.GetCommit().GetCommitter().GetDate().Time,
+		UpdatedAt:         last.GetCommit().GetCommitter().GetDate().Time,
+		ChangedFiles:      convertFiles(files),
+		TotalChangedFiles: totalFiles,
+		Commits:           commits,
+		Additions:         additions,
+		Deletions:         deletions,
+		Host:              ref.Host,
+		Repository:        ref.Repository(),
+		URL:               CompareURL(ref),
+		BaseRef:           ref.Base,
+		HeadRef:           ref.Head,
+		Template:          template,
+		LinkedIssues:      linkedIssues,
+		HeadSHA:           last.GetSHA(),
+		Contributors:      codehost.BuildContributors(commits),
+		Truncated:         len(truncatedLists) > 0,
+		TruncatedLists:    truncatedLists,
+	}, nil
+}
+
+// CreateDraftPullRequest opens a draft pull request from head into base and
+// returns a reference to it. It returns codehost.ErrReadOnly or
+// ErrNotConnected as UpdateBody does, and ErrPullRequestRejected with GitHub's
+// reasons when GitHub refuses the pull request.
+func (s *Service) CreateDraftPullRequest(ctx context.Context, ref CompareRef, title, body string) (codehost.Ref, error) {
+	if err := s.CheckWrite(ctx, ref.Host, ref.Owner); err != nil {
+		return codehost.Ref{}, err
+	}
+
+	client, err := s.clientFor(ctx, ref.Host, ref.Owner)
+	if err != nil {
+		return codehost.Ref{}, err
+	}
+
+	pr, _, err := client.PullRequests.Create(ctx, ref.Owner, ref.Repo, &github.NewPullRequest{
+		Title: github.String(title),
+		Head:  github.String(ref.Head),
+		Base:  github.String(ref.Base),
+		Body:  github.String(body),
+		Draft: github.Bool(true),
+	})
+	var responseErr *github.ErrorResponse
+	if errors.As(err, &responseErr) && responseErr.Response != nil && responseErr.Response.StatusCode == http.StatusUnprocessableEntity {
+		return codehost.Ref{}, fmt.Errorf("%w: %s", ErrPullRequestRejected, rejectionReasons(responseErr))
+	}
+	if err != nil {
+		return codehost.Ref{}, fmt.Errorf("failed to create pull request for %s: %w", ref, classifyError(ref.Host, err))
+	}
+	return codehost.Ref{Host: ref.Host, Owner: ref.Owner, Repo: ref.Repo, Number: pr.GetNumber()}, nil
+}
+
+// rejectionReasons joins the messages of a validation error, such as "A pull
+// request already exists for owner:branch."
+func rejectionReasons(err *github.ErrorResponse) string {
+	var reasons []string
+	for _, e := range err.Errors {
+		if e.Message != "" {
+			reasons = append(reasons, e.Message)
+		}
+	}
+	if len(reasons) == 0 {
+		return err.Message
+	}
+	return strings.Join(reasons, " ")
+}
+
+// getMockComparison returns the sample pull request as a comparison
+func getMockComparison(ref CompareRef) *codehost.ChangeRequest {
+	prData := getMockPRData(codehost.Ref{Host: ref.Host, Owner: ref.Owner, Repo: ref.Repo})
+	prData.Title = ref.Head
+	prData.Body = ""
+	prData.State = "not opened"
+	prData.Labels = nil
+	prData.Assignees = nil
+	prData.URL = CompareURL(ref)
+	prData.BaseRef = ref.Base
+	prData.HeadRef = ref.Head
+	return prData
+}
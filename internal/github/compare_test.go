@@ -0,0 +1,89 @@
+package github
+
+import "testing"
+
+func TestParseCompareRef(t *testing.T) {
+	want := CompareRef{Host: "github.com", Owner: "octo-org", Repo: "hello.world", Base: "main", Head: "feature/login"}
+	fork := CompareRef{Host: "github.com", Owner: "octo-org", Repo: "hello.world", Base: "main", Head: "alice:fix"}
+	enterprise := CompareRef{Host: "ghe.example.com", Owner: "team", Repo: "service", Base: "release/1.2", Head: "hotfix"}
+
+	tests := []struct {
+		name  string
+		input string
+		want  CompareRef
+	}{
+		{"full URL", "https://github.com/octo-org/hello.world/compare/main...feature/login", want},
+		{"without scheme", "github.com/octo-org/hello.world/compare/main...feature/login", want},
+		{"with query", "https://github.com/octo-org/hello.world/compare/main...feature/login?expand=1", want},
+		{"two dots", "https://www.github.com/octo-org/hello.world/compare/main..feature/login", want},
+		{"fork", "https://github.com/octo-org/hello.world/compare/main...alice:fix", fork},
+		{"enterprise", "https://ghe.example.com/team/service/compare/release/1.2...hotfix", enterprise},
+	}
+	for _, tt := range tests {
+		got, err := ParseCompareRef(tt.input)
+		if err != nil {
+			t.Errorf("%s: ParseCompareRef(%q) returned error: %v", tt.name, tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: ParseCompareRef(%q) = %+v, want %+v", tt.name, tt.input, got, tt.want)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"https://github.com/octo-org/hello.world/compare/feature",
+		"https://github.com/octo-org/hello.world/compare/main...",
+		"https://github.com/octo-org/hello.world/pull/123",
+		"https://github.com/octo-org/hello.world/compare/main...--upload-pack",
+		"ftp://github.com/octo-org/hello.world/compare/main...feature",
+	}
+	for _, input := range invalid {
+		if got, err := ParseCompareRef(input); err == nil {
+			t.Errorf("ParseCompareRef(%q) = %+v, want error", input, got)
+		}
+	}
+}
+
+func TestNewCompareRef(t *testing.T) {
+	got, err := NewCompareRef(" octo-org/hello.world ", "main", "feature/login")
+	if err != nil || got != (CompareRef{Host: "github.com", Owner: "octo-org", Repo: "hello.world", Base: "main", Head: "feature/login"}) {
+		t.Errorf("NewCompareRef() = %+v, %v", got, err)
+	}
+	if _, err := NewCompareRef("octo-org", "main", "feature"); err == nil {
+		t.Error("NewCompareRef() accepted a repository without owner")
+	}
+	if _, err := NewCompareRef("octo-org/hello.world", "main", "has space"); err == nil {
+		t.Error("NewCompareRef() accepted a branch with a space")
+	}
+}
+
+func TestCompareURL(t *testing.T) {
+	tests := []struct {
+		ref  CompareRef
+		want string
+	}{
+		{
+			CompareRef{Host: "github.com", Owner: "octo-org", Repo: "hello.world", Base: "main", Head: "feature/login"},
+			"https://github.com/octo-org/hello.world/compare/main...feature/login",
+		},
+		{
+			CompareRef{Host: "github.com", Owner: "octo-org", Repo: "hello.world", Base: "main", Head: "alice:fix#12"},
+			"https://github.com/octo-org/hello.world/compare/main...alice:fix%2312",
+		},
+		{
+			CompareRef{Host: "ghe.example.com", Owner: "team", Repo: "service", Base: "v1.0", Head: "release/100%"},
+			"https://ghe.example.com/team/service/compare/v1.0...release/100%25",
+		},
+	}
+
+	for _, tt := range tests {
+		got := CompareURL(tt.ref)
+		if got != tt.want {
+			t.Errorf("CompareURL(%+v) = %q, want %q", tt.ref, got, tt.want)
+		}
+		if parsed, err := ParseCompareRef(got); err != nil || parsed != tt.ref {
+			t.Errorf("ParseCompareRef(%q) = %+v, %v, want %+v", got, parsed, err, tt.ref)
+		}
+	}
+}
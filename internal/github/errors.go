@@ -0,0 +1,63 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/go-github/v62/github"
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+)
+
+// classifyError maps an error returned by the go-github client for host onto
+// the error taxonomy of the codehost package. Errors that do not match a known
+// category are returned unchanged.
+func classifyError(host string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	// Cancellation comes from our own caller, not from GitHub
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	var rateLimitErr *github.RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		return &codehost.RateLimitError{Host: host, Reset: rateLimitErr.Rate.Reset.Time, Err: err}
+	}
+
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &abuseErr) {
+		reset := time.Now().Add(time.Minute)
+		if abuseErr.RetryAfter != nil {
+			reset = time.Now().Add(*abuseErr.RetryAfter)
+		}
+		return &codehost.RateLimitError{Host: host, Reset: reset, Err: err}
+	}
+
+	var responseErr *github.ErrorResponse
+	if errors.As(err, &responseErr) && responseErr.Response != nil {
+		switch responseErr.Response.StatusCode {
+		case http.StatusNotFound:
+			return fmt.Errorf("%w: %v", codehost.ErrNotFound, err)
+		case http.StatusUnauthorized:
+			return fmt.Errorf("%w: %v", codehost.ErrUnauthorized, err)
+		case http.StatusForbidden:
+			return fmt.Errorf("%w: %v", codehost.ErrForbidden, err)
+		}
+		return err
+	}
+
+	var urlErr *url.Error
+	var netErr net.Error
+	if errors.As(err, &urlErr) || errors.As(err, &netErr) {
+		return &codehost.NetworkError{Host: host, Err: err}
+	}
+
+	return err
+}
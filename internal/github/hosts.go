@@ -0,0 +1,116 @@
+package github
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/google/go-github/v62/github"
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+)
+
+// DefaultHost is the host of pull requests on github.com
+const DefaultHost = codehost.DefaultHost
+
+// hostClient holds the clients for one GitHub host (github.com or a GitHub
+// Enterprise Server). Credentials never leave the host they are configured for.
+type hostClient struct {
+	host     string
+	base     *github.Client // unauthenticated client the others are derived from
+	client   *github.Client // token (or anonymous) client, the fallback when no app installation applies
+	app      *appAuth       // nil unless GitHub App credentials are configured (github.com only)
+	canWrite bool           // a token is configured, so write calls can be attempted where no app installation applies
+}
+
+// enterpriseHost is a GitHub Enterprise Server listed in GITHUB_ENTERPRISE_HOSTS
+type enterpriseHost struct {
+	host   string // as it appears in pull request URLs
+	apiURL string // API root; WithEnterpriseURLs appends api/v3/ when missing
+}
+
+// enterpriseHostsFromEnv parses GITHUB_ENTERPRISE_HOSTS, a comma-separated list
+// of hosts, each optionally followed by "=<API URL>" when the API is not served
+// from https://<host>/api/v3/
+func enterpriseHostsFromEnv() ([]enterpriseHost, error) {
+	var hosts []enterpriseHost
+	for _, entry := range strings.Split(os.Getenv("GITHUB_ENTERPRISE_HOSTS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		host, apiURL, _ := strings.Cut(entry, "=")
+		host = strings.ToLower(strings.TrimSpace(host))
+		if host == "" || strings.ContainsAny(host, "/ ") {
+			return nil, fmt.Errorf("invalid GITHUB_ENTERPRISE_HOSTS entry %q", entry)
+		}
+		if host == DefaultHost {
+			return nil, fmt.Errorf("GITHUB_ENTERPRISE_HOSTS must not list %s", DefaultHost)
+		}
+
+		apiURL = strings.TrimSpace(apiURL)
+		if apiURL == "" {
+			apiURL = "https://" + host + "/"
+		}
+		hosts = append(hosts, enterpriseHost{host: host, apiURL: apiURL})
+	}
+	return hosts, nil
+}
+
+// newEnterpriseClient creates the clients for a GitHub Enterprise Server,
+// authenticated with the token in its GITHUB_ENTERPRISE_TOKEN_<HOST> variable
+func newEnterpriseClient(enterprise enterpriseHost) (*hostClient, error) {
+	base, err := github.NewClient(nil).WithEnterpriseURLs(enterprise.apiURL, enterprise.apiURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid API URL for %s: %w", enterprise.host, err)
+	}
+
+	token := os.Getenv(enterpriseTokenVariable(enterprise.host))
+	client := base
+	if token != "" {
+		client = base.WithAuthToken(token)
+	}
+
+	return &hostClient{
+		host:     enterprise.host,
+		base:     base,
+		client:   client,
+		canWrite: token != "",
+	}, nil
+}
+
+// enterpriseTokenVariable names the environment variable holding the token for
+// host, e.g. GITHUB_ENTERPRISE_TOKEN_GHE_EXAMPLE_COM
+func enterpriseTokenVariable(host string) string {
+	return codehost.HostVariable("GITHUB_ENTERPRISE_TOKEN_", host)
+}
+
+// normalizeHost lowercases host, treating an empty host as github.com
+func normalizeHost(host string) string {
+	if host == "" {
+		return DefaultHost
+	}
+	return strings.ToLower(host)
+}
+
+// hostClientFor returns the clients for host, or codehost.ErrUnknownHost
+func (s *Service) hostClientFor(host string) (*hostClient, error) {
+	h, ok := s.hosts[normalizeHost(host)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", codehost.ErrUnknownHost, host)
+	}
+	return h, nil
+}
+
+// Hosts returns the configured hosts, github.com first
+func (s *Service) Hosts() []string {
+	var enterprise []string
+	for host := range s.hosts {
+		if host != DefaultHost {
+			enterprise = append(enterprise, host)
+		}
+	}
+	slices.Sort(enterprise)
+	return append([]string{DefaultHost}, enterprise...)
+}
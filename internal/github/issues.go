@@ -0,0 +1,67 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v62/github"
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+)
+
+// maxLinkedIssues caps how many referenced issues are fetched for one pull request
+const maxLinkedIssues = 10
+
+// IssueURL returns the web address of an issue on github.com or a GitHub Enterprise host
+func IssueURL(host, repository string, number int) string {
+	return fmt.Sprintf("https://%s/%s/issues/%d", host, repository, number)
+}
+
+// fetchLinkedIssues fetches the issues referenced in texts (the pull request
+// body and commit messages), skipping the pull request itself (self, 0 for
+// none) and other pull requests. Issues that cannot be read, such as those in
+// private repositories the credentials cannot see, are left out. It reports
+// whether any issue was left out for another reason or because of the cap.
+func (s *Service) fetchLinkedIssues(ctx context.Context, client *github.Client, host, repository string, self int, texts ...string) ([]*codehost.Issue, bool) {
+	var refs []codehost.IssueReference
+	for _, ref := range codehost.ParseIssueReferences(strings.Join(texts, "\n\n"), host, repository) {
+		if ref.Repository != repository || ref.Number != self {
+			refs = append(refs, ref)
+		}
+	}
+
+	// Issues the pull request closes matter most when there are too many
+	sort.SliceStable(refs, func(i, j int) bool { return refs[i].Closes && !refs[j].Closes })
+	incomplete := len(refs) > maxLinkedIssues
+	refs = refs[:min(len(refs), maxLinkedIssues)]
+
+	issues := make([]*codehost.Issue, 0, len(refs))
+	for _, ref := range refs {
+		owner, repo := codehost.SplitRepository(ref.Repository)
+		issue, _, err := client.Issues.Get(ctx, owner, repo, ref.Number)
+		if err := classifyError(host, err); err != nil {
+			if !errors.Is(err, codehost.ErrNotFound) && !errors.Is(err, codehost.ErrForbidden) {
+				log.Printf("Error fetching issue %s#%d: %v", ref.Repository, ref.Number, err)
+				incomplete = true
+			}
+			continue
+		}
+		if issue.IsPullRequest() {
+			continue
+		}
+		issues = append(issues, &codehost.Issue{
+			Repository: ref.Repository,
+			Number:     ref.Number,
+			Title:      issue.GetTitle(),
+			Body:       issue.GetBody(),
+			Labels:     convertLabels(issue.Labels),
+			State:      issue.GetState(),
+			URL:        IssueURL(host, ref.Repository, ref.Number),
+			Closes:     ref.Closes,
+		})
+	}
+	return issues, incomplete
+}
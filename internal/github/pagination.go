@@ -0,0 +1,31 @@
+package github
+
+import "github.com/google/go-github/v62/github"
+
+// perPage is the largest page size the GitHub REST API accepts
+const perPage = 100
+
+// listAll follows the pagination of a GitHub list endpoint until every page has
+// been fetched or limit items have been collected. The boolean result reports
+// whether items were left out because of the limit.
+func listAll[T any](limit int, list func(opts *github.ListOptions) ([]T, *github.Response, error)) ([]T, bool, error) {
+	opts := &github.ListOptions{PerPage: perPage}
+	var all []T
+
+	for {
+		page, resp, err := list(opts)
+		if err != nil {
+			return all, false, err
+		}
+		all = append(all, page...)
+
+		if len(all) >= limit {
+			truncated := len(all) > limit || resp.NextPage != 0
+			return all[:limit], truncated, nil
+		}
+		if resp.NextPage == 0 {
+			return all, false, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
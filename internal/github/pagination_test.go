@@ -0,0 +1,81 @@
+package github
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-github/v62/github"
+)
+
+// pagedList serves items in pages of pageSize like a GitHub list endpoint,
+// recording the pages requested
+func pagedList(items []int, pageSize int, requested *[]int) func(opts *github.ListOptions) ([]int, *github.Response, error) {
+	return func(opts *github.ListOptions) ([]int, *github.Response, error) {
+		page := max(opts.Page, 1)
+		*requested = append(*requested, page)
+
+		start := min((page-1)*pageSize, len(items))
+		end := min(start+pageSize, len(items))
+		resp := &github.Response{}
+		if end < len(items) {
+			resp.NextPage = page + 1
+		}
+		return items[start:end], resp, nil
+	}
+}
+
+func TestListAll(t *testing.T) {
+	items := make([]int, 250)
+	for i := range items {
+		items[i] = i
+	}
+
+	tests := []struct {
+		name          string
+		items         []int
+		limit         int
+		wantLen       int
+		wantTruncated bool
+		wantPages     int
+	}{
+		{"empty", nil, 100, 0, false, 1},
+		{"single page", items[:40], 100, 40, false, 1},
+		{"several pages", items, 1000, 250, false, 3},
+		{"exactly the limit", items[:200], 200, 200, false, 2},
+		{"limit within a page", items, 150, 150, true, 2},
+		{"limit at a page boundary", items, 100, 100, true, 1},
+	}
+
+	for _, tt := range tests {
+		var requested []int
+		got, truncated, err := listAll(tt.limit, pagedList(tt.items, perPage, &requested))
+		if err != nil {
+			t.Fatalf("%s: listAll() error = %v", tt.name, err)
+		}
+		if len(got) != tt.wantLen || truncated != tt.wantTruncated || len(requested) != tt.wantPages {
+			t.Errorf("%s: listAll() = %d items, truncated %v after %d pages, want %d items, truncated %v after %d pages",
+				tt.name, len(got), truncated, len(requested), tt.wantLen, tt.wantTruncated, tt.wantPages)
+		}
+		for i, item := range got {
+			if item != i {
+				t.Errorf("%s: listAll()[%d] = %d, want the items in order", tt.name, i, item)
+				break
+			}
+		}
+	}
+}
+
+func TestListAllReturnsErrors(t *testing.T) {
+	failure := errors.New("rate limited")
+	calls := 0
+	_, _, err := listAll(1000, func(opts *github.ListOptions) ([]int, *github.Response, error) {
+		calls++
+		if calls == 2 {
+			return nil, nil, failure
+		}
+		return []int{1}, &github.Response{NextPage: calls + 1}, nil
+	})
+	if !errors.Is(err, failure) || calls != 2 {
+		t.Errorf("listAll() error = %v after %d calls, want %v after 2", err, calls, failure)
+	}
+}
@@ -0,0 +1,192 @@
+package github
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+)
+
+// PullRequestURL returns the web address of a pull request on github.com or a
+// GitHub Enterprise host
+func PullRequestURL(ref codehost.Ref) string {
+	return fmt.Sprintf("https://%s/%s/%s/pull/%d", ref.Host, ref.Owner, ref.Repo, ref.Number)
+}
+
+var (
+	// ownerPattern matches GitHub user and organization names
+	ownerPattern = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9-]*[A-Za-z0-9])?$`)
+
+	// repoPattern matches GitHub repository names
+	repoPattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+	// shorthandPattern matches "[host/]owner/repo#123"
+	shorthandPattern = regexp.MustCompile(`^(?:([^/\s#]+)/)?([^/\s#]+)/([^/\s#]+)#(\d+)$`)
+)
+
+// ParsePRRef parses a reference to a pull request. It accepts:
+//
+//   - URLs, with or without scheme, "www." or trailing slash, and with any
+//     sub-path, query or fragment: https://github.com/owner/repo/pull/123/files
+//   - the shorthand owner/repo#123, or host/owner/repo#123 for other hosts
+//   - gh commands: gh pr view 123 --repo owner/repo, or with a URL or shorthand
+//
+// The host is not checked against the configured hosts; see codehost.Registry.
+func ParsePRRef(input string) (codehost.Ref, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return codehost.Ref{}, fmt.Errorf("pull request reference is empty")
+	}
+
+	if fields := strings.Fields(input); fields[0] == "gh" {
+		return parseGHCommand(fields)
+	}
+	if len(strings.Fields(input)) > 1 {
+		return codehost.Ref{}, fmt.Errorf("unrecognized pull request reference %q", input)
+	}
+
+	if match := shorthandPattern.FindStringSubmatch(input); match != nil {
+		host := DefaultHost
+		if match[1] != "" {
+			host = match[1]
+		}
+		return newPRRef(host, match[2], match[3], match[4])
+	}
+
+	return parsePRURL(input)
+}
+
+// parsePRURL parses a pull request URL, adding https:// when the scheme is missing
+func parsePRURL(input string) (codehost.Ref, error) {
+	if !strings.Contains(input, "://") {
+		// Without a scheme, only something that starts with a host is a URL
+		host, _, _ := strings.Cut(input, "/")
+		if !strings.ContainsAny(host, ".:") {
+			return codehost.Ref{}, fmt.Errorf("expected a pull request URL, owner/repo#123 or a gh pr command")
+		}
+		input = "https://" + input
+	}
+
+	u, err := url.Parse(input)
+	if err != nil {
+		return codehost.Ref{}, fmt.Errorf("invalid pull request URL: %w", err)
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return codehost.Ref{}, fmt.Errorf("unsupported URL scheme %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return codehost.Ref{}, fmt.Errorf("pull request URL has no host")
+	}
+
+	// owner/repo/pull/123, followed by anything (files, commits/<sha>, ...)
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(segments) >= 4 && segments[2] == "compare" {
+		return codehost.Ref{}, fmt.Errorf("this is a branch comparison, not a pull request; describe it with the branch comparison form")
+	}
+	if len(segments) < 4 || (segments[2] != "pull" && segments[2] != "pulls") {
+		return codehost.Ref{}, fmt.Errorf("expected a pull request URL like https://%s/owner/repo/pull/123", u.Host)
+	}
+	return newPRRef(u.Host, segments[0], segments[1], segments[3])
+}
+
+// ghValueFlags are the gh pr flags whose value is the next argument, so it is
+// not mistaken for the pull request. Short flags that take a value in some
+// subcommands but not in others (-c, -m) are left out.
+var ghValueFlags = map[string]bool{
+	"--jq": true, "-q": true, "--json": true, "--template": true, "-t": true,
+	"--title": true, "--body": true, "-b": true, "--body-file": true, "-F": true,
+	"--subject": true, "--base": true, "-B": true, "--branch": true,
+	"--author-email": true, "-A": true, "--match-head-commit": true, "--color": true,
+	"--milestone": true, "--comment": true,
+	"--add-label": true, "--remove-label": true, "--add-reviewer": true, "--remove-reviewer": true,
+	"--add-assignee": true, "--remove-assignee": true, "--add-project": true, "--remove-project": true,
+}
+
+// parseGHCommand parses "gh pr <subcommand> <number|url|shorthand> [-R|--repo [host/]owner/repo]"
+func parseGHCommand(fields []string) (codehost.Ref, error) {
+	if len(fields) < 3 || fields[1] != "pr" {
+		return codehost.Ref{}, fmt.Errorf("expected a gh pr command like: gh pr view 123 --repo owner/repo")
+	}
+
+	var target, repository string
+	for i := 3; i < len(fields); i++ {
+		field := fields[i]
+		switch {
+		case field == "-R" || field == "--repo":
+			if i+1 >= len(fields) {
+				return codehost.Ref{}, fmt.Errorf("%s needs a repository", field)
+			}
+			i++
+			repository = fields[i]
+		case strings.HasPrefix(field, "--repo="):
+			repository = strings.TrimPrefix(field, "--repo=")
+		case strings.HasPrefix(field, "-R"):
+			// -R=owner/repo or -Rowner/repo
+			repository = strings.TrimPrefix(strings.TrimPrefix(field, "-R"), "=")
+		case ghValueFlags[field]:
+			i++ // skip the value, as in --json title --jq .title
+		case strings.HasPrefix(field, "-"):
+			// Other flags (--web, --comments, ...) do not affect which PR is meant
+		case target == "":
+			target = field
+		}
+	}
+	if target == "" {
+		return codehost.Ref{}, fmt.Errorf("gh command does not name a pull request")
+	}
+
+	// A URL or shorthand is complete on its own
+	target = strings.TrimPrefix(target, "#")
+	if _, err := strconv.Atoi(target); err != nil {
+		return ParsePRRef(target)
+	}
+
+	if repository == "" {
+		return codehost.Ref{}, fmt.Errorf("gh command needs --repo owner/repo to identify pull request %s", target)
+	}
+	parts := strings.Split(strings.Trim(repository, "/"), "/")
+	switch len(parts) {
+	case 2:
+		return newPRRef(DefaultHost, parts[0], parts[1], target)
+	case 3:
+		return newPRRef(parts[0], parts[1], parts[2], target)
+	default:
+		return codehost.Ref{}, fmt.Errorf("invalid repository %q, expected [host/]owner/repo", repository)
+	}
+}
+
+// newPRRef validates and normalizes the parts of a reference. Hosts are
+// lowercased and lose a leading "www.".
+func newPRRef(host, owner, repo, number string) (codehost.Ref, error) {
+	host = strings.TrimPrefix(strings.ToLower(host), "www.")
+	if host == "" {
+		return codehost.Ref{}, fmt.Errorf("pull request reference has no host")
+	}
+	if !ownerPattern.MatchString(owner) {
+		return codehost.Ref{}, fmt.Errorf("invalid repository owner %q", owner)
+	}
+	repo = strings.TrimSuffix(repo, ".git")
+	if !repoPattern.MatchString(repo) || repo == "." || repo == ".." {
+		return codehost.Ref{}, fmt.Errorf("invalid repository name %q", repo)
+	}
+
+	prNumber, err := strconv.Atoi(number)
+	if err != nil || prNumber <= 0 {
+		return codehost.Ref{}, fmt.Errorf("invalid PR number: %s", number)
+	}
+
+	return codehost.Ref{Host: host, Owner: owner, Repo: repo, Number: prNumber}, nil
+}
+
+// ParseRef parses a reference to a pull request, see ParsePRRef
+func (s *Service) ParseRef(input string) (codehost.Ref, error) {
+	return ParsePRRef(input)
+}
+
+// URL returns the web address of the pull request
+func (s *Service) URL(ref codehost.Ref) string {
+	return PullRequestURL(ref)
+}
@@ -0,0 +1,130 @@
+package github
+
+import (
+	"testing"
+
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+)
+
+func TestParsePRRef(t *testing.T) {
+	want := codehost.Ref{Host: "github.com", Owner: "octo-org", Repo: "hello.world", Number: 123}
+	enterprise := codehost.Ref{Host: "ghe.example.com", Owner: "team", Repo: "service", Number: 7}
+
+	tests := []struct {
+		name  string
+		input string
+		want  codehost.Ref
+	}{
+		{"full URL", "https://github.com/octo-org/hello.world/pull/123", want},
+		{"files tab", "https://github.com/octo-org/hello.world/pull/123/files", want},
+		{"commit in PR", "https://github.com/octo-org/hello.world/pull/123/commits/0123abc", want},
+		{"trailing slash", "https://github.com/octo-org/hello.world/pull/123/", want},
+		{"query and fragment", "https://github.com/octo-org/hello.world/pull/123?w=1#discussion_r1", want},
+		{"http", "http://github.com/octo-org/hello.world/pull/123", want},
+		{"www", "https://www.github.com/octo-org/hello.world/pull/123", want},
+		{"no scheme", "github.com/octo-org/hello.world/pull/123", want},
+		{"upper case host", "https://GitHub.com/octo-org/hello.world/pull/123", want},
+		{"pulls segment", "https://github.com/octo-org/hello.world/pulls/123", want},
+		{"surrounding whitespace", "  https://github.com/octo-org/hello.world/pull/123\n", want},
+		{"shorthand", "octo-org/hello.world#123", want},
+		{"shorthand with host", "ghe.example.com/team/service#7", enterprise},
+		{"enterprise URL", "https://ghe.example.com/team/service/pull/7", enterprise},
+		{"enterprise URL with sub-path", "https://ghe.example.com/team/service/pull/7/checks", enterprise},
+		{"gh number and --repo", "gh pr view 123 --repo octo-org/hello.world", want},
+		{"gh -R before number", "gh pr checkout -R octo-org/hello.world 123", want},
+		{"gh --repo=", "gh pr diff 123 --repo=octo-org/hello.world", want},
+		{"gh hash number", "gh pr view #123 -R octo-org/hello.world", want},
+		{"gh other flags", "gh pr view --web 123 --comments -R octo-org/hello.world", want},
+		{"gh -R=", "gh pr view 123 -R=octo-org/hello.world", want},
+		{"gh flag values before number", "gh pr view --json title --jq .title -t {{.title}} 123 -R octo-org/hello.world", want},
+		{"gh flag value after number", "gh pr view 123 --json number -R octo-org/hello.world", want},
+		{"gh repo with host", "gh pr view 7 -R ghe.example.com/team/service", enterprise},
+		{"gh URL", "gh pr view https://github.com/octo-org/hello.world/pull/123", want},
+		{"gh shorthand", "gh pr view octo-org/hello.world#123", want},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePRRef(tt.input)
+			if err != nil {
+				t.Fatalf("ParsePRRef(%q) returned error: %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParsePRRef(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePRRefErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"empty", ""},
+		{"blank", "   "},
+		{"repository URL", "https://github.com/octo-org/hello.world"},
+		{"issue URL", "https://github.com/octo-org/hello.world/issues/123"},
+		{"missing number", "https://github.com/octo-org/hello.world/pull/"},
+		{"non-numeric number", "https://github.com/octo-org/hello.world/pull/abc"},
+		{"zero number", "octo-org/hello.world#0"},
+		{"negative number", "https://github.com/octo-org/hello.world/pull/-1"},
+		{"unsupported scheme", "ftp://github.com/octo-org/hello.world/pull/123"},
+		{"number only", "#123"},
+		{"repository only", "octo-org/hello.world"},
+		{"path without host", "octo-org/hello.world/pull/123"},
+		{"repo without owner", "hello.world#123"},
+		{"invalid owner", "octo_org/hello.world#123"},
+		{"invalid repo", "octo-org/hello world#123"},
+		{"dot repo", "octo-org/..#123"},
+		{"gh without repo", "gh pr view 123"},
+		{"gh without target", "gh pr view --repo octo-org/hello.world"},
+		{"gh repo flag without value", "gh pr view 123 --repo"},
+		{"gh invalid repo", "gh pr view 123 --repo hello.world"},
+		{"gh not a pr command", "gh issue view 123 --repo octo-org/hello.world"},
+		{"free text", "please describe octo-org/hello.world#123"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := ParsePRRef(tt.input); err == nil {
+				t.Errorf("ParsePRRef(%q) = %+v, want an error", tt.input, got)
+			}
+		})
+	}
+}
+
+func TestPRRefFormatting(t *testing.T) {
+	tests := []struct {
+		ref        codehost.Ref
+		wantString string
+		wantURL    string
+	}{
+		{
+			ref:        codehost.Ref{Host: "github.com", Owner: "octo-org", Repo: "hello.world", Number: 123},
+			wantString: "octo-org/hello.world#123",
+			wantURL:    "https://github.com/octo-org/hello.world/pull/123",
+		},
+		{
+			ref:        codehost.Ref{Host: "ghe.example.com", Owner: "team", Repo: "service", Number: 7},
+			wantString: "ghe.example.com/team/service#7",
+			wantURL:    "https://ghe.example.com/team/service/pull/7",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := tt.ref.String(); got != tt.wantString {
+			t.Errorf("%+v.String() = %q, want %q", tt.ref, got, tt.wantString)
+		}
+		if got := PullRequestURL(tt.ref); got != tt.wantURL {
+			t.Errorf("PullRequestURL(%+v) = %q, want %q", tt.ref, got, tt.wantURL)
+		}
+
+		// Both forms parse back to the same reference
+		for _, input := range []string{tt.ref.String(), PullRequestURL(tt.ref)} {
+			if got, err := ParsePRRef(input); err != nil || got != tt.ref {
+				t.Errorf("ParsePRRef(%q) = %+v, %v, want %+v", input, got, err, tt.ref)
+			}
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v62/github"
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+)
+
+// maxCommitLookups caps how many commits without a pull request number in
+// their message are looked up individually
+const maxCommitLookups = 200
+
+var (
+	// mergeCommitNumber matches the subject of GitHub's merge commits: "Merge pull request #123 from ..."
+	mergeCommitNumber = regexp.MustCompile(`^Merge pull request #(\d+) from `)
+
+	// squashCommitNumber matches the subject of squash merges: "Title (#123)"
+	squashCommitNumber = regexp.MustCompile(`\(#(\d+)\)$`)
+)
+
+// commitPullRequestNumber returns the number of the pull request a commit
+// merged, according to its subject, or 0 when it does not say
+func commitPullRequestNumber(message string) int {
+	subject, _, _ := strings.Cut(message, "\n")
+	subject = strings.TrimSpace(subject)
+	for _, pattern := range []*regexp.Regexp{mergeCommitNumber, squashCommitNumber} {
+		if match := pattern.FindStringSubmatch(subject); match != nil {
+			number, _ := strconv.Atoi(match[1])
+			return number
+		}
+	}
+	return 0
+}
+
+// FetchRelease finds the pull requests merged between two refs of a
+// repository (ref.Base is the earlier ref, ref.Head the later one). Pull
+// requests are found from the merge and squash commit subjects, and commits
+// that name none are looked up through the commit's associated pull requests,
+// which covers rebase merges.
+func (s *Service) FetchRelease(ctx context.Context, ref CompareRef) (*codehost.Release, error) {
+	if s.demoMode {
+		return getMockRelease(ref), nil
+	}
+
+	client, err := s.clientFor(ctx, ref.Host, ref.Owner)
+	if err != nil {
+		return nil, err
+	}
+
+	// Paging through a comparison pages through its commits, oldest first
+	var totalCommits int
+	repoCommits, truncated, err := listAll(s.maxListItems, func(opts *github.ListOptions) ([]*github.RepositoryCommit, *github.Response, error) {
+		comparison, resp, err := client.Repositories.CompareCommits(ctx, ref.Owner, ref.Repo, ref.Base, ref.Head, opts)
+		if err != nil {
+			return nil, resp, err
+		}
+		totalCommits = comparison.GetTotalCommits()
+		return comparison.Commits, resp, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare %s: %w", ref, classifyError(ref.Host, err))
+	}
+	if len(repoCommits) == 0 {
+		return nil, fmt.Errorf("%w: %s already contains %s", ErrNothingToCompare, ref.Base, ref.Head)
+	}
+
+	var truncatedLists []string
+	if truncated || totalCommits > len(repoCommits) {
+		truncatedLists = append(truncatedLists, "commits")
+	}
+
+	// Numbers named in commit subjects, and the commits that name none
+	numbers := map[int]bool{}
+	var unnamed []string
+	for _, rc := range repoCommits {
+		if number := commitPullRequestNumber(rc.GetCommit().GetMessage()); number > 0 {
+			numbers[number] = true
+		} else {
+			unnamed = append(unnamed, rc.GetSHA())
+		}
+	}
+	if len(unnamed) > maxCommitLookups {
+		unnamed = unnamed[:maxCommitLookups]
+		truncatedLists = append(truncatedLists, "commit lookups")
+	}
+
+	pullRequests, failed := s.fetchReleasePullRequests(ctx, client, ref, numbers, unnamed)
+	if failed {
+		truncatedLists = append(truncatedLists, "pull requests")
+	}
+
+	return &codehost.Release{
+		Host:           ref.Host,
+		Repository:     ref.Repository(),
+		From:           ref.Base,
+		To:             ref.Head,
+		URL:            CompareURL(ref),
+		HeadSHA:        repoCommits[len(repoCommits)-1].GetSHA(),
+		Commits:        max(totalCommits, len(repoCommits)),
+		PullRequests:   pullRequests,
+		Truncated:      len(truncatedLists) > 0,
+		TruncatedLists: truncatedLists,
+	}, nil
+}
+
+// fetchReleasePullRequests fetches the pull requests with the given numbers
+// and those associated with the unnamed commits, keeping the merged ones in
+// order of merging. It reports whether any request failed.
+func (s *Service) fetchReleasePullRequests(ctx context.Context, client *github.Client, ref CompareRef, numbers map[int]bool, unnamed []string) ([]*codehost.ReleasePullRequest, bool) {
+	sem := make(chan struct{}, commitStatsConcurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	failed := false
+	byNumber := map[int]*github.PullRequest{}
+
+	add := func(pr *github.PullRequest) {
+		mu.Lock()
+		defer mu.Unlock()
+		// Commits can also belong to open or closed pull requests from other branches
+		if pr.MergedAt != nil {
+			byNumber[pr.GetNumber()] = pr
+		}
+	}
+	fail := func(what string, err error) {
+		log.Printf("Error fetching %s of %s: %v", what, ref.Repository(), classifyError(ref.Host, err))
+		mu.Lock()
+		failed = true
+		mu.Unlock()
+	}
+
+	for number := range numbers {
+		wg.Add(1)
+		go func(number int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			// "(#123)" at the end of a subject can also name an issue
+			pr, _, err := client.PullRequests.Get(ctx, ref.Owner, ref.Repo, number)
+			if errors.Is(classifyError(ref.Host, err), codehost.ErrNotFound) {
+				return
+			}
+			if err != nil {
+				fail(fmt.Sprintf("pull request #%d", number), err)
+				return
+			}
+			add(pr)
+		}(number)
+	}
+	for _, sha := range unnamed {
+		wg.Add(1)
+		go func(sha string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			prs, _, err := client.PullRequests.ListPullRequestsWithCommit(ctx, ref.Owner, ref.Repo, sha, nil)
+			if err != nil {
+				fail("pull requests of commit "+sha, err)
+				return
+			}
+			for _, pr := range prs {
+				add(pr)
+			}
+		}(sha)
+	}
+	wg.Wait()
+
+	pullRequests := make([]*codehost.ReleasePullRequest, 0, len(byNumber))
+	for _, pr := range byNumber {
+		pullRequests = append(pullRequests, &codehost.ReleasePullRequest{
+			Number:   pr.GetNumber(),
+			Title:    pr.GetTitle(),
+			Body:     pr.GetBody(),
+			Author:   pr.GetUser().GetLogin(),
+			Labels:   convertLabels(pr.Labels),
+			URL:      PullRequestURL(codehost.Ref{Host: ref.Host, Owner: ref.Owner, Repo: ref.Repo, Number: pr.GetNumber()}),
+			MergedAt: pr.GetMergedAt().Time,
+		})
+	}
+	sort.Slice(pullRequests, func(i, j int) bool {
+		if !pullRequests[i].MergedAt.Equal(pullRequests[j].MergedAt) {
+			return pullRequests[i].MergedAt.Before(pullRequests[j].MergedAt)
+		}
+		return pullRequests[i].Number < pullRequests[j].Number
+	})
+	return pullRequests, failed
+}
+
+// getMockRelease returns sample release data
+func getMockRelease(ref CompareRef) *codehost.Release {
+	merged := time.Now().Add(-72 * time.Hour)
+	pullRequest := func(number int, title string, labels ...string) *codehost.ReleasePullRequest {
+		merged = merged.Add(time.Hour)
+		return &codehost.ReleasePullRequest{
+			Number:   number,
+			Title:    title,
+			Author:   "sample-user",
+			Labels:   labels,
+			URL:      PullRequestURL(codehost.Ref{Host: ref.Host, Owner: ref.Owner, Repo: ref.Repo, Number: number}),
+			MergedAt: merged,
+		}
+	}
+	return &codehost.Release{
+		Host:       ref.Host,
+		Repository: ref.Repository(),
+		From:       ref.Base,
+		To:         ref.Head,
+		URL:        CompareURL(ref),
+		HeadSHA:    mockHeadSHA,
+		Commits:    6,
+		PullRequests: []*codehost.ReleasePullRequest{
+			pullRequest(11, "feat(greeting): read the name from the environment"),
+			pullRequest(12, "Crash when NAME is empty", "bug"),
+			pullRequest(13, "feat!: drop the --world flag"),
+			pullRequest(14, "Bump golang.org/x/text from 0.14.0 to 0.15.0", "dependencies"),
+			pullRequest(15, "Document the NAME variable", "documentation"),
+			pullRequest(16, "Tidy up the Makefile"),
+		},
+	}
+}
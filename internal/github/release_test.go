@@ -0,0 +1,22 @@
+package github
+
+import "testing"
+
+func TestCommitPullRequestNumber(t *testing.T) {
+	tests := []struct {
+		message string
+		want    int
+	}{
+		{"Merge pull request #42 from octo/feature\n\nAdd login", 42},
+		{"Add login (#43)", 43},
+		{"Add login (#44)\n\n* first commit\n* second commit (#7)", 44},
+		{"Revert \"Add login (#43)\" (#45)", 45},
+		{"Fix #12 in the parser", 0},
+		{"Add login", 0},
+	}
+	for _, tt := range tests {
+		if got := commitPullRequestNumber(tt.message); got != tt.want {
+			t.Errorf("commitPullRequestNumber(%q) = %d, want %d", tt.message, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-github/v62/github"
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+)
+
+// CreateReview submits a review of the pull request made of the comments,
+// anchored to lines of its diff at commitID (the head the comments were
+// written against), and returns the review's web address. The review only
+// comments: it neither approves nor requests changes.
+func (s *Service) CreateReview(ctx context.Context, ref codehost.Ref, commitID, body string, comments []*codehost.ReviewComment) (string, error) {
+	if err := s.CheckWrite(ctx, ref.Host, ref.Owner); err != nil {
+		return "", err
+	}
+
+	client, err := s.clientFor(ctx, ref.Host, ref.Owner)
+	if err != nil {
+		return "", err
+	}
+
+	draftComments := make([]*github.DraftReviewComment, 0, len(comments))
+	for _, comment := range comments {
+		draftComments = append(draftComments, &github.DraftReviewComment{
+			Path: github.String(comment.Path),
+			Body: github.String(comment.Body),
+			Line: github.Int(comment.Line),
+			Side: github.String(comment.Side),
+		})
+	}
+	request := &github.PullRequestReviewRequest{
+		Body:     github.String(body),
+		Event:    github.String("COMMENT"),
+		Comments: draftComments,
+	}
+	if commitID != "" {
+		request.CommitID = github.String(commitID)
+	}
+
+	review, _, err := client.PullRequests.CreateReview(ctx, ref.Owner, ref.Repo, ref.Number, request)
+	var responseErr *github.ErrorResponse
+	if errors.As(err, &responseErr) && responseErr.Response != nil && responseErr.Response.StatusCode == http.StatusUnprocessableEntity {
+		return "", fmt.Errorf("%w: %s", ErrReviewRejected, rejectionReasons(responseErr))
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to create review on %s: %w", ref, classifyError(ref.Host, err))
+	}
+	return review.GetHTMLURL(), nil
+}
@@ -2,217 +2,390 @@ package github
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/google/go-github/v62/github"
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
 )
 
+// Service reads and writes pull requests on github.com and GitHub Enterprise
+// hosts. It implements codehost.Provider.
 type Service struct {
-	client *github.Client
-}
-
-type PRData struct {
-	Title        string                `json:"title"`
-	Body         string                `json:"body"`
-	User         *github.User          `json:"user"`
-	Assignees    []*github.User        `json:"assignees"`
-	Labels       []*github.Label       `json:"labels"`
-	State        string                `json:"state"`
-	CreatedAt    time.Time             `json:"created_at"`
-	UpdatedAt    time.Time             `json:"updated_at"`
-	ChangedFiles []*github.CommitFile  `json:"changed_files"`
-	Additions    int                   `json:"additions"`
-	Deletions    int                   `json:"deletions"`
-	Repository   string                `json:"repository"`
-	PRNumber     int                   `json:"pr_number"`
-	Contributors []*github.Contributor `json:"contributors"`
+	hosts        map[string]*hostClient // keyed by lowercase host
+	maxListItems int
+	demoMode     bool
+	commitStats  commitStatsCache
 }
 
 func NewService() *Service {
 	githubToken := os.Getenv("GITHUB_TOKEN")
 
-	var client *github.Client
+	base := github.NewClient(nil)
+	client := base
 	if githubToken != "" {
-		client = github.NewClient(nil).WithAuthToken(githubToken)
-	} else {
-		client = github.NewClient(nil)
+		client = base.WithAuthToken(githubToken)
+	}
+
+	// GitHub App credentials take precedence over the token where the app is installed
+	app, err := newAppAuthFromEnv(base)
+	if err != nil {
+		log.Printf("Warning: %v, GitHub App authentication is disabled", err)
+	}
+
+	hosts := map[string]*hostClient{
+		DefaultHost: {
+			host:     DefaultHost,
+			base:     base,
+			client:   client,
+			app:      app,
+			canWrite: githubToken != "",
+		},
+	}
+
+	// GitHub Enterprise Server hosts, each with its own token
+	enterpriseHosts, err := enterpriseHostsFromEnv()
+	if err != nil {
+		log.Printf("Warning: %v, GitHub Enterprise hosts are disabled", err)
+	}
+	for _, enterprise := range enterpriseHosts {
+		h, err := newEnterpriseClient(enterprise)
+		if err != nil {
+			log.Printf("Warning: %v, skipping GitHub Enterprise host %s", err, enterprise.host)
+			continue
+		}
+		if !h.canWrite {
+			log.Printf("Warning: %s not provided, only public repositories on %s can be read", enterpriseTokenVariable(h.host), h.host)
+		}
+		log.Printf("GitHub Enterprise host %s enabled (API %s)", h.host, h.base.BaseURL)
+		hosts[h.host] = h
+	}
+
+	// Demo mode serves sample data instead of calling GitHub
+	demoMode := false
+	if demoModeStr := os.Getenv("GITHUB_DEMO_MODE"); demoModeStr != "" {
+		if parsed, err := strconv.ParseBool(demoModeStr); err == nil {
+			demoMode = parsed
+		} else {
+			log.Printf("Warning: Invalid GITHUB_DEMO_MODE value '%s', defaulting to false", demoModeStr)
+		}
 	}
 
-	return &Service{client: client}
+	if demoMode {
+		log.Println("Warning: GitHub demo mode is ENABLED. All PR data will be sample data.")
+	} else if app != nil {
+		log.Printf("Authenticating to GitHub as app %d, falling back to GITHUB_TOKEN where it is not installed", app.appID)
+	} else if githubToken == "" {
+		log.Println("Warning: GITHUB_TOKEN not provided, only public repositories can be read and rate limits are low")
+	}
+
+	return &Service{
+		hosts:        hosts,
+		maxListItems: codehost.MaxListItemsFromEnv(),
+		demoMode:     demoMode,
+	}
 }
 
-func (s *Service) FetchPRData(owner, repo string, prNumber int) (*PRData, error) {
-	githubToken := os.Getenv("GITHUB_TOKEN")
+// clientFor returns the client to use for a repository owned by owner on
+// host: one authenticated as the signed-in user when ctx carries their token
+// for the host (see WithUserToken), otherwise one authenticated as the GitHub
+// App installation set with WithInstallation or the one on the owner's account
+// when there is one, the host's token (or anonymous) client otherwise
+func (s *Service) clientFor(ctx context.Context, host, owner string) (*github.Client, error) {
+	h, err := s.hostClientFor(host)
+	if err != nil {
+		return nil, err
+	}
 
-	if githubToken == "" {
-		// Fallback to mock data if no token is provided
-		log.Println("Warning: GITHUB_TOKEN not provided, using mock data")
-		return getMockPRData(owner, repo, prNumber), nil
+	if token, ok := userToken(ctx, h.host); ok {
+		if token == "" {
+			return nil, ErrNotConnected
+		}
+		return h.base.WithAuthToken(token), nil
 	}
 
-	ctx := context.Background()
+	if h.app == nil {
+		return h.client, nil
+	}
 
-	// Fetch PR data
-	pr, _, err := s.client.PullRequests.Get(ctx, owner, repo, prNumber)
+	token, err := h.app.installationToken(ctx, owner)
+	if errors.Is(err, errNoInstallation) {
+		return h.client, nil
+	}
 	if err != nil {
-		log.Printf("Error fetching PR data: %v", err)
-		return getMockPRData(owner, repo, prNumber), nil
+		return nil, err
 	}
+	return h.base.WithAuthToken(token.token), nil
+}
 
-	// Fetch additional data
-	labels, _, err := s.client.Issues.ListLabelsByIssue(ctx, owner, repo, prNumber, nil)
+// FetchHeadSHA returns the commit the pull request head currently points to.
+// It costs a single API call, which makes it a cheap freshness check.
+func (s *Service) FetchHeadSHA(ctx context.Context, ref codehost.Ref) (string, error) {
+	if s.demoMode {
+		return mockHeadSHA, nil
+	}
+
+	client, err := s.clientFor(ctx, ref.Host, ref.Owner)
 	if err != nil {
-		log.Printf("Error fetching labels: %v", err)
-		labels = []*github.Label{}
+		return "", err
 	}
 
-	files, _, err := s.client.PullRequests.ListFiles(ctx, owner, repo, prNumber, nil)
+	pr, _, err := client.PullRequests.Get(ctx, ref.Owner, ref.Repo, ref.Number)
 	if err != nil {
-		log.Printf("Error fetching files: %v", err)
-		files = []*github.CommitFile{}
-	}
-
-	// Note: GitHub API doesn't have a direct endpoint for PR contributors
-	// We'll use the PR author and assignees as contributors
-	contributors := []*github.Contributor{}
-	if pr.User != nil {
-		contributors = append(contributors, &github.Contributor{
-			Login:     pr.User.Login,
-			ID:        pr.User.ID,
-			AvatarURL: pr.User.AvatarURL,
-		})
+		return "", fmt.Errorf("failed to fetch pull request %s: %w", ref, classifyError(ref.Host, err))
+	}
+	return pr.GetHead().GetSHA(), nil
+}
+
+// Name returns the product name shown to users
+func (s *Service) Name() string {
+	return "GitHub"
+}
+
+// FetchChangeRequest fetches the pull request with its labels, files, commits
+// and reviews
+func (s *Service) FetchChangeRequest(ctx context.Context, ref codehost.Ref) (*codehost.ChangeRequest, error) {
+	if s.demoMode {
+		return getMockPRData(ref), nil
+	}
+
+	client, err := s.clientFor(ctx, ref.Host, ref.Owner)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch PR data
+	pr, _, err := client.PullRequests.Get(ctx, ref.Owner, ref.Repo, ref.Number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pull request %s: %w", ref, classifyError(ref.Host, err))
 	}
 
-	return &PRData{
-		Title:        pr.GetTitle(),
-		Body:         pr.GetBody(),
-		User:         pr.User,
-		Assignees:    pr.Assignees,
-		Labels:       labels,
-		State:        pr.GetState(),
-		CreatedAt:    pr.GetCreatedAt().Time,
-		UpdatedAt:    pr.GetUpdatedAt().Time,
-		ChangedFiles: files,
-		Additions:    pr.GetAdditions(),
-		Deletions:    pr.GetDeletions(),
-		Repository:   fmt.Sprintf("%s/%s", owner, repo),
-		PRNumber:     prNumber,
-		Contributors: contributors,
+	var truncatedLists []string
+	markTruncated := func(list string, truncated bool, err error) {
+		if err != nil {
+			log.Printf("Error fetching %s: %v", list, classifyError(ref.Host, err))
+		}
+		if truncated || err != nil {
+			truncatedLists = append(truncatedLists, list)
+		}
+	}
+
+	// Fetch additional data, following pagination up to the configured limit
+	labels, truncated, err := listAll(s.maxListItems, func(opts *github.ListOptions) ([]*github.Label, *github.Response, error) {
+		return client.Issues.ListLabelsByIssue(ctx, ref.Owner, ref.Repo, ref.Number, opts)
+	})
+	markTruncated("labels", truncated, err)
+
+	files, truncated, err := listAll(s.maxListItems, func(opts *github.ListOptions) ([]*github.CommitFile, *github.Response, error) {
+		return client.PullRequests.ListFiles(ctx, ref.Owner, ref.Repo, ref.Number, opts)
+	})
+	markTruncated("files", truncated || len(files) < pr.GetChangedFiles(), err)
+
+	repoCommits, truncated, err := listAll(s.maxListItems, func(opts *github.ListOptions) ([]*github.RepositoryCommit, *github.Response, error) {
+		return client.PullRequests.ListCommits(ctx, ref.Owner, ref.Repo, ref.Number, opts)
+	})
+	markTruncated("commits", truncated, err)
+
+	reviews, truncated, err := listAll(s.maxListItems, func(opts *github.ListOptions) ([]*github.PullRequestReview, *github.Response, error) {
+		return client.PullRequests.ListReviews(ctx, ref.Owner, ref.Repo, ref.Number, opts)
+	})
+	markTruncated("reviews", truncated, err)
+
+	// Derive the contributors from the commit authors and Co-authored-by trailers
+	commits := convertCommits(repoCommits)
+	if s.fetchCommitStats(ctx, client, ref.Host, ref.Owner, ref.Repo, commits) {
+		truncatedLists = append(truncatedLists, "commit line counts")
+	}
+	contributors := codehost.BuildContributors(commits)
+	if len(contributors) == 0 && pr.User != nil {
+		contributors = append(contributors, &codehost.Contributor{Login: pr.User.GetLogin()})
+	}
+
+	// Issues mentioned in the body (outside the generated region) and commit messages
+	texts := []string{codehost.StripManagedRegion(pr.GetBody())}
+	for _, commit := range commits {
+		texts = append(texts, commit.Message)
+	}
+	linkedIssues, incomplete := s.fetchLinkedIssues(ctx, client, ref.Host, ref.Repository(), ref.Number, texts...)
+	if incomplete {
+		truncatedLists = append(truncatedLists, "linked issues")
+	}
+
+	// Descriptions follow the repository's template as of the base branch.
+	// Without it generation falls back to the built-in sections, so a failure
+	// is logged rather than returned.
+	template, err := fetchTemplate(ctx, client, ref.Host, ref.Owner, ref.Repo, pr.GetBase().GetRef())
+	if err != nil {
+		log.Printf("Error fetching pull request template of %s: %v", ref.Repository(), err)
+	}
+
+	return &codehost.ChangeRequest{
+		Title:             pr.GetTitle(),
+		Body:              pr.GetBody(),
+		User:              convertUser(pr.User),
+		Assignees:         convertUsers(pr.Assignees),
+		Labels:            convertLabels(labels),
+		State:             pr.GetState(),
+		CreatedAt:         pr.GetCreatedAt().Time,
+		UpdatedAt:         pr.GetUpdatedAt().Time,
+		ChangedFiles:      convertFiles(files),
+		TotalChangedFiles: pr.GetChangedFiles(),
+		Commits:           commits,
+		Reviews:           convertReviews(reviews),
+		Additions:         pr.GetAdditions(),
+		Deletions:         pr.GetDeletions(),
+		Host:              ref.Host,
+		Repository:        ref.Repository(),
+		PRNumber:          ref.Number,
+		URL:               PullRequestURL(ref),
+		Template:          template,
+		LinkedIssues:      linkedIssues,
+		HeadSHA:           pr.GetHead().GetSHA(),
+		Contributors:      contributors,
+		Truncated:         len(truncatedLists) > 0,
+		TruncatedLists:    truncatedLists,
 	}, nil
 }
 
-func getMockPRData(owner, repo string, prNumber int) *PRData {
-	return &PRData{
-		Title: "Sample Pull Request",
-		Body:  "This is a sample pull request description for testing purposes.",
-		User: &github.User{
-			Login:     github.String("sample-user"),
-			ID:        github.Int64(12345),
-			AvatarURL: github.String("https://github.com/images/error/octocat_happy.gif"),
-		},
-		Assignees: []*github.User{
+// mockHeadSHA is the head commit of the sample pull request
+const mockHeadSHA = "8e7d6c5b4a3f2e1d0c9b8a7f6e5d4c3b2a1f0e9d"
+
+func getMockPRData(ref codehost.Ref) *codehost.ChangeRequest {
+	return &codehost.ChangeRequest{
+		Title:     "Sample Pull Request",
+		Body:      "This is a sample pull request description for testing purposes.\n\nFixes #42",
+		User:      &codehost.User{Login: "sample-user", Name: "Sample User"},
+		Assignees: []*codehost.User{{Login: "reviewer1", Name: "Reviewer One"}},
+		Labels:    []string{"enhancement", "documentation"},
+		State:     "open",
+		CreatedAt: time.Now().Add(-24 * time.Hour),
+		UpdatedAt: time.Now(),
+		ChangedFiles: []*codehost.File{
 			{
-				Login:     github.String("reviewer1"),
-				ID:        github.Int64(67890),
-				AvatarURL: github.String("https://github.com/images/error/octocat_happy.gif"),
+				Filename:  "main.go",
+				Status:    "modified",
+				Additions: 10,
+				Deletions: 2,
+				Changes:   12,
+				Patch:     "@@ -1,7 +1,14 @@\n package main\n \n-import \"fmt\"\n+import (\n+\t\"fmt\"\n+\t\"os\"\n+)\n \n func main() {\n-\tfmt.Println(\"hello\")\n+\tname := os.Getenv(\"NAME\")\n+\tif name == \"\" {\n+\t\tname = \"world\"\n+\t}\n+\tfmt.Printf(\"hello %s\\n\", name)\n }",
 			},
-		},
-		Labels: []*github.Label{
 			{
-				Name:  github.String("enhancement"),
-				Color: github.String("a2eeef"),
+				Filename:  "README.md",
+				Status:    "modified",
+				Additions: 5,
+				Deletions: 0,
+				Changes:   5,
+				Patch:     "@@ -3,3 +3,8 @@\n ## Usage\n \n Run `go run .`\n+\n+## Configuration\n+\n+Set `NAME` to change who gets greeted.\n+",
 			},
+		},
+		TotalChangedFiles: 2,
+		Additions:         15,
+		Deletions:         2,
+		Host:              ref.Host,
+		Repository:        ref.Repository(),
+		PRNumber:          ref.Number,
+		URL:               PullRequestURL(ref),
+		HeadSHA:           mockHeadSHA,
+		Commits: []codehost.Commit{
 			{
-				Name:  github.String("documentation"),
-				Color: github.String("0075ca"),
+				SHA:         "3f9c2d1a7b6e5f4d3c2b1a0f9e8d7c6b5a4f3e2d",
+				Message:     "Read the greeting name from the environment",
+				AuthorLogin: "sample-user",
+				AuthorName:  "Sample User",
+				AuthorEmail: "sample-user@example.com",
+				Additions:   10,
+				Deletions:   2,
+				HasStats:    true,
 			},
-		},
-		State:     "open",
-		CreatedAt: time.Now().Add(-24 * time.Hour),
-		UpdatedAt: time.Now(),
-		ChangedFiles: []*github.CommitFile{
 			{
-				Filename:  github.String("main.go"),
-				Additions: github.Int(10),
-				Deletions: github.Int(2),
-				Changes:   github.Int(12),
+				SHA:         "8e7d6c5b4a3f2e1d0c9b8a7f6e5d4c3b2a1f0e9d",
+				Message:     "Document the NAME variable\n\nCo-authored-by: Reviewer One <reviewer1@example.com>",
+				AuthorLogin: "sample-user",
+				AuthorName:  "Sample User",
+				AuthorEmail: "sample-user@example.com",
+				CoAuthors:   []codehost.CoAuthor{{Name: "Reviewer One", Email: "reviewer1@example.com"}},
+				Additions:   5,
+				Deletions:   0,
+				HasStats:    true,
 			},
+		},
+		LinkedIssues: []*codehost.Issue{
 			{
-				Filename:  github.String("README.md"),
-				Additions: github.Int(5),
-				Deletions: github.Int(0),
-				Changes:   github.Int(5),
+				Repository: ref.Repository(),
+				Number:     42,
+				Title:      "Greeting name cannot be configured",
+				Body:       "The greeting always says hello to the world. It should be possible to greet someone else.",
+				Labels:     []string{"enhancement"},
+				State:      "open",
+				URL:        IssueURL(ref.Host, ref.Repository(), 42),
+				Closes:     true,
 			},
 		},
-		Additions:    15,
-		Deletions:    2,
-		Repository:   fmt.Sprintf("%s/%s", owner, repo),
-		PRNumber:     prNumber,
-		Contributors: []*github.Contributor{},
+		Contributors: []*codehost.Contributor{
+			{Login: "sample-user", Name: "Sample User", Email: "sample-user@example.com", Commits: 2, Additions: 15, Deletions: 2},
+			{Name: "Reviewer One", Email: "reviewer1@example.com", Commits: 1, Additions: 5, Deletions: 0},
+		},
 	}
 }
 
-// ParseGitHubURL extracts owner, repo, and PR number from a GitHub PR URL
-func (s *Service) ParseGitHubURL(url string) (string, string, int, error) {
-	// Expected format: https://github.com/owner/repo/pull/123
-	parts := strings.Split(url, "/")
-	if len(parts) < 7 || parts[2] != "github.com" || parts[5] != "pull" {
-		return "", "", 0, fmt.Errorf("invalid GitHub PR URL format")
+// convertUser maps a GitHub user to a codehost.User
+func convertUser(user *github.User) *codehost.User {
+	if user == nil {
+		return nil
 	}
-
-	owner := parts[3]
-	repo := parts[4]
-	prNumberStr := parts[6]
-
-	// Remove any query parameters or fragments
-	prNumberStr = strings.Split(prNumberStr, "?")[0]
-	prNumberStr = strings.Split(prNumberStr, "#")[0]
-
-	prNumber, err := strconv.Atoi(prNumberStr)
-	if err != nil {
-		return "", "", 0, fmt.Errorf("invalid PR number: %s", prNumberStr)
-	}
-
-	return owner, repo, prNumber, nil
+	return &codehost.User{Login: user.GetLogin(), Name: user.GetName()}
 }
 
-// Helper functions for formatting GitHub data
-func GetLabelsString(labels []*github.Label) string {
-	if len(labels) == 0 {
-		return "none"
+// convertUsers maps GitHub users to codehost.User values
+func convertUsers(users []*github.User) []*codehost.User {
+	converted := make([]*codehost.User, 0, len(users))
+	for _, user := range users {
+		converted = append(converted, convertUser(user))
 	}
+	return converted
+}
 
-	var labelNames []string
+// convertLabels returns the names of the labels
+func convertLabels(labels []*github.Label) []string {
+	names := make([]string, 0, len(labels))
 	for _, label := range labels {
 		if label.Name != nil {
-			labelNames = append(labelNames, *label.Name)
+			names = append(names, label.GetName())
 		}
 	}
-	return strings.Join(labelNames, ", ")
+	return names
 }
 
-func GetUserString(user *github.User) string {
-	if user == nil || user.Login == nil {
-		return "unknown"
+// convertFiles maps the GitHub pull request files to codehost.File values
+func convertFiles(files []*github.CommitFile) []*codehost.File {
+	converted := make([]*codehost.File, 0, len(files))
+	for _, file := range files {
+		converted = append(converted, &codehost.File{
+			Filename:         file.GetFilename(),
+			PreviousFilename: file.GetPreviousFilename(),
+			Status:           file.GetStatus(),
+			Additions:        file.GetAdditions(),
+			Deletions:        file.GetDeletions(),
+			Changes:          file.GetChanges(),
+			Patch:            file.GetPatch(),
+		})
 	}
-	return *user.Login
+	return converted
 }
 
-func GetAssigneesString(assignees []*github.User) string {
-	if len(assignees) == 0 {
-		return "none"
-	}
-
-	var assigneeNames []string
-	for _, assignee := range assignees {
-		if assignee.Login != nil {
-			assigneeNames = append(assigneeNames, *assignee.Login)
-		}
+// convertReviews maps the GitHub pull request reviews to codehost.Review values
+func convertReviews(reviews []*github.PullRequestReview) []*codehost.Review {
+	converted := make([]*codehost.Review, 0, len(reviews))
+	for _, review := range reviews {
+		converted = append(converted, &codehost.Review{
+			User:        review.GetUser().GetLogin(),
+			State:       review.GetState(),
+			SubmittedAt: review.GetSubmittedAt().Time,
+		})
 	}
-	return strings.Join(assigneeNames, ", ")
+	return converted
 }
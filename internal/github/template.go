@@ -0,0 +1,113 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/google/go-github/v62/github"
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+)
+
+// templateLocations are the directories GitHub looks for a pull request
+// template in, in order of precedence: .github, the repository root and docs
+var templateLocations = []string{".github", "", "docs"}
+
+// templateName is the name of a pull request template file without its
+// extension, and of the directory holding several templates. GitHub matches
+// both case-insensitively.
+const templateName = "pull_request_template"
+
+// isTemplateFile reports whether a file name is a single pull request template
+func isTemplateFile(name string) bool {
+	name = strings.ToLower(name)
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) == templateName && (ext == "" || ext == ".md" || ext == ".txt")
+}
+
+// findTemplate looks through the entries of one template location for a
+// template file, and failing that for a directory of templates
+func findTemplate(entries []*github.RepositoryContent) (file, dir string) {
+	for _, entry := range entries {
+		switch {
+		case entry.GetType() == "file" && isTemplateFile(entry.GetName()):
+			return entry.GetPath(), ""
+		case entry.GetType() == "dir" && strings.EqualFold(entry.GetName(), templateName) && dir == "":
+			dir = entry.GetPath()
+		}
+	}
+	return "", dir
+}
+
+// pickTemplate chooses among the templates in a PULL_REQUEST_TEMPLATE
+// directory. Authors pick one with ?template= when opening a pull request,
+// which is not recorded, so a template named "default" wins, then the first
+// Markdown file by name.
+func pickTemplate(entries []*github.RepositoryContent) string {
+	var first string
+	for _, entry := range entries {
+		name := strings.ToLower(entry.GetName())
+		if entry.GetType() != "file" || path.Ext(name) != ".md" {
+			continue
+		}
+		if strings.TrimSuffix(name, ".md") == "default" {
+			return entry.GetPath()
+		}
+		if first == "" || name < strings.ToLower(path.Base(first)) {
+			first = entry.GetPath()
+		}
+	}
+	return first
+}
+
+// fetchTemplate returns the pull request template of the repository at ref
+// (the base branch of the pull request), or "" when it has none
+func fetchTemplate(ctx context.Context, client *github.Client, host, owner, repo, ref string) (string, error) {
+	opts := &github.RepositoryContentGetOptions{Ref: ref}
+	listDirectory := func(dir string) ([]*github.RepositoryContent, error) {
+		_, entries, _, err := client.Repositories.GetContents(ctx, owner, repo, dir, opts)
+		if err := classifyError(host, err); err != nil && !errors.Is(err, codehost.ErrNotFound) {
+			return nil, fmt.Errorf("failed to list %q: %w", dir, err)
+		}
+		return entries, nil
+	}
+
+	// A single template file anywhere takes precedence over a directory of templates
+	var templateDirs []string
+	var file string
+	for _, location := range templateLocations {
+		entries, err := listDirectory(location)
+		if err != nil {
+			return "", err
+		}
+		var dir string
+		if file, dir = findTemplate(entries); file != "" {
+			break
+		}
+		if dir != "" {
+			templateDirs = append(templateDirs, dir)
+		}
+	}
+	for i := 0; file == "" && i < len(templateDirs); i++ {
+		entries, err := listDirectory(templateDirs[i])
+		if err != nil {
+			return "", err
+		}
+		file = pickTemplate(entries)
+	}
+	if file == "" {
+		return "", nil
+	}
+
+	content, _, _, err := client.Repositories.GetContents(ctx, owner, repo, file, opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch %s: %w", file, classifyError(host, err))
+	}
+	template, err := content.GetContent()
+	if err != nil {
+		return "", fmt.Errorf("failed to decode %s: %w", file, err)
+	}
+	return strings.TrimSpace(strings.ReplaceAll(template, "\r\n", "\n")), nil
+}
@@ -0,0 +1,53 @@
+package github
+
+import (
+	"path"
+	"testing"
+
+	"github.com/google/go-github/v62/github"
+)
+
+func entry(kind, filePath string) *github.RepositoryContent {
+	return &github.RepositoryContent{Type: github.String(kind), Name: github.String(path.Base(filePath)), Path: github.String(filePath)}
+}
+
+func TestFindTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		entries  []*github.RepositoryContent
+		wantFile string
+		wantDir  string
+	}{
+		{"markdown file", []*github.RepositoryContent{entry("file", ".github/CODEOWNERS"), entry("file", ".github/pull_request_template.md")}, ".github/pull_request_template.md", ""},
+		{"upper case", []*github.RepositoryContent{entry("file", "PULL_REQUEST_TEMPLATE.md")}, "PULL_REQUEST_TEMPLATE.md", ""},
+		{"no extension", []*github.RepositoryContent{entry("file", "docs/pull_request_template")}, "docs/pull_request_template", ""},
+		{"directory", []*github.RepositoryContent{entry("dir", ".github/PULL_REQUEST_TEMPLATE"), entry("dir", ".github/workflows")}, "", ".github/PULL_REQUEST_TEMPLATE"},
+		{"file wins over directory", []*github.RepositoryContent{entry("dir", ".github/PULL_REQUEST_TEMPLATE"), entry("file", ".github/pull_request_template.md")}, ".github/pull_request_template.md", ""},
+		{"other extension", []*github.RepositoryContent{entry("file", "pull_request_template.yml")}, "", ""},
+		{"none", nil, "", ""},
+	}
+	for _, tt := range tests {
+		file, dir := findTemplate(tt.entries)
+		if file != tt.wantFile || dir != tt.wantDir {
+			t.Errorf("%s: findTemplate() = %q, %q, want %q, %q", tt.name, file, dir, tt.wantFile, tt.wantDir)
+		}
+	}
+}
+
+func TestPickTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []*github.RepositoryContent
+		want    string
+	}{
+		{"default", []*github.RepositoryContent{entry("file", "T/bugfix.md"), entry("file", "T/default.md")}, "T/default.md"},
+		{"first by name", []*github.RepositoryContent{entry("file", "T/release.md"), entry("file", "T/Bugfix.md"), entry("file", "T/feature.md")}, "T/Bugfix.md"},
+		{"markdown only", []*github.RepositoryContent{entry("file", "T/config.yml"), entry("dir", "T/old.md"), entry("file", "T/feature.md")}, "T/feature.md"},
+		{"empty", nil, ""},
+	}
+	for _, tt := range tests {
+		if got := pickTemplate(tt.entries); got != tt.want {
+			t.Errorf("%s: pickTemplate() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
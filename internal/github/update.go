@@ -0,0 +1,150 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/go-github/v62/github"
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+)
+
+// CanWrite reports whether write calls to owner's repositories can be
+// attempted under ctx, either with the signed-in user's token or the server's
+// credentials. Whether a token actually has write scope is only known once
+// GitHub answers.
+func (s *Service) CanWrite(ctx context.Context, host, owner string) bool {
+	return s.CheckWrite(ctx, host, owner) == nil
+}
+
+// CheckWrite returns codehost.ErrReadOnly when no credentials able to write to
+// owner's repositories on host are configured, and ErrNotConnected when the
+// signed-in user must connect their GitHub account first. Where the GitHub App
+// is installed, its installation is used for writes, so the installation must
+// have been granted write access to pull requests.
+func (s *Service) CheckWrite(ctx context.Context, host, owner string) error {
+	if s.demoMode {
+		return codehost.ErrReadOnly
+	}
+	h, err := s.hostClientFor(host)
+	if err != nil {
+		return err
+	}
+	if token, ok := userToken(ctx, h.host); ok {
+		if token == "" {
+			return ErrNotConnected
+		}
+		return nil
+	}
+	if h.app != nil {
+		token, err := h.app.installationToken(ctx, owner)
+		switch {
+		case err == nil && !token.canWrite:
+			return codehost.ErrReadOnly
+		case err == nil:
+			return nil
+		case !errors.Is(err, errNoInstallation):
+			return err
+		}
+	}
+	if !h.canWrite {
+		return codehost.ErrReadOnly
+	}
+	return nil
+}
+
+// CheckRepositoryWrite is CheckWrite for one repository. Under the signed-in
+// user's token, GitHub is also asked whether the user can push to it, and
+// codehost.ErrForbidden is returned when they cannot.
+func (s *Service) CheckRepositoryWrite(ctx context.Context, host, owner, repo string) error {
+	if err := s.CheckWrite(ctx, host, owner); err != nil {
+		return err
+	}
+	if _, ok := userToken(ctx, normalizeHost(host)); !ok {
+		return nil
+	}
+
+	client, err := s.clientFor(ctx, host, owner)
+	if err != nil {
+		return err
+	}
+	repository, _, err := client.Repositories.Get(ctx, owner, repo)
+	if err != nil {
+		return fmt.Errorf("failed to fetch repository %s/%s: %w", owner, repo, classifyError(host, err))
+	}
+	if permissions := repository.GetPermissions(); !permissions["push"] && !permissions["admin"] {
+		return fmt.Errorf("%w: no push access to %s/%s", codehost.ErrForbidden, owner, repo)
+	}
+	return nil
+}
+
+// FetchBody returns the current description of the pull request
+func (s *Service) FetchBody(ctx context.Context, ref codehost.Ref) (*codehost.Body, error) {
+	if s.demoMode {
+		mock := getMockPRData(ref)
+		return &codehost.Body{Body: mock.Body, UpdatedAt: mock.UpdatedAt}, nil
+	}
+
+	client, err := s.clientFor(ctx, ref.Host, ref.Owner)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, _, err := client.PullRequests.Get(ctx, ref.Owner, ref.Repo, ref.Number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pull request %s: %w", ref, classifyError(ref.Host, err))
+	}
+	return &codehost.Body{Body: pr.GetBody(), UpdatedAt: pr.GetUpdatedAt().Time}, nil
+}
+
+// UpdateBody replaces the description of the pull request. When current is
+// set and the pull request no longer matches it, nothing is written and
+// codehost.ErrConflict is returned; see codehost.Provider. It returns
+// codehost.ErrReadOnly when no token is configured, or ErrNotConnected when
+// the signed-in user must connect their GitHub account first.
+func (s *Service) UpdateBody(ctx context.Context, ref codehost.Ref, body string, current *codehost.Body) (*codehost.Body, error) {
+	if err := s.CheckWrite(ctx, ref.Host, ref.Owner); err != nil {
+		return nil, err
+	}
+
+	client, err := s.clientFor(ctx, ref.Host, ref.Owner)
+	if err != nil {
+		return nil, err
+	}
+
+	if current != nil {
+		latest, err := s.FetchBody(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+		if err := current.CheckUnchanged(ref, latest); err != nil {
+			return nil, err
+		}
+	}
+
+	pr, _, err := client.PullRequests.Edit(ctx, ref.Owner, ref.Repo, ref.Number, &github.PullRequest{Body: github.String(body)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update pull request %s: %w", ref, classifyError(ref.Host, err))
+	}
+	return &codehost.Body{Body: pr.GetBody(), UpdatedAt: pr.GetUpdatedAt().Time}, nil
+}
+
+// CreateComment posts a comment on the pull request. It returns
+// codehost.ErrReadOnly when no token is configured, or ErrNotConnected as
+// UpdateBody does.
+func (s *Service) CreateComment(ctx context.Context, ref codehost.Ref, body string) error {
+	if err := s.CheckWrite(ctx, ref.Host, ref.Owner); err != nil {
+		return err
+	}
+
+	client, err := s.clientFor(ctx, ref.Host, ref.Owner)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = client.Issues.CreateComment(ctx, ref.Owner, ref.Repo, ref.Number, &github.IssueComment{Body: github.String(body)})
+	if err != nil {
+		return fmt.Errorf("failed to comment on pull request %s: %w", ref, classifyError(ref.Host, err))
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package github
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNotConnected means the signed-in user must connect their GitHub account
+// before GitHub can be called on their behalf
+var ErrNotConnected = errors.New("GitHub account is not connected")
+
+type userTokenKey struct{}
+
+// userTokens are a user's access tokens by the host that issued them
+type userTokens struct {
+	tokens   map[string]string
+	required bool // hosts without a token fail with ErrNotConnected
+}
+
+// WithUserToken returns a context under which the service calls host as the
+// user owning token (an OAuth access token) instead of using the server's
+// credentials. Tokens set earlier for other hosts are kept, and hosts without
+// a token are unaffected. An empty token marks a user who has not connected an
+// account: calls to host made under the returned context fail with
+// ErrNotConnected.
+func WithUserToken(ctx context.Context, host, token string) context.Context {
+	current, _ := ctx.Value(userTokenKey{}).(userTokens)
+	next := userTokens{tokens: make(map[string]string, len(current.tokens)+1), required: current.required}
+	for h, t := range current.tokens {
+		next.tokens[h] = t
+	}
+	next.tokens[normalizeHost(host)] = token
+	return context.WithValue(ctx, userTokenKey{}, next)
+}
+
+// RequireUserTokens returns a context under which calls to hosts without a
+// token set by WithUserToken fail with ErrNotConnected instead of using the
+// server's credentials
+func RequireUserTokens(ctx context.Context) context.Context {
+	current, _ := ctx.Value(userTokenKey{}).(userTokens)
+	return context.WithValue(ctx, userTokenKey{}, userTokens{tokens: current.tokens, required: true})
+}
+
+// userToken returns the token set by WithUserToken for host, if any. Under
+// RequireUserTokens, hosts without one get an empty token.
+func userToken(ctx context.Context, host string) (string, bool) {
+	t, ok := ctx.Value(userTokenKey{}).(userTokens)
+	if !ok {
+		return "", false
+	}
+	if token, ok := t.tokens[host]; ok {
+		return token, true
+	}
+	return "", t.required
+}
@@ -0,0 +1,34 @@
+package github
+
+import (
+	"context"
+	"testing"
+)
+
+func TestUserToken(t *testing.T) {
+	ctx := WithUserToken(context.Background(), "github.com", "public-token")
+	ctx = WithUserToken(ctx, "GHE.Example.com", "enterprise-token")
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		host      string
+		wantToken string
+		wantOK    bool
+	}{
+		{"no tokens", context.Background(), "github.com", "", false},
+		{"github.com", ctx, "github.com", "public-token", true},
+		{"enterprise host", ctx, "ghe.example.com", "enterprise-token", true},
+		{"other host", ctx, "other.example.com", "", false},
+		{"other host when required", RequireUserTokens(ctx), "other.example.com", "", true},
+		{"connected host when required", RequireUserTokens(ctx), "ghe.example.com", "enterprise-token", true},
+		{"not connected", WithUserToken(ctx, "github.com", ""), "github.com", "", true},
+	}
+
+	for _, tt := range tests {
+		token, ok := userToken(tt.ctx, tt.host)
+		if token != tt.wantToken || ok != tt.wantOK {
+			t.Errorf("%s: userToken(%q) = %q, %v, want %q, %v", tt.name, tt.host, token, ok, tt.wantToken, tt.wantOK)
+		}
+	}
+}
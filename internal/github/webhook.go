@@ -0,0 +1,84 @@
+package github
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/google/go-github/v62/github"
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+)
+
+// ErrInvalidSignature means a webhook payload does not match its X-Hub-Signature-256 header
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// PullRequestEvent is the part of a pull_request webhook delivery the toolbox acts on
+type PullRequestEvent struct {
+	Action         string
+	Host           string // github.com or the GitHub Enterprise host that sent the event
+	Owner          string
+	Repo           string
+	PRNumber       int
+	Draft          bool
+	HeadSHA        string
+	InstallationID int64 // set when the webhook comes from a GitHub App installation
+}
+
+// Ref returns the pull request the event is about
+func (e *PullRequestEvent) Ref() codehost.Ref {
+	return codehost.Ref{Host: e.Host, Owner: e.Owner, Repo: e.Repo, Number: e.PRNumber}
+}
+
+// ValidateWebhookSignature checks the X-Hub-Signature-256 header ("sha256=<hex HMAC>")
+// against the HMAC-SHA256 of the payload keyed with the webhook secret
+func ValidateWebhookSignature(payload []byte, signature string, secret []byte) error {
+	digest, found := strings.CutPrefix(signature, "sha256=")
+	if !found {
+		return fmt.Errorf("%w: missing sha256 signature", ErrInvalidSignature)
+	}
+
+	received, err := hex.DecodeString(digest)
+	if err != nil {
+		return fmt.Errorf("%w: malformed signature", ErrInvalidSignature)
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	if !hmac.Equal(received, mac.Sum(nil)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// ParsePullRequestEvent decodes a pull_request webhook payload
+func ParsePullRequestEvent(payload []byte) (*PullRequestEvent, error) {
+	parsed, err := github.ParseWebHook("pull_request", payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse pull_request event: %w", err)
+	}
+
+	event := parsed.(*github.PullRequestEvent)
+	if event.Repo == nil || event.PullRequest == nil {
+		return nil, fmt.Errorf("pull_request event without repository or pull request")
+	}
+
+	host := DefaultHost
+	if repoURL, err := url.Parse(event.GetRepo().GetHTMLURL()); err == nil && repoURL.Host != "" {
+		host = strings.ToLower(repoURL.Host)
+	}
+
+	return &PullRequestEvent{
+		Action:         event.GetAction(),
+		Host:           host,
+		Owner:          event.GetRepo().GetOwner().GetLogin(),
+		Repo:           event.GetRepo().GetName(),
+		PRNumber:       event.GetNumber(),
+		Draft:          event.GetPullRequest().GetDraft(),
+		HeadSHA:        event.GetPullRequest().GetHead().GetSHA(),
+		InstallationID: event.GetInstallation().GetID(),
+	}, nil
+}
@@ -0,0 +1,68 @@
+package github
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func TestValidateWebhookSignature(t *testing.T) {
+	secret := []byte("It's a Secret to Everybody")
+	payload := []byte("Hello, World!")
+	// The example from GitHub's documentation on validating webhook deliveries
+	valid := "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17"
+
+	mac := hmac.New(sha256.New, []byte("another secret"))
+	mac.Write(payload)
+	otherSecret := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	tests := []struct {
+		name      string
+		payload   []byte
+		signature string
+		wantErr   bool
+	}{
+		{"valid", payload, valid, false},
+		{"tampered payload", []byte("Hello, World?"), valid, true},
+		{"other secret", payload, otherSecret, true},
+		{"missing", payload, "", true},
+		{"sha1 only", payload, "sha1=01dc10d0c83e72ed246219cdd91669667fe2ca59", true},
+		{"not hex", payload, "sha256=not-hex", true},
+		{"truncated", payload, valid[:len(valid)-2], true},
+	}
+
+	for _, tt := range tests {
+		err := ValidateWebhookSignature(tt.payload, tt.signature, secret)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: ValidateWebhookSignature() error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%s: ValidateWebhookSignature() error = %v, want ErrInvalidSignature", tt.name, err)
+		}
+	}
+}
+
+func TestParsePullRequestEvent(t *testing.T) {
+	payload := []byte(`{
+		"action": "ready_for_review",
+		"number": 42,
+		"pull_request": {"draft": false, "head": {"sha": "abc123"}},
+		"repository": {"name": "service", "html_url": "https://GHE.example.com/team/service", "owner": {"login": "team"}},
+		"installation": {"id": 99}
+	}`)
+
+	event, err := ParsePullRequestEvent(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := PullRequestEvent{Action: "ready_for_review", Host: "ghe.example.com", Owner: "team", Repo: "service", PRNumber: 42, HeadSHA: "abc123", InstallationID: 99}
+	if *event != want {
+		t.Errorf("ParsePullRequestEvent() = %+v, want %+v", *event, want)
+	}
+
+	if _, err := ParsePullRequestEvent([]byte(`{"action": "opened"}`)); err == nil {
+		t.Error("ParsePullRequestEvent() without a pull request succeeded, want an error")
+	}
+}
@@ -0,0 +1,175 @@
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+)
+
+// perPage is the largest page size the GitLab REST API accepts
+const perPage = 100
+
+// hostClient talks to the REST API of one GitLab host (gitlab.com or a
+// self-managed instance). Its token never leaves the host it is configured for.
+type hostClient struct {
+	host       string
+	apiURL     string // API root ending in /api/v4
+	token      string // empty for anonymous access to public projects
+	httpClient *http.Client
+}
+
+// apiError is the error body GitLab returns, which uses either field
+type apiError struct {
+	Message any    `json:"message"`
+	Error   string `json:"error"`
+}
+
+// projectPath returns the API path of a project, which GitLab identifies by
+// its URL-encoded full path ("group%2Fsubgroup%2Fproject")
+func projectPath(ref codehost.Ref) string {
+	return "/projects/" + url.PathEscape(ref.Repository())
+}
+
+// mergeRequestPath returns the API path of the merge request named by ref
+func mergeRequestPath(ref codehost.Ref) string {
+	return fmt.Sprintf("%s/merge_requests/%d", projectPath(ref), ref.Number)
+}
+
+// do sends a request to the API and decodes the JSON response into out, which
+// may be nil. Failures are mapped onto the codehost error taxonomy.
+func (c *hostClient) do(ctx context.Context, method, path string, query url.Values, body, out any) (*http.Response, error) {
+	endpoint := c.apiURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("PRIVATE-TOKEN", c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, c.classifyError(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return resp, c.responseError(resp)
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp, fmt.Errorf("failed to decode GitLab response: %w", err)
+		}
+	}
+	return resp, nil
+}
+
+// responseError maps an unsuccessful response onto the codehost error taxonomy
+func (c *hostClient) responseError(resp *http.Response) error {
+	var apiErr apiError
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	message := strings.TrimSpace(string(data))
+	if json.Unmarshal(data, &apiErr) == nil {
+		switch {
+		case apiErr.Message != nil:
+			message = fmt.Sprint(apiErr.Message)
+		case apiErr.Error != "":
+			message = apiErr.Error
+		}
+	}
+	err := fmt.Errorf("GitLab API returned %s: %s", resp.Status, message)
+
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return fmt.Errorf("%w: %v", codehost.ErrNotFound, err)
+	case http.StatusUnauthorized:
+		return fmt.Errorf("%w: %v", codehost.ErrUnauthorized, err)
+	case http.StatusForbidden:
+		return fmt.Errorf("%w: %v", codehost.ErrForbidden, err)
+	case http.StatusTooManyRequests:
+		return &codehost.RateLimitError{Host: c.host, Reset: rateLimitReset(resp.Header), Err: err}
+	}
+	return err
+}
+
+// rateLimitReset reads when the rate limit resets from the RateLimit-Reset
+// (Unix time) or Retry-After (seconds) header, assuming a minute without either
+func rateLimitReset(header http.Header) time.Time {
+	if reset, err := strconv.ParseInt(header.Get("RateLimit-Reset"), 10, 64); err == nil {
+		return time.Unix(reset, 0)
+	}
+	if seconds, err := strconv.Atoi(header.Get("Retry-After")); err == nil {
+		return time.Now().Add(time.Duration(seconds) * time.Second)
+	}
+	return time.Now().Add(time.Minute)
+}
+
+// classifyError maps a transport error onto the codehost error taxonomy
+func (c *hostClient) classifyError(err error) error {
+	// Cancellation comes from our own caller, not from GitLab
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	var urlErr *url.Error
+	var netErr net.Error
+	if errors.As(err, &urlErr) || errors.As(err, &netErr) {
+		return &codehost.NetworkError{Host: c.host, Err: err}
+	}
+	return err
+}
+
+// listAll follows the pagination of a GitLab list endpoint until every page has
+// been fetched or limit items have been collected. The boolean result reports
+// whether items were left out because of the limit.
+func listAll[T any](ctx context.Context, c *hostClient, path string, limit int) ([]T, bool, error) {
+	query := url.Values{"per_page": {strconv.Itoa(perPage)}}
+	var all []T
+
+	for {
+		var page []T
+		resp, err := c.do(ctx, http.MethodGet, path, query, nil, &page)
+		if err != nil {
+			return all, false, err
+		}
+		all = append(all, page...)
+
+		next := resp.Header.Get("X-Next-Page")
+		if len(all) >= limit {
+			truncated := len(all) > limit || next != ""
+			return all[:limit], truncated, nil
+		}
+		if next == "" {
+			return all, false, nil
+		}
+		query.Set("page", next)
+	}
+}
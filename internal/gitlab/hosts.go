@@ -0,0 +1,97 @@
+package gitlab
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+)
+
+// DefaultHost is the host of merge requests on gitlab.com
+const DefaultHost = "gitlab.com"
+
+// selfManagedHost is a GitLab instance listed in GITLAB_HOSTS
+type selfManagedHost struct {
+	host   string // as it appears in merge request URLs
+	apiURL string // API root, /api/v4 is appended when missing
+}
+
+// selfManagedHostsFromEnv parses GITLAB_HOSTS, a comma-separated list of hosts,
+// each optionally followed by "=<API URL>" when the API is not served from
+// https://<host>/api/v4
+func selfManagedHostsFromEnv() ([]selfManagedHost, error) {
+	var hosts []selfManagedHost
+	for _, entry := range strings.Split(os.Getenv("GITLAB_HOSTS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		host, apiURL, _ := strings.Cut(entry, "=")
+		host = strings.ToLower(strings.TrimSpace(host))
+		if host == "" || strings.ContainsAny(host, "/ ") {
+			return nil, fmt.Errorf("invalid GITLAB_HOSTS entry %q", entry)
+		}
+		if host == DefaultHost {
+			return nil, fmt.Errorf("GITLAB_HOSTS must not list %s", DefaultHost)
+		}
+
+		apiURL = strings.TrimSpace(apiURL)
+		if apiURL == "" {
+			apiURL = "https://" + host
+		}
+		hosts = append(hosts, selfManagedHost{host: host, apiURL: apiURL})
+	}
+	return hosts, nil
+}
+
+// newHostClient creates the client for a GitLab host
+func newHostClient(host, apiURL, token string) (*hostClient, error) {
+	u, err := url.Parse(apiURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, fmt.Errorf("invalid API URL for %s: %q", host, apiURL)
+	}
+	apiURL = strings.TrimRight(apiURL, "/")
+	if !strings.HasSuffix(apiURL, "/api/v4") {
+		apiURL += "/api/v4"
+	}
+
+	return &hostClient{
+		host:       host,
+		apiURL:     apiURL,
+		token:      token,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// selfManagedTokenVariable names the environment variable holding the token
+// for host, e.g. GITLAB_TOKEN_GITLAB_EXAMPLE_COM
+func selfManagedTokenVariable(host string) string {
+	return codehost.HostVariable("GITLAB_TOKEN_", host)
+}
+
+// hostClientFor returns the client for host, or codehost.ErrUnknownHost
+func (s *Service) hostClientFor(host string) (*hostClient, error) {
+	h, ok := s.hosts[strings.ToLower(host)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", codehost.ErrUnknownHost, host)
+	}
+	return h, nil
+}
+
+// Hosts returns the configured hosts, gitlab.com first
+func (s *Service) Hosts() []string {
+	var selfManaged []string
+	for host := range s.hosts {
+		if host != DefaultHost {
+			selfManaged = append(selfManaged, host)
+		}
+	}
+	slices.Sort(selfManaged)
+	return append([]string{DefaultHost}, selfManaged...)
+}
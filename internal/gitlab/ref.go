@@ -0,0 +1,127 @@
+package gitlab
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+)
+
+var (
+	// namespacePattern matches a GitLab group, subgroup or user path
+	namespacePattern = regexp.MustCompile(`^[A-Za-z0-9_.][A-Za-z0-9_.-]*$`)
+
+	// shorthandPattern matches "[host/]group[/subgroup...]/project!123"
+	shorthandPattern = regexp.MustCompile(`^([^\s!#]+)!(\d+)$`)
+)
+
+// ParseMRRef parses a reference to a merge request. It accepts:
+//
+//   - URLs, with or without scheme, and with any sub-path, query or fragment:
+//     https://gitlab.com/group/subgroup/project/-/merge_requests/123/diffs
+//   - the shorthand group/project!123, or host/group/project!123 for other
+//     hosts, where a first segment containing a dot is taken as the host
+//
+// The host is not checked against the configured hosts; see codehost.Registry.
+func ParseMRRef(input string) (codehost.Ref, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return codehost.Ref{}, fmt.Errorf("merge request reference is empty")
+	}
+	if len(strings.Fields(input)) > 1 {
+		return codehost.Ref{}, fmt.Errorf("unrecognized merge request reference %q", input)
+	}
+
+	if match := shorthandPattern.FindStringSubmatch(input); match != nil {
+		segments := strings.Split(strings.Trim(match[1], "/"), "/")
+		host := DefaultHost
+		if strings.Contains(segments[0], ".") && len(segments) > 2 {
+			host, segments = segments[0], segments[1:]
+		}
+		return newMRRef(host, segments, match[2])
+	}
+
+	return parseMRURL(input)
+}
+
+// parseMRURL parses a merge request URL, adding https:// when the scheme is missing
+func parseMRURL(input string) (codehost.Ref, error) {
+	if !strings.Contains(input, "://") {
+		input = "https://" + input
+	}
+
+	u, err := url.Parse(input)
+	if err != nil {
+		return codehost.Ref{}, fmt.Errorf("invalid merge request URL: %w", err)
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return codehost.Ref{}, fmt.Errorf("unsupported URL scheme %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return codehost.Ref{}, fmt.Errorf("merge request URL has no host")
+	}
+
+	// group/.../project/-/merge_requests/123, followed by anything (diffs,
+	// commits, ...). Old URLs lack the "-" separator.
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i, segment := range segments {
+		if segment != "merge_requests" || i+1 >= len(segments) {
+			continue
+		}
+		project := segments[:i]
+		if len(project) > 0 && project[len(project)-1] == "-" {
+			project = project[:len(project)-1]
+		}
+		return newMRRef(u.Host, project, segments[i+1])
+	}
+	return codehost.Ref{}, fmt.Errorf("expected a merge request URL like https://%s/group/project/-/merge_requests/123", u.Host)
+}
+
+// newMRRef validates and normalizes the parts of a reference. The last path
+// segment is the project, everything before it the namespace. Hosts are
+// lowercased and lose a leading "www.".
+func newMRRef(host string, path []string, number string) (codehost.Ref, error) {
+	host = strings.TrimPrefix(strings.ToLower(host), "www.")
+	if host == "" {
+		return codehost.Ref{}, fmt.Errorf("merge request reference has no host")
+	}
+	if len(path) < 2 {
+		return codehost.Ref{}, fmt.Errorf("merge request reference needs a group and a project")
+	}
+	for _, segment := range path {
+		if !namespacePattern.MatchString(segment) || segment == "." || segment == ".." {
+			return codehost.Ref{}, fmt.Errorf("invalid project path %q", strings.Join(path, "/"))
+		}
+	}
+
+	mrNumber, err := strconv.Atoi(number)
+	if err != nil || mrNumber <= 0 {
+		return codehost.Ref{}, fmt.Errorf("invalid merge request number: %s", number)
+	}
+
+	project := strings.TrimSuffix(path[len(path)-1], ".git")
+	return codehost.Ref{
+		Host:   host,
+		Owner:  strings.Join(path[:len(path)-1], "/"),
+		Repo:   project,
+		Number: mrNumber,
+	}, nil
+}
+
+// MergeRequestURL returns the web address of a merge request
+func MergeRequestURL(ref codehost.Ref) string {
+	return fmt.Sprintf("https://%s/%s/-/merge_requests/%d", ref.Host, ref.Repository(), ref.Number)
+}
+
+// ParseRef parses a reference to a merge request, see ParseMRRef
+func (s *Service) ParseRef(input string) (codehost.Ref, error) {
+	return ParseMRRef(input)
+}
+
+// URL returns the web address of the merge request
+func (s *Service) URL(ref codehost.Ref) string {
+	return MergeRequestURL(ref)
+}
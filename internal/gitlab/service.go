@@ -0,0 +1,375 @@
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+)
+
+const (
+	// maxCommitStats caps how many commits get their line counts fetched individually
+	maxCommitStats = 100
+
+	// commitStatsConcurrency limits parallel requests for commit line counts
+	commitStatsConcurrency = 8
+)
+
+// Service reads and writes merge requests on gitlab.com and self-managed
+// GitLab instances. It implements codehost.Provider.
+type Service struct {
+	hosts        map[string]*hostClient // keyed by lowercase host
+	maxListItems int
+}
+
+// user is a GitLab account as embedded in API responses
+type user struct {
+	Username string `json:"username"`
+	Name     string `json:"name"`
+}
+
+// mergeRequest holds the fields of a GitLab merge request we use
+type mergeRequest struct {
+	Title        string    `json:"title"`
+	Description  string    `json:"description"`
+	State        string    `json:"state"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	Author       *user     `json:"author"`
+	Assignees    []*user   `json:"assignees"`
+	Labels       []string  `json:"labels"`
+	SHA          string    `json:"sha"`
+	TargetBranch string    `json:"target_branch"`
+	WebURL       string    `json:"web_url"`
+	ChangesCount string    `json:"changes_count"` // a number, or e.g. "1000+" when GitLab stopped counting
+}
+
+// diff is one changed file of a merge request
+type diff struct {
+	OldPath     string `json:"old_path"`
+	NewPath     string `json:"new_path"`
+	NewFile     bool   `json:"new_file"`
+	RenamedFile bool   `json:"renamed_file"`
+	DeletedFile bool   `json:"deleted_file"`
+	Diff        string `json:"diff"`
+}
+
+// commit is a commit of a merge request
+type commit struct {
+	ID          string `json:"id"`
+	Message     string `json:"message"`
+	AuthorName  string `json:"author_name"`
+	AuthorEmail string `json:"author_email"`
+	Stats       *struct {
+		Additions int `json:"additions"`
+		Deletions int `json:"deletions"`
+	} `json:"stats"`
+}
+
+// approvals lists who approved a merge request
+type approvals struct {
+	ApprovedBy []struct {
+		User user `json:"user"`
+	} `json:"approved_by"`
+}
+
+func NewService() *Service {
+	hosts := map[string]*hostClient{}
+
+	token := os.Getenv("GITLAB_TOKEN")
+	hosts[DefaultHost] = &hostClient{
+		host:       DefaultHost,
+		apiURL:     "https://" + DefaultHost + "/api/v4",
+		token:      token,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+	if token == "" {
+		log.Println("Warning: GITLAB_TOKEN not provided, only public GitLab projects can be read")
+	}
+
+	// Self-managed instances, each with its own token
+	selfManagedHosts, err := selfManagedHostsFromEnv()
+	if err != nil {
+		log.Printf("Warning: %v, self-managed GitLab hosts are disabled", err)
+	}
+	for _, selfManaged := range selfManagedHosts {
+		h, err := newHostClient(selfManaged.host, selfManaged.apiURL, os.Getenv(selfManagedTokenVariable(selfManaged.host)))
+		if err != nil {
+			log.Printf("Warning: %v, skipping GitLab host %s", err, selfManaged.host)
+			continue
+		}
+		if h.token == "" {
+			log.Printf("Warning: %s not provided, only public projects on %s can be read", selfManagedTokenVariable(h.host), h.host)
+		}
+		log.Printf("GitLab host %s enabled (API %s)", h.host, h.apiURL)
+		hosts[h.host] = h
+	}
+
+	return &Service{
+		hosts:        hosts,
+		maxListItems: codehost.MaxListItemsFromEnv(),
+	}
+}
+
+// Name returns the product name shown to users
+func (s *Service) Name() string {
+	return "GitLab"
+}
+
+// fetchMergeRequest fetches the metadata of the merge request
+func (s *Service) fetchMergeRequest(ctx context.Context, h *hostClient, ref codehost.Ref) (*mergeRequest, error) {
+	var mr mergeRequest
+	if _, err := h.do(ctx, http.MethodGet, mergeRequestPath(ref), nil, nil, &mr); err != nil {
+		return nil, fmt.Errorf("failed to fetch merge request %s: %w", ref, err)
+	}
+	return &mr, nil
+}
+
+// FetchHeadSHA returns the commit the merge request head currently points to.
+// It costs a single API call, which makes it a cheap freshness check.
+func (s *Service) FetchHeadSHA(ctx context.Context, ref codehost.Ref) (string, error) {
+	h, err := s.hostClientFor(ref.Host)
+	if err != nil {
+		return "", err
+	}
+
+	mr, err := s.fetchMergeRequest(ctx, h, ref)
+	if err != nil {
+		return "", err
+	}
+	return mr.SHA, nil
+}
+
+// FetchChangeRequest fetches the merge request with its labels, changes,
+// commits and approvals
+func (s *Service) FetchChangeRequest(ctx context.Context, ref codehost.Ref) (*codehost.ChangeRequest, error) {
+	h, err := s.hostClientFor(ref.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	mr, err := s.fetchMergeRequest(ctx, h, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	var truncatedLists []string
+	markTruncated := func(list string, truncated bool, err error) {
+		if err != nil {
+			log.Printf("Error fetching %s: %v", list, err)
+		}
+		if truncated || err != nil {
+			truncatedLists = append(truncatedLists, list)
+		}
+	}
+
+	// Fetch additional data, following pagination up to the configured limit
+	diffs, truncated, err := s.fetchDiffs(ctx, h, ref)
+	totalFiles, countErr := strconv.Atoi(mr.ChangesCount)
+	markTruncated("files", truncated || countErr != nil || len(diffs) < totalFiles, err)
+	if countErr != nil {
+		totalFiles = len(diffs)
+	}
+
+	mrCommits, truncated, err := listAll[commit](ctx, h, mergeRequestPath(ref)+"/commits", s.maxListItems)
+	markTruncated("commits", truncated, err)
+
+	var mrApprovals approvals
+	if _, err := h.do(ctx, http.MethodGet, mergeRequestPath(ref)+"/approvals", nil, nil, &mrApprovals); err != nil {
+		markTruncated("reviews", false, err)
+	}
+
+	files := convertDiffs(diffs)
+	additions, deletions := 0, 0
+	for _, file := range files {
+		additions += file.Additions
+		deletions += file.Deletions
+	}
+
+	// Derive the contributors from the commit authors and Co-authored-by trailers
+	commits := convertCommits(mrCommits)
+	if s.fetchCommitStats(ctx, h, ref, commits) {
+		truncatedLists = append(truncatedLists, "commit line counts")
+	}
+	contributors := codehost.BuildContributors(commits)
+	if len(contributors) == 0 && mr.Author != nil {
+		contributors = append(contributors, &codehost.Contributor{Login: mr.Author.Username, Name: mr.Author.Name})
+	}
+
+	var reviews []*codehost.Review
+	for _, approval := range mrApprovals.ApprovedBy {
+		reviews = append(reviews, &codehost.Review{User: approval.User.Username, State: "APPROVED"})
+	}
+
+	// Descriptions follow the project's template as of the target branch.
+	// Without it generation falls back to the built-in sections, so a failure
+	// is logged rather than returned.
+	template, err := fetchTemplate(ctx, h, ref, mr.TargetBranch)
+	if err != nil {
+		log.Printf("Error fetching merge request template of %s: %v", ref.Repository(), err)
+	}
+
+	webURL := mr.WebURL
+	if webURL == "" {
+		webURL = MergeRequestURL(ref)
+	}
+
+	return &codehost.ChangeRequest{
+		Title:             mr.Title,
+		Body:              mr.Description,
+		User:              convertUser(mr.Author),
+		Assignees:         convertUsers(mr.Assignees),
+		Labels:            mr.Labels,
+		State:             mr.State,
+		CreatedAt:         mr.CreatedAt,
+		UpdatedAt:         mr.UpdatedAt,
+		ChangedFiles:      files,
+		TotalChangedFiles: totalFiles,
+		Commits:           commits,
+		Reviews:           reviews,
+		Additions:         additions,
+		Deletions:         deletions,
+		Host:              ref.Host,
+		Repository:        ref.Repository(),
+		PRNumber:          ref.Number,
+		URL:               webURL,
+		HeadSHA:           mr.SHA,
+		Contributors:      contributors,
+		Template:          template,
+		Truncated:         len(truncatedLists) > 0,
+		TruncatedLists:    truncatedLists,
+	}, nil
+}
+
+// fetchDiffs lists the changed files of the merge request. Instances older than
+// GitLab 15.7 lack the paginated diffs endpoint and only offer the changes of
+// the merge request in one response.
+func (s *Service) fetchDiffs(ctx context.Context, h *hostClient, ref codehost.Ref) ([]diff, bool, error) {
+	diffs, truncated, err := listAll[diff](ctx, h, mergeRequestPath(ref)+"/diffs", s.maxListItems)
+	if !errors.Is(err, codehost.ErrNotFound) {
+		return diffs, truncated, err
+	}
+
+	var changes struct {
+		Changes []diff `json:"changes"`
+	}
+	if _, err := h.do(ctx, http.MethodGet, mergeRequestPath(ref)+"/changes", nil, nil, &changes); err != nil {
+		return nil, false, err
+	}
+	if len(changes.Changes) > s.maxListItems {
+		return changes.Changes[:s.maxListItems], true, nil
+	}
+	return changes.Changes, false, nil
+}
+
+// fetchCommitStats fills in the line counts of up to maxCommitStats commits, since
+// the MR commit listing does not include them. It reports whether any commit was skipped.
+func (s *Service) fetchCommitStats(ctx context.Context, h *hostClient, ref codehost.Ref, commits []codehost.Commit) bool {
+	limit := min(len(commits), maxCommitStats)
+	sem := make(chan struct{}, commitStatsConcurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	skipped := limit < len(commits)
+
+	for i := 0; i < limit; i++ {
+		wg.Add(1)
+		go func(c *codehost.Commit) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			var detail commit
+			if _, err := h.do(ctx, http.MethodGet, projectPath(ref)+"/repository/commits/"+c.SHA, nil, nil, &detail); err != nil || detail.Stats == nil {
+				log.Printf("Error fetching stats for commit %s: %v", c.SHA, err)
+				mu.Lock()
+				skipped = true
+				mu.Unlock()
+				return
+			}
+			c.Additions = detail.Stats.Additions
+			c.Deletions = detail.Stats.Deletions
+			c.HasStats = true
+		}(&commits[i])
+	}
+
+	wg.Wait()
+	return skipped
+}
+
+// convertUser maps a GitLab user to a codehost.User
+func convertUser(u *user) *codehost.User {
+	if u == nil {
+		return nil
+	}
+	return &codehost.User{Login: u.Username, Name: u.Name}
+}
+
+// convertUsers maps GitLab users to codehost.User values
+func convertUsers(users []*user) []*codehost.User {
+	converted := make([]*codehost.User, 0, len(users))
+	for _, u := range users {
+		converted = append(converted, convertUser(u))
+	}
+	return converted
+}
+
+// convertCommits maps the MR commits, which GitLab lists newest first, to
+// codehost.Commit values oldest first
+func convertCommits(mrCommits []commit) []codehost.Commit {
+	commits := make([]codehost.Commit, 0, len(mrCommits))
+	for _, c := range slices.Backward(mrCommits) {
+		converted := codehost.Commit{
+			SHA:         c.ID,
+			Message:     c.Message,
+			AuthorName:  c.AuthorName,
+			AuthorEmail: strings.ToLower(c.AuthorEmail),
+		}
+		converted.CoAuthors = codehost.ParseCoAuthors(converted.Message)
+		commits = append(commits, converted)
+	}
+	return commits
+}
+
+// convertDiffs maps the MR diffs to codehost.File values, counting the added
+// and removed lines in each patch since GitLab does not report them per file
+func convertDiffs(diffs []diff) []*codehost.File {
+	files := make([]*codehost.File, 0, len(diffs))
+	for _, d := range diffs {
+		file := &codehost.File{
+			Filename: d.NewPath,
+			Status:   "modified",
+			Patch:    strings.TrimSuffix(d.Diff, "\n"),
+		}
+		switch {
+		case d.NewFile:
+			file.Status = "added"
+		case d.DeletedFile:
+			file.Status = "removed"
+			file.Filename = d.OldPath
+		case d.RenamedFile:
+			file.Status = "renamed"
+			file.PreviousFilename = d.OldPath
+		}
+
+		for _, line := range strings.Split(file.Patch, "\n") {
+			switch {
+			case strings.HasPrefix(line, "+"):
+				file.Additions++
+			case strings.HasPrefix(line, "-"):
+				file.Deletions++
+			}
+		}
+		file.Changes = file.Additions + file.Deletions
+		files = append(files, file)
+	}
+	return files
+}
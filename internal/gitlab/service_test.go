@@ -0,0 +1,321 @@
+package gitlab_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+	"github.com/nahue/pr-toolbox-go/internal/gitlab"
+)
+
+// newTestServer stands in for a GitLab instance serving merge request
+// team/service!7. The diffs are served in two pages to exercise pagination;
+// with legacy set, the paginated diffs endpoint is missing as on GitLab
+// releases before 15.7.
+func newTestServer(t *testing.T, legacy bool) *httptest.Server {
+	t.Helper()
+
+	updatedAt := time.Date(2025, 9, 1, 12, 0, 0, 0, time.UTC)
+	description := "Original description"
+	writeJSON := func(w http.ResponseWriter, v any) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(v)
+	}
+	mergeRequest := func() map[string]any {
+		return map[string]any{
+			"title":         "Add greeting",
+			"description":   description,
+			"state":         "opened",
+			"created_at":    updatedAt.Add(-time.Hour),
+			"updated_at":    updatedAt,
+			"author":        map[string]any{"username": "alice", "name": "Alice Doe"},
+			"assignees":     []any{map[string]any{"username": "bob", "name": "Bob"}},
+			"labels":        []string{"enhancement", "docs"},
+			"sha":           "def456",
+			"target_branch": "main",
+			"web_url":       "https://gitlab.example.com/team/service/-/merge_requests/7",
+			"changes_count": "3",
+		}
+	}
+	diffs := []any{
+		map[string]any{"old_path": "main.go", "new_path": "main.go", "diff": "@@ -1,2 +1,3 @@\n package main\n+import \"fmt\"\n-func main() {}\n+func main() { fmt.Println(\"hi\") }\n"},
+		map[string]any{"old_path": "docs/old.md", "new_path": "docs/new.md", "renamed_file": true, "diff": "@@ -1 +1 @@\n-Old title\n+New title\n"},
+		map[string]any{"old_path": "legacy.go", "new_path": "legacy.go", "deleted_file": true, "diff": "@@ -1 +0,0 @@\n-package legacy\n"},
+	}
+
+	// requireProject rejects requests for any project but team/service, which
+	// GitLab addresses by its URL-encoded path
+	requireProject := func(handler http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if project := r.PathValue("project"); project != "team/service" {
+				http.Error(w, `{"message":"404 Project Not Found"}`, http.StatusNotFound)
+				return
+			}
+			handler(w, r)
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v4/projects/{project}/merge_requests/7", requireProject(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, mergeRequest())
+	}))
+	mux.HandleFunc("PUT /api/v4/projects/{project}/merge_requests/7", requireProject(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "secret" {
+			http.Error(w, `{"message":"401 Unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+		var update struct {
+			Description string `json:"description"`
+		}
+		json.NewDecoder(r.Body).Decode(&update)
+		description = update.Description
+		updatedAt = updatedAt.Add(time.Minute)
+		writeJSON(w, mergeRequest())
+	}))
+	if legacy {
+		mux.HandleFunc("GET /api/v4/projects/{project}/merge_requests/7/changes", requireProject(func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]any{"changes": diffs})
+		}))
+	} else {
+		mux.HandleFunc("GET /api/v4/projects/{project}/merge_requests/7/diffs", requireProject(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("page") == "2" {
+				writeJSON(w, diffs[2:])
+				return
+			}
+			w.Header().Set("X-Next-Page", "2")
+			writeJSON(w, diffs[:2])
+		}))
+	}
+	mux.HandleFunc("GET /api/v4/projects/{project}/merge_requests/7/commits", requireProject(func(w http.ResponseWriter, r *http.Request) {
+		// Newest first, as GitLab lists them
+		writeJSON(w, []any{
+			map[string]any{"id": "def456", "message": "Remove legacy package\n\nCo-authored-by: Carol <carol@example.com>", "author_name": "Alice Doe", "author_email": "Alice@example.com"},
+			map[string]any{"id": "abc123", "message": "Add greeting", "author_name": "Alice Doe", "author_email": "alice@example.com"},
+		})
+	}))
+	mux.HandleFunc("GET /api/v4/projects/{project}/repository/commits/{sha}", requireProject(func(w http.ResponseWriter, r *http.Request) {
+		stats := map[string]map[string]any{
+			"abc123": {"additions": 2, "deletions": 1},
+			"def456": {"additions": 1, "deletions": 2},
+		}
+		writeJSON(w, map[string]any{"id": r.PathValue("sha"), "stats": stats[r.PathValue("sha")]})
+	}))
+	mux.HandleFunc("GET /api/v4/projects/{project}/repository/tree", requireProject(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("path") != ".gitlab/merge_request_templates" || r.URL.Query().Get("ref") != "main" {
+			http.Error(w, `{"message":"404 Tree Not Found"}`, http.StatusNotFound)
+			return
+		}
+		writeJSON(w, []any{
+			map[string]any{"name": "Bug.md", "type": "blob", "path": ".gitlab/merge_request_templates/Bug.md"},
+			map[string]any{"name": "Default.md", "type": "blob", "path": ".gitlab/merge_request_templates/Default.md"},
+			map[string]any{"name": "archive", "type": "tree", "path": ".gitlab/merge_request_templates/archive"},
+		})
+	}))
+	mux.HandleFunc("GET /api/v4/projects/{project}/repository/files/{file}", requireProject(func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("file") != ".gitlab/merge_request_templates/Default.md" || r.URL.Query().Get("ref") != "main" {
+			http.Error(w, `{"message":"404 File Not Found"}`, http.StatusNotFound)
+			return
+		}
+		content := base64.StdEncoding.EncodeToString([]byte("## What does this MR do?\r\n\r\n## Checklist\r\n- [ ] Tests\r\n"))
+		writeJSON(w, map[string]any{"file_name": "Default.md", "encoding": "base64", "content": content})
+	}))
+	mux.HandleFunc("GET /api/v4/projects/{project}/merge_requests/7/approvals", requireProject(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"approved_by": []any{map[string]any{"user": map[string]any{"username": "bob"}}}})
+	}))
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestService(t *testing.T, token string, legacy bool) *gitlab.Service {
+	server := newTestServer(t, legacy)
+	t.Setenv("GITLAB_HOSTS", "gitlab.example.com="+server.URL)
+	t.Setenv("GITLAB_TOKEN_GITLAB_EXAMPLE_COM", token)
+	return gitlab.NewService()
+}
+
+var testRef = codehost.Ref{Host: "gitlab.example.com", Owner: "team", Repo: "service", Number: 7}
+
+func TestFetchChangeRequest(t *testing.T) {
+	for _, legacy := range []bool{false, true} {
+		service := newTestService(t, "", legacy)
+
+		mr, err := service.FetchChangeRequest(context.Background(), testRef)
+		if err != nil {
+			t.Fatalf("FetchChangeRequest (legacy %v) returned error: %v", legacy, err)
+		}
+
+		if mr.Title != "Add greeting" || mr.Body != "Original description" || mr.HeadSHA != "def456" || mr.State != "opened" {
+			t.Errorf("unexpected metadata: %q, %q, %q, %q", mr.Title, mr.Body, mr.HeadSHA, mr.State)
+		}
+		if mr.User == nil || mr.User.Login != "alice" || mr.User.Name != "Alice Doe" {
+			t.Errorf("unexpected author: %+v", mr.User)
+		}
+		if len(mr.Assignees) != 1 || mr.Assignees[0].Login != "bob" {
+			t.Errorf("unexpected assignees: %+v", mr.Assignees)
+		}
+		if got := codehost.GetLabelsString(mr.Labels); got != "enhancement, docs" {
+			t.Errorf("labels = %q", got)
+		}
+		if mr.URL != "https://gitlab.example.com/team/service/-/merge_requests/7" || mr.Repository != "team/service" {
+			t.Errorf("unexpected location: %q, %q", mr.URL, mr.Repository)
+		}
+		if mr.Truncated {
+			t.Errorf("unexpected truncated lists: %v", mr.TruncatedLists)
+		}
+
+		if len(mr.ChangedFiles) != 3 || mr.TotalChangedFiles != 3 {
+			t.Fatalf("got %d of %d files, want 3 of 3", len(mr.ChangedFiles), mr.TotalChangedFiles)
+		}
+		main, docs, removed := mr.ChangedFiles[0], mr.ChangedFiles[1], mr.ChangedFiles[2]
+		if main.Status != "modified" || main.Additions != 2 || main.Deletions != 1 || main.Changes != 3 {
+			t.Errorf("unexpected main.go: %+v", main)
+		}
+		if docs.Filename != "docs/new.md" || docs.Status != "renamed" || docs.PreviousFilename != "docs/old.md" || docs.Patch != "@@ -1 +1 @@\n-Old title\n+New title" {
+			t.Errorf("unexpected docs/new.md: %+v", docs)
+		}
+		if removed.Filename != "legacy.go" || removed.Status != "removed" || removed.Deletions != 1 {
+			t.Errorf("unexpected legacy.go: %+v", removed)
+		}
+		if mr.Additions != 3 || mr.Deletions != 3 {
+			t.Errorf("line counts = +%d -%d, want +3 -3", mr.Additions, mr.Deletions)
+		}
+
+		if len(mr.Commits) != 2 {
+			t.Fatalf("got %d commits, want 2", len(mr.Commits))
+		}
+		first, second := mr.Commits[0], mr.Commits[1]
+		if first.SHA != "abc123" || !first.HasStats || first.Additions != 2 || first.Deletions != 1 {
+			t.Errorf("unexpected first commit: %+v", first)
+		}
+		if second.SHA != "def456" || second.AuthorEmail != "alice@example.com" || len(second.CoAuthors) != 1 {
+			t.Errorf("unexpected second commit: %+v", second)
+		}
+		if len(mr.Contributors) != 2 || mr.Contributors[0].Email != "alice@example.com" || mr.Contributors[0].Commits != 2 {
+			t.Errorf("unexpected contributors: %+v", mr.Contributors)
+		}
+
+		if len(mr.Reviews) != 1 || mr.Reviews[0].User != "bob" || mr.Reviews[0].State != "APPROVED" {
+			t.Errorf("unexpected reviews: %+v", mr.Reviews)
+		}
+
+		// The template named Default wins over the others in the directory
+		if mr.Template != "## What does this MR do?\n\n## Checklist\n- [ ] Tests" {
+			t.Errorf("template = %q", mr.Template)
+		}
+	}
+}
+
+func TestFetchChangeRequestRespectsListLimit(t *testing.T) {
+	t.Setenv("GITHUB_MAX_LIST_ITEMS", "2")
+	service := newTestService(t, "", false)
+
+	mr, err := service.FetchChangeRequest(context.Background(), testRef)
+	if err != nil {
+		t.Fatalf("FetchChangeRequest returned error: %v", err)
+	}
+
+	// The first page already fills the limit and announces a second one
+	if len(mr.ChangedFiles) != 2 || mr.TotalChangedFiles != 3 {
+		t.Errorf("got %d of %d files, want 2 of 3", len(mr.ChangedFiles), mr.TotalChangedFiles)
+	}
+	if !mr.Truncated || mr.TruncatedLists[0] != "files" {
+		t.Errorf("truncated lists = %v, want files first", mr.TruncatedLists)
+	}
+}
+
+func TestFetchChangeRequestUnknownProject(t *testing.T) {
+	service := newTestService(t, "", false)
+
+	ref := codehost.Ref{Host: "gitlab.example.com", Owner: "team", Repo: "other", Number: 7}
+	if _, err := service.FetchChangeRequest(context.Background(), ref); !errors.Is(err, codehost.ErrNotFound) {
+		t.Errorf("FetchChangeRequest error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestUpdateBody(t *testing.T) {
+	service := newTestService(t, "secret", false)
+	ctx := context.Background()
+
+	current, err := service.FetchBody(ctx, testRef)
+	if err != nil {
+		t.Fatalf("FetchBody returned error: %v", err)
+	}
+
+	updated, err := service.UpdateBody(ctx, testRef, "New description", current)
+	if err != nil {
+		t.Fatalf("UpdateBody returned error: %v", err)
+	}
+	if updated.Body != "New description" {
+		t.Errorf("body = %q, want the new description", updated.Body)
+	}
+
+	if _, err := service.UpdateBody(ctx, testRef, "Stale description", current); !errors.Is(err, codehost.ErrConflict) {
+		t.Errorf("stale UpdateBody error = %v, want ErrConflict", err)
+	}
+}
+
+func TestUpdateBodyWithoutToken(t *testing.T) {
+	service := newTestService(t, "", false)
+
+	if _, err := service.UpdateBody(context.Background(), testRef, "New description", nil); !errors.Is(err, codehost.ErrReadOnly) {
+		t.Errorf("UpdateBody error = %v, want ErrReadOnly", err)
+	}
+}
+
+func TestParseMRRef(t *testing.T) {
+	nested := codehost.Ref{Host: "gitlab.com", Owner: "group/sub", Repo: "project", Number: 42}
+
+	tests := []struct {
+		input string
+		want  codehost.Ref
+	}{
+		{"https://gitlab.example.com/team/service/-/merge_requests/7", testRef},
+		{"https://gitlab.example.com/team/service/merge_requests/7", testRef},
+		{"gitlab.example.com/team/service/-/merge_requests/7/diffs?view=parallel", testRef},
+		{"https://www.GitLab.Example.com/team/service.git/-/merge_requests/7#note_1", testRef},
+		{"gitlab.example.com/team/service!7", testRef},
+		{"https://gitlab.com/group/sub/project/-/merge_requests/42/commits", nested},
+		{"group/sub/project!42", nested},
+		{"  group/sub/project!42  ", nested},
+	}
+	for _, tt := range tests {
+		got, err := gitlab.ParseMRRef(tt.input)
+		if err != nil {
+			t.Errorf("ParseMRRef(%q) returned error: %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseMRRef(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+
+	for _, input := range []string{
+		"",
+		"project!42",
+		"group/project!0",
+		"group/project#42",
+		"group/project !42",
+		"ftp://gitlab.com/group/project/-/merge_requests/42",
+		"https://gitlab.com/group/project/-/issues/42",
+		"https://gitlab.com/group/project/-/merge_requests/",
+		"https://gitlab.com/group/../project/-/merge_requests/42",
+	} {
+		if got, err := gitlab.ParseMRRef(input); err == nil {
+			t.Errorf("ParseMRRef(%q) = %+v, want an error", input, got)
+		}
+	}
+}
+
+func TestMergeRequestURL(t *testing.T) {
+	if got := gitlab.MergeRequestURL(testRef); got != "https://gitlab.example.com/team/service/-/merge_requests/7" {
+		t.Errorf("MergeRequestURL = %q", got)
+	}
+}
@@ -0,0 +1,92 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+)
+
+// templateDir is the directory GitLab reads merge request description
+// templates from
+const templateDir = ".gitlab/merge_request_templates"
+
+// treeEntry is one entry of a repository tree listing
+type treeEntry struct {
+	Name string `json:"name"`
+	Type string `json:"type"` // "blob" for files, "tree" for directories
+	Path string `json:"path"`
+}
+
+// fileContent is a repository file as returned by the files API
+type fileContent struct {
+	Content  string `json:"content"`
+	Encoding string `json:"encoding"`
+}
+
+// pickTemplate chooses among the merge request templates. GitLab fills in a
+// template named "Default" (case-insensitively) for new merge requests;
+// otherwise authors pick one, which is not recorded, so the first Markdown
+// file by name is used.
+func pickTemplate(entries []treeEntry) string {
+	var first string
+	for _, entry := range entries {
+		name := strings.ToLower(entry.Name)
+		if entry.Type != "blob" || path.Ext(name) != ".md" {
+			continue
+		}
+		if strings.TrimSuffix(name, ".md") == "default" {
+			return entry.Path
+		}
+		if first == "" || name < strings.ToLower(path.Base(first)) {
+			first = entry.Path
+		}
+	}
+	return first
+}
+
+// fetchTemplate returns the merge request template of the project at branch
+// (the target branch of the merge request), or "" when it has none
+func fetchTemplate(ctx context.Context, h *hostClient, ref codehost.Ref, branch string) (string, error) {
+	var entries []treeEntry
+	query := url.Values{"path": {templateDir}, "ref": {branch}, "per_page": {strconv.Itoa(perPage)}}
+	if _, err := h.do(ctx, http.MethodGet, projectPath(ref)+"/repository/tree", query, nil, &entries); err != nil {
+		if errors.Is(err, codehost.ErrNotFound) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to list %s: %w", templateDir, err)
+	}
+	file := pickTemplate(entries)
+	if file == "" {
+		return "", nil
+	}
+
+	var content fileContent
+	if _, err := h.do(ctx, http.MethodGet, projectPath(ref)+"/repository/files/"+url.PathEscape(file), url.Values{"ref": {branch}}, nil, &content); err != nil {
+		return "", fmt.Errorf("failed to fetch %s: %w", file, err)
+	}
+	template, err := decodeContent(content)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode %s: %w", file, err)
+	}
+	return strings.TrimSpace(strings.ReplaceAll(template, "\r\n", "\n")), nil
+}
+
+// decodeContent returns the text of a file from the files API
+func decodeContent(content fileContent) (string, error) {
+	if content.Encoding != "base64" {
+		return content.Content, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(content.Content, "\n", ""))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
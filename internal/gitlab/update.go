@@ -0,0 +1,85 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+)
+
+// CheckWrite returns codehost.ErrReadOnly when no token is configured for
+// host. The token is the same for every owner.
+func (s *Service) CheckWrite(ctx context.Context, host, owner string) error {
+	h, err := s.hostClientFor(host)
+	if err != nil {
+		return err
+	}
+	if h.token == "" {
+		return codehost.ErrReadOnly
+	}
+	return nil
+}
+
+// FetchBody returns the current description of the merge request
+func (s *Service) FetchBody(ctx context.Context, ref codehost.Ref) (*codehost.Body, error) {
+	h, err := s.hostClientFor(ref.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	mr, err := s.fetchMergeRequest(ctx, h, ref)
+	if err != nil {
+		return nil, err
+	}
+	return &codehost.Body{Body: mr.Description, UpdatedAt: mr.UpdatedAt}, nil
+}
+
+// UpdateBody replaces the description of the merge request. When current is
+// set and the merge request no longer matches it, nothing is written and
+// codehost.ErrConflict is returned; see codehost.Provider. It returns
+// codehost.ErrReadOnly when no token is configured for the host.
+func (s *Service) UpdateBody(ctx context.Context, ref codehost.Ref, body string, current *codehost.Body) (*codehost.Body, error) {
+	if err := s.CheckWrite(ctx, ref.Host, ref.Owner); err != nil {
+		return nil, err
+	}
+	h, err := s.hostClientFor(ref.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	if current != nil {
+		latest, err := s.FetchBody(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+		if err := current.CheckUnchanged(ref, latest); err != nil {
+			return nil, err
+		}
+	}
+
+	var mr mergeRequest
+	update := map[string]string{"description": body}
+	if _, err := h.do(ctx, http.MethodPut, mergeRequestPath(ref), nil, update, &mr); err != nil {
+		return nil, fmt.Errorf("failed to update merge request %s: %w", ref, err)
+	}
+	return &codehost.Body{Body: mr.Description, UpdatedAt: mr.UpdatedAt}, nil
+}
+
+// CreateComment posts a note on the merge request. It returns
+// codehost.ErrReadOnly when no token is configured for the host.
+func (s *Service) CreateComment(ctx context.Context, ref codehost.Ref, body string) error {
+	if err := s.CheckWrite(ctx, ref.Host, ref.Owner); err != nil {
+		return err
+	}
+	h, err := s.hostClientFor(ref.Host)
+	if err != nil {
+		return err
+	}
+
+	note := map[string]string{"body": body}
+	if _, err := h.do(ctx, http.MethodPost, mergeRequestPath(ref)+"/notes", nil, note, nil); err != nil {
+		return fmt.Errorf("failed to comment on merge request %s: %w", ref, err)
+	}
+	return nil
+}
@@ -0,0 +1,257 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultAnthropicModel   = "claude-3-5-haiku-latest"
+	defaultAnthropicBaseURL = "https://api.anthropic.com"
+	anthropicAPIVersion     = "2023-06-01"
+)
+
+// AnthropicProvider talks to the Anthropic Messages API
+type AnthropicProvider struct {
+	apiKey     string
+	baseURL    string
+	model      string
+	httpClient *http.Client
+}
+
+// NewAnthropicProvider creates a provider for the Anthropic API
+func NewAnthropicProvider(apiKey, model string) *AnthropicProvider {
+	return &AnthropicProvider{
+		apiKey:     apiKey,
+		baseURL:    defaultAnthropicBaseURL,
+		model:      model,
+		httpClient: &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+func (p *AnthropicProvider) Name() string {
+	return "anthropic"
+}
+
+func (p *AnthropicProvider) DefaultModel() string {
+	return p.model
+}
+
+// anthropicModels are the Anthropic models known to work with the Messages API
+var anthropicModels = []string{
+	"claude-3-5-haiku-latest",
+	"claude-3-5-sonnet-latest",
+	"claude-3-7-sonnet-latest",
+	"claude-sonnet-4-0",
+	"claude-opus-4-0",
+}
+
+func (p *AnthropicProvider) Models(ctx context.Context) ([]string, error) {
+	return withModel(anthropicModels, p.model), nil
+}
+
+type anthropicRequest struct {
+	Model       string    `json:"model"`
+	System      string    `json:"system,omitempty"`
+	Messages    []Message `json:"messages"`
+	MaxTokens   int       `json:"max_tokens"`
+	Temperature float32   `json:"temperature"`
+	Stream      bool      `json:"stream,omitempty"`
+}
+
+type anthropicResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	Usage struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+// anthropicStreamEvent covers the fields of the streaming events we use
+type anthropicStreamEvent struct {
+	Type    string `json:"type"`
+	Message struct {
+		Usage struct {
+			InputTokens int `json:"input_tokens"`
+		} `json:"usage"`
+	} `json:"message"`
+	Delta struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"delta"`
+	Usage struct {
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type anthropicError struct {
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// newAnthropicRequest converts a completion request, moving system messages
+// into the top-level system prompt as the Messages API requires
+func newAnthropicRequest(req CompletionRequest) anthropicRequest {
+	var system []string
+	var messages []Message
+	for _, message := range req.Messages {
+		if message.Role == RoleSystem {
+			system = append(system, message.Content)
+			continue
+		}
+		messages = append(messages, message)
+	}
+
+	return anthropicRequest{
+		Model:       req.Model,
+		System:      strings.Join(system, "\n\n"),
+		Messages:    messages,
+		MaxTokens:   req.MaxTokens,
+		Temperature: req.Temperature,
+	}
+}
+
+// newHTTPRequest builds an authenticated request to the Messages API
+func (p *AnthropicProvider) newHTTPRequest(ctx context.Context, body any) (*http.Request, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/v1/messages", bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", p.apiKey)
+	httpReq.Header.Set("anthropic-version", anthropicAPIVersion)
+	return httpReq, nil
+}
+
+// decodeAnthropicError turns a non-2xx response into an error
+func decodeAnthropicError(resp *http.Response) error {
+	var apiErr anthropicError
+	if err := json.NewDecoder(resp.Body).Decode(&apiErr); err == nil && apiErr.Error.Message != "" {
+		return fmt.Errorf("anthropic API error (status %d, %s): %s", resp.StatusCode, apiErr.Error.Type, apiErr.Error.Message)
+	}
+	return fmt.Errorf("anthropic API error: status %d", resp.StatusCode)
+}
+
+func (p *AnthropicProvider) CreateCompletion(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	httpReq, err := p.newHTTPRequest(ctx, newAnthropicRequest(req))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeAnthropicError(resp)
+	}
+
+	var result anthropicResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode anthropic response: %w", err)
+	}
+
+	var text strings.Builder
+	for _, block := range result.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	if text.Len() == 0 {
+		return nil, fmt.Errorf("no response from anthropic")
+	}
+
+	return &CompletionResponse{
+		Content: text.String(),
+		Usage: Usage{
+			PromptTokens:     result.Usage.InputTokens,
+			CompletionTokens: result.Usage.OutputTokens,
+		},
+	}, nil
+}
+
+func (p *AnthropicProvider) StreamCompletion(ctx context.Context, req CompletionRequest, onDelta func(string) error) (*CompletionResponse, error) {
+	body := newAnthropicRequest(req)
+	body.Stream = true
+
+	httpReq, err := p.newHTTPRequest(ctx, body)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Accept", "text/event-stream")
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeAnthropicError(resp)
+	}
+
+	var content strings.Builder
+	var usage Usage
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+
+		var event anthropicStreamEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return nil, fmt.Errorf("failed to decode anthropic stream event: %w", err)
+		}
+
+		switch event.Type {
+		case "message_start":
+			usage.PromptTokens = event.Message.Usage.InputTokens
+		case "content_block_delta":
+			if event.Delta.Type != "text_delta" {
+				continue
+			}
+			content.WriteString(event.Delta.Text)
+			if err := onDelta(event.Delta.Text); err != nil {
+				return nil, err
+			}
+		case "message_delta":
+			usage.CompletionTokens = event.Usage.OutputTokens
+		case "error":
+			return nil, fmt.Errorf("anthropic API error (%s): %s", event.Error.Type, event.Error.Message)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if content.Len() == 0 {
+		return nil, fmt.Errorf("no response from anthropic")
+	}
+
+	return &CompletionResponse{Content: content.String(), Usage: usage}, nil
+}
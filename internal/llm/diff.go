@@ -0,0 +1,193 @@
+package llm
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+)
+
+// defaultDiffTokenBudget is used when LLM_DIFF_TOKEN_BUDGET is not set
+const defaultDiffTokenBudget = 6000
+
+// fileCategory orders changed files by how useful their patch is to the model
+type fileCategory int
+
+const (
+	categorySource fileCategory = iota
+	categoryTest
+	categoryGenerated
+)
+
+func (c fileCategory) String() string {
+	switch c {
+	case categoryTest:
+		return "test"
+	case categoryGenerated:
+		return "generated"
+	default:
+		return "source"
+	}
+}
+
+// generatedMarkers are filename fragments that identify generated or vendored files
+var generatedMarkers = []string{
+	"_templ.go",
+	".pb.go",
+	"_gen.go",
+	".gen.go",
+	"_generated.",
+	".generated.",
+	".min.js",
+	".min.css",
+	".snap",
+	"vendor/",
+	"node_modules/",
+	"dist/",
+}
+
+// lockFiles are dependency manifests that are regenerated by tooling
+var lockFiles = map[string]bool{
+	"go.sum":            true,
+	"package-lock.json": true,
+	"yarn.lock":         true,
+	"pnpm-lock.yaml":    true,
+	"Cargo.lock":        true,
+	"Gemfile.lock":      true,
+	"poetry.lock":       true,
+	"composer.lock":     true,
+}
+
+// testMarkers are filename fragments that identify test code
+var testMarkers = []string{
+	"_test.go",
+	".test.",
+	".spec.",
+	"/test/",
+	"/tests/",
+	"/__tests__/",
+	"testdata/",
+}
+
+// classifyFile decides whether a changed file is source, test or generated code
+func classifyFile(filename string) fileCategory {
+	name := "/" + filename
+	base := path.Base(filename)
+
+	if lockFiles[base] {
+		return categoryGenerated
+	}
+	for _, marker := range generatedMarkers {
+		if strings.Contains(name, marker) {
+			return categoryGenerated
+		}
+	}
+
+	for _, marker := range testMarkers {
+		if strings.Contains(name, marker) {
+			return categoryTest
+		}
+	}
+	if strings.HasPrefix(base, "test_") {
+		return categoryTest
+	}
+
+	return categorySource
+}
+
+// estimateTokens approximates the token count of a string (roughly 4 characters per token)
+func estimateTokens(s string) int {
+	return (len(s) + 3) / 4
+}
+
+// sortFilesByPriority returns the files ordered source first, then tests, then generated code
+func sortFilesByPriority(files []*codehost.File) []*codehost.File {
+	sorted := make([]*codehost.File, len(files))
+	copy(sorted, files)
+	sort.SliceStable(sorted, func(a, b int) bool {
+		return classifyFile(sorted[a].Filename) < classifyFile(sorted[b].Filename)
+	})
+	return sorted
+}
+
+// fileHeader renders the heading that precedes a file's patch in the prompt
+func fileHeader(file *codehost.File) string {
+	filename := file.Filename
+	return fmt.Sprintf("### %s (%s, %s, +%d/-%d)\n", filename, file.Status, classifyFile(filename), file.Additions, file.Deletions)
+}
+
+// fileEntry renders a file's complete patch for the prompt
+func fileEntry(file *codehost.File) string {
+	patch := file.Patch
+	if patch == "" {
+		return fileHeader(file) + "(no patch available: binary file or diff too large)\n\n"
+	}
+	return fileHeader(file) + "```diff\n" + patch + "\n```\n\n"
+}
+
+// diffTokens estimates the tokens needed to include every patch untruncated
+func diffTokens(files []*codehost.File) int {
+	total := 0
+	for _, file := range files {
+		total += estimateTokens(fileEntry(file))
+	}
+	return total
+}
+
+// buildDiffContext renders the patches of the changed files for the prompt,
+// prioritising source files over tests and tests over generated code, and
+// stopping once the token budget is exhausted. Dropped or cut hunks are
+// replaced with explicit truncation markers so the model knows the diff is partial.
+func buildDiffContext(files []*codehost.File, budget int) string {
+	var sb strings.Builder
+	remaining := budget
+	var omitted []string
+
+	for _, file := range sortFilesByPriority(files) {
+		filename := file.Filename
+
+		entry := fileEntry(file)
+		if estimateTokens(entry) <= remaining {
+			sb.WriteString(entry)
+			remaining -= estimateTokens(entry)
+			continue
+		}
+
+		// Include a truncated prefix of the patch when a meaningful amount of budget is left
+		header := fileHeader(file)
+		overhead := estimateTokens(header+"```diff\n\n```\n\n") + 20
+		if file.Patch == "" || remaining-overhead < 50 {
+			omitted = append(omitted, filename)
+			continue
+		}
+
+		lines := strings.Split(file.Patch, "\n")
+		kept := 0
+		used := 0
+		for _, line := range lines {
+			cost := estimateTokens(line + "\n")
+			if used+cost > remaining-overhead {
+				break
+			}
+			used += cost
+			kept++
+		}
+
+		truncated := strings.Join(lines[:kept], "\n")
+		entry = fmt.Sprintf("%s```diff\n%s\n... [patch truncated: %d of %d lines omitted]\n```\n\n", header, truncated, len(lines)-kept, len(lines))
+		sb.WriteString(entry)
+		remaining -= estimateTokens(entry)
+	}
+
+	if len(omitted) > 0 {
+		sb.WriteString(fmt.Sprintf("[diff truncated: %d file(s) omitted to fit the token budget: %s]\n", len(omitted), strings.Join(omitted, ", ")))
+	}
+
+	if sb.Len() == 0 {
+		return "(no file changes available)"
+	}
+
+	return strings.TrimRight(sb.String(), "\n")
+}
@@ -0,0 +1,110 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+)
+
+func TestClassifyFile(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     fileCategory
+	}{
+		{"internal/llm/diff.go", categorySource},
+		{"README.md", categorySource},
+		{"internal/llm/diff_test.go", categoryTest},
+		{"web/src/app.spec.ts", categoryTest},
+		{"tests/test_parser.py", categoryTest},
+		{"test_parser.py", categoryTest},
+		{"internal/app/testdata/payload.json", categoryTest},
+		{"go.sum", categoryGenerated},
+		{"web/package-lock.json", categoryGenerated},
+		{"templates/layout_templ.go", categoryGenerated},
+		{"api/service.pb.go", categoryGenerated},
+		{"vendor/github.com/pkg/errors/errors.go", categoryGenerated},
+		{"static/app.min.js", categoryGenerated},
+		{"dist_notes.md", categorySource},
+	}
+
+	for _, tt := range tests {
+		if got := classifyFile(tt.filename); got != tt.want {
+			t.Errorf("classifyFile(%q) = %s, want %s", tt.filename, got, tt.want)
+		}
+	}
+}
+
+// patchOf returns a patch adding n numbered lines
+func patchOf(n int) string {
+	lines := []string{fmt.Sprintf("@@ -0,0 +1,%d @@", n)}
+	for i := 1; i <= n; i++ {
+		lines = append(lines, fmt.Sprintf("+line %d of the change", i))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestBuildDiffContext(t *testing.T) {
+	source := &codehost.File{Filename: "main.go", Status: "modified", Additions: 3, Patch: patchOf(3)}
+	test := &codehost.File{Filename: "main_test.go", Status: "added", Additions: 3, Patch: patchOf(3)}
+	binary := &codehost.File{Filename: "logo.png", Status: "added"}
+	large := &codehost.File{Filename: "server.go", Status: "modified", Additions: 400, Patch: patchOf(400)}
+	lock := &codehost.File{Filename: "go.sum", Status: "modified", Additions: 400, Patch: patchOf(400)}
+
+	tests := []struct {
+		name        string
+		files       []*codehost.File
+		budget      int
+		contains    []string // in this order
+		notContains []string
+	}{
+		{
+			name:     "no files",
+			budget:   1000,
+			contains: []string{"(no file changes available)"},
+		},
+		{
+			name:        "everything fits, source first",
+			files:       []*codehost.File{test, binary, source},
+			budget:      1000,
+			contains:    []string{"### logo.png (added, source, +0/-0)\n(no patch available", "### main.go (modified, source, +3/-0)\n```diff\n", "### main_test.go (added, test, +3/-0)"},
+			notContains: []string{"truncated"},
+		},
+		{
+			name:        "large patch is cut",
+			files:       []*codehost.File{large, source},
+			budget:      500,
+			contains:    []string{"### server.go", "+line 1 of the change", "lines omitted]", "### main.go"},
+			notContains: []string{"+line 400 of the change", "file(s) omitted"},
+		},
+		{
+			name:        "files without room are omitted",
+			files:       []*codehost.File{lock, large},
+			budget:      60,
+			contains:    []string{"[diff truncated: 2 file(s) omitted to fit the token budget: server.go, go.sum]"},
+			notContains: []string{"```diff"},
+		},
+	}
+
+	for _, tt := range tests {
+		got := buildDiffContext(tt.files, tt.budget)
+		rest := got
+		for _, want := range tt.contains {
+			i := strings.Index(rest, want)
+			if i < 0 {
+				t.Errorf("%s: buildDiffContext() = %q, want it to contain %q after the previous parts", tt.name, got, want)
+				break
+			}
+			rest = rest[i+len(want):]
+		}
+		for _, unwanted := range tt.notContains {
+			if strings.Contains(got, unwanted) {
+				t.Errorf("%s: buildDiffContext() = %q, want it not to contain %q", tt.name, got, unwanted)
+			}
+		}
+		if estimateTokens(got) > tt.budget+50 {
+			t.Errorf("%s: buildDiffContext() used about %d tokens, over the budget of %d", tt.name, estimateTokens(got), tt.budget)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FakeProvider returns deterministic completions built from the prompt itself.
+// It needs no network access, which makes it suitable for development and tests.
+type FakeProvider struct{}
+
+// NewFakeProvider creates an offline provider
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{}
+}
+
+func (p *FakeProvider) Name() string {
+	return "fake"
+}
+
+func (p *FakeProvider) DefaultModel() string {
+	return "fake-model"
+}
+
+func (p *FakeProvider) Models(ctx context.Context) ([]string, error) {
+	return []string{"fake-model", "fake-model-large"}, nil
+}
+
+func (p *FakeProvider) CreateCompletion(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var prompt string
+	for _, message := range req.Messages {
+		if message.Role == RoleUser {
+			prompt = message.Content
+		}
+	}
+
+	content := fakeCompletion(prompt)
+	return &CompletionResponse{
+		Content: content,
+		Usage: Usage{
+			PromptTokens:     estimateTokens(prompt),
+			CompletionTokens: estimateTokens(content),
+		},
+	}, nil
+}
+
+// StreamCompletion emits the fake completion word by word
+func (p *FakeProvider) StreamCompletion(ctx context.Context, req CompletionRequest, onDelta func(string) error) (*CompletionResponse, error) {
+	resp, err := p.CreateCompletion(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, word := range strings.SplitAfter(resp.Content, " ") {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := onDelta(word); err != nil {
+			return nil, err
+		}
+	}
+
+	return resp, nil
+}
+
+// fakeCompletion lists the files and linked issues mentioned in the prompt,
+// wrapped in a description skeleton when the prompt asks for a full
+// description, or in the repository's pull request template when the prompt
+// includes one. Title and release highlights prompts get a one-line answer,
+// and review prompts a comment on the first added line.
+func fakeCompletion(prompt string) string {
+	var title, repository string
+	var changes, template, links []string
+	inTemplate := false
+
+	for _, line := range strings.Split(prompt, "\n") {
+		switch {
+		case line == templateStart:
+			inTemplate = true
+		case line == templateEnd:
+			inTemplate = false
+		case inTemplate:
+			template = append(template, line)
+		case strings.HasPrefix(line, "Title: "):
+			title = strings.TrimPrefix(line, "Title: ")
+		case strings.HasPrefix(line, "Repository: "):
+			repository = strings.TrimPrefix(line, "Repository: ")
+		case strings.HasPrefix(line, "### "):
+			changes = append(changes, "- "+strings.TrimPrefix(line, "### "))
+		case strings.HasPrefix(line, "  Link: "):
+			links = append(links, "- "+strings.TrimPrefix(line, "  Link: "))
+		}
+	}
+	if len(changes) == 0 {
+		changes = append(changes, "- No file changes were included in the prompt")
+	}
+
+	if strings.HasPrefix(prompt, titleInstruction) {
+		return fmt.Sprintf("Update %s", repository)
+	}
+	if strings.HasPrefix(prompt, reviewInstruction) {
+		return fakeReview(prompt)
+	}
+	if strings.HasPrefix(prompt, highlightsInstruction) {
+		return fmt.Sprintf("Offline highlights for %s, generated by the fake LLM provider.", repository)
+	}
+	if len(template) > 0 {
+		return fmt.Sprintf("%s\n\nOffline description for \"%s\" in %s, generated by the fake LLM provider.\n\n%s", strings.Join(template, "\n"), title, repository, strings.Join(changes, "\n"))
+	}
+	if !strings.Contains(prompt, "Please structure the description") {
+		return strings.Join(changes, "\n")
+	}
+
+	description := fmt.Sprintf(`## Summary
+
+Offline description for "%s" in %s, generated by the fake LLM provider.
+
+## Changes Made
+
+%s`, title, repository, strings.Join(changes, "\n"))
+	if len(links) > 0 {
+		description += "\n\n## Relevant Links\n\n" + strings.Join(links, "\n")
+	}
+	return description
+}
+
+// fakeReview comments on the first added line of the file in a review prompt
+func fakeReview(prompt string) string {
+	var filename string
+	for _, line := range strings.Split(prompt, "\n") {
+		if strings.HasPrefix(line, "File: ") {
+			filename, _, _ = strings.Cut(strings.TrimPrefix(line, "File: "), " ")
+		}
+		ref, _, ok := strings.Cut(line, " +")
+		if _, err := strconv.Atoi(strings.TrimPrefix(ref, "R")); ok && err == nil && strings.HasPrefix(ref, "R") {
+			return fmt.Sprintf(`[{"line": %q, "severity": "nit", "comment": "Offline review comment on %s, generated by the fake LLM provider."}]`, ref, filename)
+		}
+	}
+	return "[]"
+}
@@ -0,0 +1,24 @@
+package llm
+
+import (
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	defaultOllamaBaseURL = "http://localhost:11434/v1"
+	defaultOllamaModel   = "llama3.1"
+)
+
+// NewOllamaProvider creates a provider for a local Ollama server, or any other
+// endpoint exposing the OpenAI-compatible chat completions API. apiKey may be
+// empty since local servers usually do not check it.
+func NewOllamaProvider(baseURL, apiKey, model string) *OpenAIProvider {
+	config := openai.DefaultConfig(apiKey)
+	config.BaseURL = baseURL
+
+	return &OpenAIProvider{
+		name:   "ollama",
+		client: openai.NewClientWithConfig(config),
+		model:  model,
+	}
+}
@@ -0,0 +1,169 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const defaultOpenAIModel = openai.GPT3Dot5Turbo
+
+// OpenAIProvider talks to the OpenAI chat completions API, or to any server
+// implementing the same API
+type OpenAIProvider struct {
+	name   string
+	client *openai.Client
+	model  string
+}
+
+// NewOpenAIProvider creates a provider for the OpenAI API
+func NewOpenAIProvider(apiKey, model string) *OpenAIProvider {
+	return &OpenAIProvider{
+		name:   "openai",
+		client: openai.NewClient(apiKey),
+		model:  model,
+	}
+}
+
+func (p *OpenAIProvider) Name() string {
+	return p.name
+}
+
+func (p *OpenAIProvider) DefaultModel() string {
+	return p.model
+}
+
+// openAIModels are the OpenAI chat models offered for selection
+var openAIModels = []string{
+	openai.GPT3Dot5Turbo,
+	openai.GPT4oMini,
+	openai.GPT4o,
+	openai.GPT4Turbo,
+	openai.GPT4Dot1Mini,
+	openai.GPT4Dot1,
+}
+
+// Models returns the known chat models for OpenAI. Other OpenAI-compatible
+// servers (such as Ollama) are asked for the models they have installed.
+func (p *OpenAIProvider) Models(ctx context.Context) ([]string, error) {
+	if p.name == "openai" {
+		return withModel(openAIModels, p.model), nil
+	}
+
+	list, err := p.client.ListModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	models := make([]string, 0, len(list.Models))
+	for _, model := range list.Models {
+		models = append(models, model.ID)
+	}
+	sort.Strings(models)
+	return withModel(models, p.model), nil
+}
+
+// withModel makes sure the configured default model is part of the list
+func withModel(models []string, model string) []string {
+	if slices.Contains(models, model) {
+		return models
+	}
+	return append([]string{model}, models...)
+}
+
+// chatRequest converts a completion request to the go-openai format
+func chatRequest(req CompletionRequest) openai.ChatCompletionRequest {
+	messages := make([]openai.ChatCompletionMessage, 0, len(req.Messages))
+	for _, message := range req.Messages {
+		messages = append(messages, openai.ChatCompletionMessage{
+			Role:    message.Role,
+			Content: message.Content,
+		})
+	}
+
+	// go-openai omits a zero temperature, which the API would read as its
+	// default of 1, so 0 is sent as the smallest positive value instead
+	temperature := req.Temperature
+	if temperature == 0 {
+		temperature = math.SmallestNonzeroFloat32
+	}
+
+	return openai.ChatCompletionRequest{
+		Model:       req.Model,
+		Messages:    messages,
+		MaxTokens:   req.MaxTokens,
+		Temperature: temperature,
+	}
+}
+
+func (p *OpenAIProvider) CreateCompletion(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	resp, err := p.client.CreateChatCompletion(ctx, chatRequest(req))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no response from %s", p.name)
+	}
+
+	return &CompletionResponse{
+		Content: resp.Choices[0].Message.Content,
+		Usage: Usage{
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+		},
+	}, nil
+}
+
+func (p *OpenAIProvider) StreamCompletion(ctx context.Context, req CompletionRequest, onDelta func(string) error) (*CompletionResponse, error) {
+	chatReq := chatRequest(req)
+	chatReq.Stream = true
+	chatReq.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+
+	stream, err := p.client.CreateChatCompletionStream(ctx, chatReq)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	var content strings.Builder
+	var usage Usage
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.Usage != nil {
+			usage = Usage{
+				PromptTokens:     resp.Usage.PromptTokens,
+				CompletionTokens: resp.Usage.CompletionTokens,
+			}
+		}
+		if len(resp.Choices) == 0 || resp.Choices[0].Delta.Content == "" {
+			continue
+		}
+
+		delta := resp.Choices[0].Delta.Content
+		content.WriteString(delta)
+		if err := onDelta(delta); err != nil {
+			return nil, err
+		}
+	}
+
+	if content.Len() == 0 {
+		return nil, fmt.Errorf("no response from %s", p.name)
+	}
+
+	return &CompletionResponse{Content: content.String(), Usage: usage}, nil
+}
@@ -0,0 +1,27 @@
+package llm
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestChatRequestKeepsZeroTemperature(t *testing.T) {
+	tests := []struct {
+		temperature float32
+		want        string
+	}{
+		{0, `"temperature":1e-45`},
+		{0.7, `"temperature":0.7`},
+	}
+
+	for _, tt := range tests {
+		encoded, err := json.Marshal(chatRequest(CompletionRequest{Model: "gpt-4o", MaxTokens: 100, Temperature: tt.temperature}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(encoded), tt.want) {
+			t.Errorf("chatRequest(temperature %v) = %s, want it to contain %s", tt.temperature, encoded, tt.want)
+		}
+	}
+}
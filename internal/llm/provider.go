@@ -0,0 +1,98 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Message roles understood by every provider
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Provider is a large language model backend able to answer chat completion requests
+type Provider interface {
+	// Name identifies the provider (e.g. "openai", "anthropic")
+	Name() string
+	// DefaultModel is the model used when a request does not specify one
+	DefaultModel() string
+	// Models lists the models that can be requested from the provider
+	Models(ctx context.Context) ([]string, error)
+	// CreateCompletion sends the conversation to the model and returns its reply
+	CreateCompletion(ctx context.Context, req CompletionRequest) (*CompletionResponse, error)
+	// StreamCompletion is like CreateCompletion but calls onDelta with each piece
+	// of the reply as it is generated. Returning an error from onDelta aborts the stream.
+	StreamCompletion(ctx context.Context, req CompletionRequest, onDelta func(string) error) (*CompletionResponse, error)
+}
+
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type CompletionRequest struct {
+	Model       string
+	Messages    []Message
+	MaxTokens   int
+	Temperature float32
+}
+
+type CompletionResponse struct {
+	Content string
+	Usage   Usage
+}
+
+// Usage reports the tokens consumed by a completion
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// TotalTokens returns the sum of prompt and completion tokens
+func (u Usage) TotalTokens() int {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+// NewProviderFromEnv creates the provider selected by LLM_PROVIDER (openai, anthropic, ollama or fake)
+func NewProviderFromEnv() (Provider, error) {
+	name := strings.ToLower(os.Getenv("LLM_PROVIDER"))
+	if name == "" {
+		name = "openai"
+	}
+
+	switch name {
+	case "openai":
+		apiKey := os.Getenv("OPENAI_API_KEY")
+		if apiKey == "" {
+			return nil, fmt.Errorf("OpenAI API key not configured (set OPENAI_API_KEY, or LLM_PROVIDER=fake to run offline)")
+		}
+		return NewOpenAIProvider(apiKey, envOrDefault("OPENAI_MODEL", defaultOpenAIModel)), nil
+	case "anthropic":
+		apiKey := os.Getenv("ANTHROPIC_API_KEY")
+		if apiKey == "" {
+			return nil, fmt.Errorf("Anthropic API key not configured (set ANTHROPIC_API_KEY)")
+		}
+		return NewAnthropicProvider(apiKey, envOrDefault("ANTHROPIC_MODEL", defaultAnthropicModel)), nil
+	case "ollama":
+		return NewOllamaProvider(
+			envOrDefault("OLLAMA_BASE_URL", defaultOllamaBaseURL),
+			os.Getenv("OLLAMA_API_KEY"),
+			envOrDefault("OLLAMA_MODEL", defaultOllamaModel),
+		), nil
+	case "fake":
+		return NewFakeProvider(), nil
+	default:
+		return nil, fmt.Errorf("unknown LLM_PROVIDER %q (expected openai, anthropic, ollama or fake)", name)
+	}
+}
+
+func envOrDefault(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,150 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+)
+
+// ReleaseNotesPromptVersion identifies the prompt used to write release
+// highlights. Bump it whenever the prompt or the changelog layout changes.
+const ReleaseNotesPromptVersion = "2025-09-12"
+
+const (
+	// highlightsInstruction asks for the highlights paragraph; the fake provider keys on it
+	highlightsInstruction = "Write the highlights paragraph of the release notes for the release described below."
+
+	// maxHighlightsPullRequests caps how many pull requests are shown to the model
+	maxHighlightsPullRequests = 150
+
+	// maxReleaseBodyLength caps how much of each pull request description goes into the prompt
+	maxReleaseBodyLength = 300
+)
+
+// GenerateReleaseNotes writes a Markdown changelog for the pull requests of a
+// release. The pull requests are grouped into sections by label and
+// conventional commit type; only the highlights paragraph at the top is
+// written by the model.
+func (s *Service) GenerateReleaseNotes(ctx context.Context, release *codehost.Release, settings Settings) (*Generation, error) {
+	sections := codehost.GroupReleasePullRequests(release.PullRequests)
+	run := &generationRun{settings: settings}
+
+	var highlights string
+	if len(release.PullRequests) > 0 {
+		var err error
+		highlights, err = s.complete(ctx, run, buildHighlightsPrompt(release, sections), settings.MaxTokens, settings.Temperature)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Generation{
+		Description:   renderChangelog(release, sections, strings.TrimSpace(highlights)),
+		Provider:      s.provider.Name(),
+		PromptVersion: ReleaseNotesPromptVersion,
+		Settings:      settings,
+		Usage:         run.usage,
+	}, nil
+}
+
+// buildHighlightsPrompt lists the grouped pull requests for the model
+func buildHighlightsPrompt(release *codehost.Release, sections map[string][]*codehost.ReleaseEntry) string {
+	var b strings.Builder
+	shown := 0
+	for _, section := range codehost.ReleaseSections {
+		entries := sections[section]
+		if len(entries) == 0 || shown >= maxHighlightsPullRequests {
+			continue
+		}
+		fmt.Fprintf(&b, "%s:\n", section)
+		for _, entry := range entries {
+			if shown >= maxHighlightsPullRequests {
+				break
+			}
+			shown++
+			fmt.Fprintf(&b, "- #%d %s", entry.Number, entry.Title)
+			if len(entry.Labels) > 0 {
+				fmt.Fprintf(&b, " [%s]", strings.Join(entry.Labels, ", "))
+			}
+			b.WriteString("\n")
+			if body := strings.TrimSpace(codehost.StripManagedRegion(entry.Body)); body != "" {
+				if len(body) > maxReleaseBodyLength {
+					body = strings.ToValidUTF8(body[:maxReleaseBodyLength], "") + "..."
+				}
+				fmt.Fprintf(&b, "  %s\n", strings.Join(strings.Fields(body), " "))
+			}
+		}
+		b.WriteString("\n")
+	}
+	if shown < len(release.PullRequests) {
+		fmt.Fprintf(&b, "(%d more pull requests are not listed.)\n", len(release.PullRequests)-shown)
+	}
+
+	return fmt.Sprintf(`%s
+
+Repository: %s
+Release: %s...%s (%d commits, %d merged pull requests)
+%s
+Merged pull requests, grouped by kind:
+
+%s
+Write a single paragraph of three to five sentences for the people upgrading: lead with breaking changes when there are any, then the most significant features and fixes. Mention pull requests by number (#123) where it helps. Do not list every change, do not invent changes that are not listed above, and reply with the paragraph only, without a heading.`,
+		highlightsInstruction,
+		release.Repository,
+		release.From,
+		release.To,
+		release.Commits,
+		len(release.PullRequests),
+		releaseTruncationNote(release),
+		b.String(),
+	)
+}
+
+// releaseTruncationNote warns the model when the list of pull requests may be incomplete
+func releaseTruncationNote(release *codehost.Release) string {
+	if !release.Truncated {
+		return ""
+	}
+	return fmt.Sprintf("Note: the following data is incomplete for this release: %s.\n", strings.Join(release.TruncatedLists, ", "))
+}
+
+// renderChangelog lays out the release notes: the highlights, then a section
+// per kind of change with a line per pull request
+func renderChangelog(release *codehost.Release, sections map[string][]*codehost.ReleaseEntry, highlights string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s\n\n", release.To)
+	if highlights != "" {
+		fmt.Fprintf(&b, "### Highlights\n\n%s\n\n", highlights)
+	}
+	if len(release.PullRequests) == 0 {
+		fmt.Fprintf(&b, "No pull requests were merged between %s and %s.\n\n", release.From, release.To)
+	}
+
+	for _, section := range codehost.ReleaseSections {
+		entries := sections[section]
+		if len(entries) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "### %s\n\n", section)
+		for _, entry := range entries {
+			b.WriteString("- ")
+			if entry.Scope != "" {
+				fmt.Fprintf(&b, "**%s:** ", entry.Scope)
+			}
+			fmt.Fprintf(&b, "%s ([#%d](%s))", entry.Summary, entry.Number, entry.URL)
+			if entry.Author != "" {
+				fmt.Fprintf(&b, " by @%s", entry.Author)
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+
+	if release.Truncated {
+		fmt.Fprintf(&b, "_Some pull requests may be missing: the %s could not be read in full._\n\n", strings.Join(release.TruncatedLists, ", "))
+	}
+	fmt.Fprintf(&b, "**Full changelog**: [%s...%s](%s)\n", release.From, release.To, release.URL)
+	return b.String()
+}
@@ -0,0 +1,279 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+)
+
+// ReviewPromptVersion identifies the prompt used to review files. Bump it
+// whenever the prompt or the reply format changes.
+const ReviewPromptVersion = "2025-09-13"
+
+const (
+	// reviewInstruction opens every file review prompt; the fake provider keys on it
+	reviewInstruction = "Review the following change to one file of a pull request."
+
+	// maxReviewFiles caps how many files of a pull request are reviewed
+	maxReviewFiles = 30
+
+	// maxFileComments caps how many comments are kept for one file
+	maxFileComments = 5
+
+	// maxReviewBodyLength caps how much of the pull request description goes into each prompt
+	maxReviewBodyLength = 1000
+)
+
+// Review severities, from most to least serious
+var reviewSeverities = []string{"bug", "security", "performance", "maintainability", "nit"}
+
+// Review is a generated first-pass review of a pull request: comments anchored
+// to lines of its diff, for a person to accept or discard before submitting
+type Review struct {
+	Comments      []*codehost.ReviewComment `json:"comments"`
+	ReviewedFiles []string                  `json:"reviewed_files"`
+	SkippedFiles  []string                  `json:"skipped_files,omitempty"` // removed, generated, too large or without a patch
+	Provider      string                    `json:"provider"`
+	PromptVersion string                    `json:"prompt_version"`
+	Settings      Settings                  `json:"settings"`
+	Usage         Usage                     `json:"usage"`
+}
+
+// reviewReply is one issue as the model reports it
+type reviewReply struct {
+	Line     any    `json:"line"` // "R12" or "L7"; a bare number means the new version
+	Severity string `json:"severity"`
+	Comment  string `json:"comment"`
+}
+
+// GenerateReview asks the model for issues in each changed file's patch, one
+// file per prompt. Every comment the model returns is checked against the
+// parsed hunks of the file, and comments on lines outside the diff are
+// dropped, so all comments can be submitted as a review. progress (optional)
+// is called as each file is reviewed.
+func (s *Service) GenerateReview(ctx context.Context, prData *codehost.ChangeRequest, settings Settings, progress ProgressFunc) (*Review, error) {
+	var files []*codehost.File
+	var skipped []string
+	for _, file := range sortFilesByPriority(prData.ChangedFiles) {
+		// A patch that does not fit a summary batch is too large to review in one prompt
+		if file.Status == "removed" || file.Patch == "" || classifyFile(file.Filename) == categoryGenerated ||
+			len(files) >= maxReviewFiles || estimateTokens(file.Patch) > s.batchTokenBudget {
+			skipped = append(skipped, file.Filename)
+			continue
+		}
+		files = append(files, file)
+	}
+
+	run := &generationRun{settings: settings, tracker: newProgressTracker(progress, len(files))}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	fileComments := make([][]*codehost.ReviewComment, len(files))
+	sem := make(chan struct{}, summaryConcurrency)
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+
+	for i, file := range files {
+		wg.Add(1)
+		go func(i int, file *codehost.File) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				return
+			}
+
+			comments, err := s.reviewFile(ctx, run, prData, file)
+			if err != nil {
+				once.Do(func() {
+					firstErr = fmt.Errorf("failed to review %s: %w", file.Filename, err)
+					cancel()
+				})
+				return
+			}
+			fileComments[i] = comments
+			run.tracker.step()
+		}(i, file)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	review := &Review{
+		Comments:      []*codehost.ReviewComment{},
+		SkippedFiles:  skipped,
+		Provider:      s.provider.Name(),
+		PromptVersion: ReviewPromptVersion,
+		Settings:      settings,
+		Usage:         run.usage,
+	}
+	for i, file := range files {
+		review.ReviewedFiles = append(review.ReviewedFiles, file.Filename)
+		review.Comments = append(review.Comments, fileComments[i]...)
+	}
+	return review, nil
+}
+
+// reviewFile asks the model for issues in one file and anchors them to its diff
+func (s *Service) reviewFile(ctx context.Context, run *generationRun, prData *codehost.ChangeRequest, file *codehost.File) ([]*codehost.ReviewComment, error) {
+	patch, err := codehost.ParsePatch(file.Patch)
+	if err != nil {
+		log.Printf("Skipping review of %s in %s: %v", file.Filename, prData.Repository, err)
+		return nil, nil
+	}
+
+	reply, err := s.complete(ctx, run, buildReviewPrompt(prData, file, patch), run.settings.MaxTokens, run.settings.Temperature)
+	if err != nil {
+		return nil, err
+	}
+
+	replies, err := parseReviewReply(reply)
+	if err != nil {
+		log.Printf("Ignoring unreadable review of %s in %s: %v", file.Filename, prData.Repository, err)
+		return nil, nil
+	}
+
+	var comments []*codehost.ReviewComment
+	seen := map[string]bool{}
+	for _, r := range replies {
+		side, number, ok := parseReviewLine(r.Line)
+		body := strings.TrimSpace(r.Comment)
+		if !ok || body == "" {
+			continue
+		}
+		line := patch.Find(side, number)
+		if line == nil {
+			log.Printf("Dropping review comment on %s %s%d, which is not in the diff", file.Filename, side[:1], number)
+			continue
+		}
+		key := fmt.Sprintf("%s%d", side, number)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		comments = append(comments, &codehost.ReviewComment{
+			Path:     file.Filename,
+			Line:     line.Line(),
+			Side:     line.Side(),
+			Severity: normalizeSeverity(r.Severity),
+			Body:     body,
+			DiffLine: line.Op + line.Text,
+		})
+		if len(comments) == maxFileComments {
+			break
+		}
+	}
+
+	// Comments read best in the order of the diff
+	sort.SliceStable(comments, func(i, j int) bool {
+		return patch.Find(comments[i].Side, comments[i].Line).Position < patch.Find(comments[j].Side, comments[j].Line).Position
+	})
+	return comments, nil
+}
+
+// buildReviewPrompt shows the model one file's patch with the line references to comment on
+func buildReviewPrompt(prData *codehost.ChangeRequest, file *codehost.File, patch *codehost.Patch) string {
+	body := strings.TrimSpace(codehost.StripManagedRegion(prData.Body))
+	if len(body) > maxReviewBodyLength {
+		body = strings.ToValidUTF8(body[:maxReviewBodyLength], "") + "..."
+	}
+	if body == "" {
+		body = "(none)"
+	}
+	filename := file.Filename
+	if file.PreviousFilename != "" {
+		filename = fmt.Sprintf("%s (renamed from %s)", file.Filename, file.PreviousFilename)
+	}
+
+	return fmt.Sprintf(`%s
+
+Repository: %s
+Pull request: %s
+Description:
+%s
+
+File: %s (%s, %s, +%d/-%d)
+
+Every line of the diff below starts with the reference a comment on it must use: R12 is line 12 of the new version of the file (an added or unchanged line), L7 is line 7 of the old version (a deleted line).
+
+%s
+Report only concrete problems a careful reviewer would raise: bugs, incorrect logic, unhandled errors or edge cases, security issues, race conditions, performance problems and clearly misleading code. Do not comment on formatting, do not praise, do not repeat a point, and prefer commenting on added lines. Report at most %d issues, the most important first.
+
+Reply with a JSON array only, without Markdown code fences. Each issue is an object like {"line": "R12", "severity": "bug", "comment": "What is wrong and how to fix it."}, where severity is one of %s. When there is nothing worth raising, reply with [].`,
+		reviewInstruction,
+		prData.Repository,
+		prData.Title,
+		body,
+		filename,
+		file.Status,
+		classifyFile(file.Filename),
+		file.Additions,
+		file.Deletions,
+		patch.Annotate(),
+		maxFileComments,
+		strings.Join(reviewSeverities, ", "),
+	)
+}
+
+// parseReviewReply reads the JSON array of issues from the model's reply,
+// tolerating code fences or prose around it
+func parseReviewReply(reply string) ([]reviewReply, error) {
+	start := strings.Index(reply, "[")
+	end := strings.LastIndex(reply, "]")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("no JSON array in reply %q", reply)
+	}
+
+	var replies []reviewReply
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &replies); err != nil {
+		return nil, fmt.Errorf("failed to parse reply: %w", err)
+	}
+	return replies, nil
+}
+
+// parseReviewLine reads a line reference such as "R12" or "L7"
+func parseReviewLine(value any) (side string, line int, ok bool) {
+	switch v := value.(type) {
+	case float64:
+		return codehost.SideRight, int(v), v > 0 && v == float64(int(v))
+	case string:
+		v = strings.ToUpper(strings.TrimSpace(v))
+		side = codehost.SideRight
+		switch {
+		case strings.HasPrefix(v, "R"):
+			v = v[1:]
+		case strings.HasPrefix(v, "L"):
+			side, v = codehost.SideLeft, v[1:]
+		}
+		line, err := strconv.Atoi(v)
+		return side, line, err == nil && line > 0
+	default:
+		return "", 0, false
+	}
+}
+
+// normalizeSeverity lowercases a severity, dropping those not asked for
+func normalizeSeverity(severity string) string {
+	severity = strings.ToLower(strings.TrimSpace(severity))
+	for _, known := range reviewSeverities {
+		if severity == known {
+			return known
+		}
+	}
+	return ""
+}
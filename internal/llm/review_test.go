@@ -0,0 +1,157 @@
+package llm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+)
+
+// replyProvider answers every completion with the same reply
+type replyProvider struct {
+	FakeProvider
+	reply string
+}
+
+func (p *replyProvider) CreateCompletion(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	return &CompletionResponse{Content: p.reply}, nil
+}
+
+const reviewPatch = `@@ -1,4 +1,5 @@
+ package main
+-import "fmt"
++import (
++	"fmt"
++)
+ `
+
+// reviewReplyText comments on lines inside and outside the diff, wrapped in
+// the prose and code fences models tend to add
+const reviewReplyText = "Here is what I found:\n```json\n" + `[
+  {"line": "R3", "severity": "Nit", "comment": "  The parentheses are not needed for one import. "},
+  {"line": "l2", "severity": "bug", "comment": "The removed import is still used."},
+  {"line": 2, "severity": "style", "comment": "A bare number is a line of the new version."},
+  {"line": "R40", "severity": "bug", "comment": "Line 40 is not in the diff."},
+  {"line": "L5", "severity": "bug", "comment": "Old line 5 is not in the diff."},
+  {"line": "R3", "severity": "bug", "comment": "A second comment on the same line."},
+  {"line": "R1", "severity": "bug", "comment": "   "},
+  {"line": "main.go", "severity": "bug", "comment": "Not a line reference."}
+]` + "\n```"
+
+func TestGenerateReview(t *testing.T) {
+	service := NewService(&replyProvider{reply: reviewReplyText})
+	prData := &codehost.ChangeRequest{
+		Title:      "Group imports",
+		Repository: "octo-org/hello",
+		ChangedFiles: []*codehost.File{
+			{Filename: "main.go", Status: "modified", Patch: reviewPatch},
+			{Filename: "old.go", Status: "removed", Patch: "@@ -1 +0,0 @@\n-package old"},
+			{Filename: "logo.png", Status: "added"},
+			{Filename: "go.sum", Status: "modified", Patch: "@@ -1 +1 @@\n-a\n+b"},
+		},
+	}
+
+	review, err := service.GenerateReview(context.Background(), prData, service.DefaultSettings(), nil)
+	if err != nil {
+		t.Fatalf("GenerateReview() error = %v", err)
+	}
+	if len(review.ReviewedFiles) != 1 || review.ReviewedFiles[0] != "main.go" {
+		t.Errorf("GenerateReview() reviewed %v, want only main.go", review.ReviewedFiles)
+	}
+	if len(review.SkippedFiles) != 3 {
+		t.Errorf("GenerateReview() skipped %v, want old.go, logo.png and go.sum", review.SkippedFiles)
+	}
+
+	// Comments outside the diff, repeated, empty or without a usable line
+	// reference are dropped, and the rest follow the order of the diff
+	want := []codehost.ReviewComment{
+		{Path: "main.go", Line: 2, Side: codehost.SideLeft, Severity: "bug", Body: "The removed import is still used.", DiffLine: `-import "fmt"`},
+		{Path: "main.go", Line: 2, Side: codehost.SideRight, Severity: "", Body: "A bare number is a line of the new version.", DiffLine: "+import ("},
+		{Path: "main.go", Line: 3, Side: codehost.SideRight, Severity: "nit", Body: "The parentheses are not needed for one import.", DiffLine: "+\t\"fmt\""},
+	}
+	if len(review.Comments) != len(want) {
+		t.Fatalf("GenerateReview() returned %d comments, want %d: %+v", len(review.Comments), len(want), review.Comments)
+	}
+	for i, comment := range review.Comments {
+		if *comment != want[i] {
+			t.Errorf("comment %d = %+v, want %+v", i, *comment, want[i])
+		}
+	}
+}
+
+func TestGenerateReviewIgnoresUnreadableReply(t *testing.T) {
+	service := NewService(&replyProvider{reply: "The change looks good to me."})
+	prData := &codehost.ChangeRequest{ChangedFiles: []*codehost.File{{Filename: "main.go", Status: "modified", Patch: reviewPatch}}}
+
+	review, err := service.GenerateReview(context.Background(), prData, service.DefaultSettings(), nil)
+	if err != nil {
+		t.Fatalf("GenerateReview() error = %v", err)
+	}
+	if len(review.Comments) != 0 || len(review.ReviewedFiles) != 1 {
+		t.Errorf("GenerateReview() = %d comments on %v, want none on main.go", len(review.Comments), review.ReviewedFiles)
+	}
+}
+
+func TestParseReviewReply(t *testing.T) {
+	replies, err := parseReviewReply(reviewReplyText)
+	if err != nil {
+		t.Fatalf("parseReviewReply() error = %v", err)
+	}
+	if len(replies) != 8 || replies[0].Line != "R3" || replies[2].Line != float64(2) {
+		t.Errorf("parseReviewReply() = %+v", replies)
+	}
+
+	if replies, err := parseReviewReply("[]"); err != nil || len(replies) != 0 {
+		t.Errorf("parseReviewReply(%q) = %+v, %v, want no replies", "[]", replies, err)
+	}
+	for _, reply := range []string{"", "Nothing to report.", "] [", `[{"line": "R1", "comment": }]`} {
+		if _, err := parseReviewReply(reply); err == nil {
+			t.Errorf("parseReviewReply(%q) returned no error", reply)
+		}
+	}
+}
+
+func TestParseReviewLine(t *testing.T) {
+	tests := []struct {
+		value any
+		side  string
+		line  int
+		ok    bool
+	}{
+		{"R12", codehost.SideRight, 12, true},
+		{" l7 ", codehost.SideLeft, 7, true},
+		{"12", codehost.SideRight, 12, true},
+		{float64(12), codehost.SideRight, 12, true},
+		{float64(1.5), codehost.SideRight, 1, false},
+		{float64(0), codehost.SideRight, 0, false},
+		{"R0", codehost.SideRight, 0, false},
+		{"R-3", codehost.SideRight, -3, false},
+		{"X12", codehost.SideRight, 0, false},
+		{nil, "", 0, false},
+		{true, "", 0, false},
+	}
+
+	for _, tt := range tests {
+		side, line, ok := parseReviewLine(tt.value)
+		if ok != tt.ok || (ok && (side != tt.side || line != tt.line)) {
+			t.Errorf("parseReviewLine(%#v) = %q, %d, %v, want %q, %d, %v", tt.value, side, line, ok, tt.side, tt.line, tt.ok)
+		}
+	}
+}
+
+func TestNormalizeSeverity(t *testing.T) {
+	tests := map[string]string{
+		"bug":           "bug",
+		" Security ":    "security",
+		"PERFORMANCE":   "performance",
+		"nit":           "nit",
+		"style":         "",
+		"":              "",
+		"bug, probably": "",
+	}
+	for severity, want := range tests {
+		if got := normalizeSeverity(severity); got != want {
+			t.Errorf("normalizeSeverity(%q) = %q, want %q", severity, got, want)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+)
+
+// PromptVersion identifies the prompts used to generate descriptions. Bump it
+// whenever the prompts change in a way that affects the output.
+const PromptVersion = "2025-09-11"
+
+const systemPrompt = "You are an expert software developer and technical writer. Please create comprehensive, professional pull request descriptions based on pull request data from GitHub, GitLab or Gitea. Focus on clarity, technical accuracy, and helpfulness for reviewers."
+
+// Service generates pull request descriptions with the configured LLM provider
+type Service struct {
+	provider         Provider
+	diffTokenBudget  int
+	batchTokenBudget int
+}
+
+func NewService(provider Provider) *Service {
+	return &Service{
+		provider:         provider,
+		diffTokenBudget:  envTokenBudget("LLM_DIFF_TOKEN_BUDGET", defaultDiffTokenBudget),
+		batchTokenBudget: envTokenBudget("LLM_BATCH_TOKEN_BUDGET", defaultBatchTokenBudget),
+	}
+}
+
+// ProviderName returns the name of the provider backing the service
+func (s *Service) ProviderName() string {
+	return s.provider.Name()
+}
+
+// envTokenBudget reads a non-negative token budget from the environment
+func envTokenBudget(name string, fallback int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 0 {
+		log.Printf("Warning: Invalid %s value '%s', defaulting to %d", name, value, fallback)
+		return fallback
+	}
+	return parsed
+}
+
+// GeneratePRDescription generates a description for the pull request with the
+// given settings. When the diff does not fit in the prompt budget, the changed
+// files are first summarised in batches and the final description is written
+// from those summaries. progress (optional) is called as each stage completes.
+func (s *Service) GeneratePRDescription(ctx context.Context, prData *codehost.ChangeRequest, settings Settings, progress ProgressFunc) (*Generation, error) {
+	return s.generate(ctx, prData, settings, progress, nil)
+}
+
+// StreamPRDescription works like GeneratePRDescription but streams the final
+// description, calling onToken with each piece as the model produces it.
+// Cancelling ctx cancels the upstream request.
+func (s *Service) StreamPRDescription(ctx context.Context, prData *codehost.ChangeRequest, settings Settings, progress ProgressFunc, onToken func(string) error) (*Generation, error) {
+	return s.generate(ctx, prData, settings, progress, onToken)
+}
+
+func (s *Service) generate(ctx context.Context, prData *codehost.ChangeRequest, settings Settings, progress ProgressFunc, onToken func(string) error) (*Generation, error) {
+	run := &generationRun{settings: settings}
+	var changes string
+
+	if diffTokens(prData.ChangedFiles) <= s.diffTokenBudget {
+		run.tracker = newProgressTracker(progress, 1)
+		changes = "Diff of the changed files (source files first, then tests, then generated code):\n\n" +
+			buildDiffContext(prData.ChangedFiles, s.diffTokenBudget)
+	} else {
+		batches, generated := planBatches(prData.ChangedFiles, s.batchTokenBudget)
+		run.tracker = newProgressTracker(progress, len(batches)+countMergeSteps(len(batches), s.summaryGroupSize())+1)
+
+		summaries, err := s.summariseChanges(ctx, run, prData, batches, generated)
+		if err != nil {
+			return nil, err
+		}
+		changes = "The diff is too large to include, so here are summaries of the changed files written from the full patches:\n\n" + summaries
+	}
+
+	prompt := buildDescriptionPrompt(prData, changes)
+	var description string
+	var err error
+	if onToken != nil {
+		description, err = s.stream(ctx, run, prompt, settings.MaxTokens, settings.Temperature, onToken)
+	} else {
+		description, err = s.complete(ctx, run, prompt, settings.MaxTokens, settings.Temperature)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	run.tracker.step()
+	return &Generation{
+		Description:   description,
+		Provider:      s.provider.Name(),
+		PromptVersion: PromptVersion,
+		Settings:      settings,
+		Usage:         run.usage,
+	}, nil
+}
+
+// buildDescriptionPrompt creates the detailed prompt with the pull request data
+// and the rendered changes (either the diff itself or summaries of it)
+func buildDescriptionPrompt(prData *codehost.ChangeRequest, changes string) string {
+	return fmt.Sprintf(`You are a helpful assistant that generates professional pull request descriptions.
+
+Given the following pull request data:
+
+Repository: %s
+PR Number: %d
+Title: %s
+Current Description: %s
+State: %s
+Created: %s
+Updated: %s
+Additions: %d lines
+Deletions: %d lines
+Changed Files: %d files
+Labels: %s
+Author: %s
+Assignees: %s
+
+Commits (oldest first):
+%s
+
+Contributors (derived from commit authors and Co-authored-by trailers):
+%s
+
+Linked issues (mentioned in the description and commit messages):
+%s
+%s
+%s
+
+%s`,
+		prData.Repository,
+		prData.PRNumber,
+		prData.Title,
+		codehost.StripManagedRegion(prData.Body),
+		prData.State,
+		prData.CreatedAt.Format("2006-01-02 15:04:05"),
+		prData.UpdatedAt.Format("2006-01-02 15:04:05"),
+		prData.Additions,
+		prData.Deletions,
+		prData.TotalChangedFiles,
+		codehost.GetLabelsString(prData.Labels),
+		codehost.GetUserString(prData.User),
+		codehost.GetAssigneesString(prData.Assignees),
+		codehost.GetCommitsString(prData.Commits),
+		codehost.GetContributorsString(prData.Contributors),
+		codehost.GetLinkedIssuesString(prData.LinkedIssues, maxIssueBodyLength),
+		truncationNote(prData),
+		changes,
+		descriptionStructure(prData.Template),
+	)
+}
+
+// descriptionStructure tells the model how to lay out the description: as the
+// repository's pull request template when it has one, which reviewers expect
+// to see filled in, or with the built-in sections otherwise
+func descriptionStructure(template string) string {
+	if strings.TrimSpace(template) == "" {
+		return `Base the "Changes Made" section on the commit messages and the changes above rather than on the title alone; treat the commit messages as ground truth.
+
+Please structure the description with the following sections:
+1. **Summary** - A brief, high-level overview of the purpose of this pull request.
+2. **Changes Made** - A clear and itemized list of the specific modifications made in this PR.
+3. **Motivation/Context:** Explain *why* these changes were necessary (e.g., bug fix, new feature, refactoring, performance improvement), drawing on the linked issues above when there are any.
+4. **How to Test (Optional but Recommended):** Provide instructions for how a reviewer can verify the changes.
+5. **Potential Impacts/Considerations:** Mention any known side effects, performance implications, or areas that require particular attention during review.
+6. **Relevant Links (Optional):** List the linked issues above using their exact Markdown links, with "Fixes" before the ones this pull request closes. Do not invent issue numbers or URLs; leave this section out when there are no linked issues or other links in the pull request data.
+7. **Contributors** - List of contributors to the PR with their contribution counts, exactly as given in the contributor data above
+
+Ensure the description is easy to read, uses clear language, and is formatted for readability (e.g., bullet points, headings).
+Make the description clear, professional, and helpful for code reviewers. Focus on the "why" and "what" of the changes. Include the contributors section to acknowledge all team members who contributed to this PR.`
+	}
+
+	return fmt.Sprintf(`Describe the changes based on the commit messages and the changes above rather than on the title alone; treat the commit messages as ground truth.
+
+This repository has a pull request template. Please write the description by filling in the template below:
+- Keep every heading of the template, in the same order and with the same wording. Do not add, remove or rename sections.
+- Keep every checklist item ("- [ ] ...") verbatim. Check it ("- [x] ...") only when the pull request data clearly shows it is done, and leave it unchecked otherwise.
+- Replace the HTML comments and placeholder text under each heading with content about this pull request. Write "N/A" under a heading that does not apply.
+- Where the template asks for related issues, use the linked issues above with their exact Markdown links, writing "Fixes" before the ones this pull request closes. Do not invent issue numbers or URLs.
+- Output only the filled-in template, without wrapping it in a code block.
+
+%s
+%s
+%s
+
+Make the description clear, professional, and helpful for code reviewers. Focus on the "why" and "what" of the changes.`, templateStart, strings.TrimSpace(template), templateEnd)
+}
+
+// maxIssueBodyLength caps how much of each linked issue's description goes into the prompt
+const maxIssueBodyLength = 1000
+
+// templateStart and templateEnd delimit the pull request template in the prompt
+const (
+	templateStart = "<pull_request_template>"
+	templateEnd   = "</pull_request_template>"
+)
+
+// truncationNote warns the model when some of the PR data could not be fetched in full
+func truncationNote(prData *codehost.ChangeRequest) string {
+	if !prData.Truncated {
+		return ""
+	}
+	return fmt.Sprintf("Note: the following data is incomplete for this pull request: %s. Do not assume the lists above are exhaustive.\n", strings.Join(prData.TruncatedLists, ", "))
+}
+
+// completionRequest builds the chat request for a prompt with the run's model
+func completionRequest(run *generationRun, prompt string, maxTokens int, temperature float32) CompletionRequest {
+	return CompletionRequest{
+		Model: run.settings.Model,
+		Messages: []Message{
+			{
+				Role:    RoleSystem,
+				Content: systemPrompt,
+			},
+			{
+				Role:    RoleUser,
+				Content: prompt,
+			},
+		},
+		MaxTokens:   maxTokens,
+		Temperature: temperature,
+	}
+}
+
+// complete sends a single chat completion request and returns the generated text
+func (s *Service) complete(ctx context.Context, run *generationRun, prompt string, maxTokens int, temperature float32) (string, error) {
+	resp, err := s.provider.CreateCompletion(ctx, completionRequest(run, prompt, maxTokens, temperature))
+	if err != nil {
+		log.Printf("%s API error: %v", s.provider.Name(), err)
+		return "", fmt.Errorf("failed to generate description: %w", err)
+	}
+
+	// Extract the generated text
+	run.addUsage(resp.Usage)
+	return resp.Content, nil
+}
+
+// stream sends a streaming chat completion request, forwarding each piece of
+// the reply to onToken, and returns the complete generated text
+func (s *Service) stream(ctx context.Context, run *generationRun, prompt string, maxTokens int, temperature float32, onToken func(string) error) (string, error) {
+	resp, err := s.provider.StreamCompletion(ctx, completionRequest(run, prompt, maxTokens, temperature), onToken)
+	if err != nil {
+		log.Printf("%s API error: %v", s.provider.Name(), err)
+		return "", fmt.Errorf("failed to generate description: %w", err)
+	}
+
+	run.addUsage(resp.Usage)
+	return resp.Content, nil
+}
@@ -0,0 +1,105 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+const (
+	defaultTemperature = 0.7
+	defaultMaxTokens   = 1000
+
+	maxTemperature = 2.0
+	maxMaxTokens   = 16384
+)
+
+// Settings controls how the final description is generated
+type Settings struct {
+	Model       string  `json:"model"`
+	Temperature float32 `json:"temperature"`
+	MaxTokens   int     `json:"max_tokens"`
+}
+
+// Generation is a generated description together with how it was produced
+type Generation struct {
+	Description   string   `json:"description"`
+	Provider      string   `json:"provider"`
+	PromptVersion string   `json:"prompt_version"`
+	Settings      Settings `json:"settings"`
+	Usage         Usage    `json:"usage"`
+}
+
+// DefaultSettings returns the settings used when nothing is configured
+func (s *Service) DefaultSettings() Settings {
+	return Settings{
+		Model:       s.provider.DefaultModel(),
+		Temperature: defaultTemperature,
+		MaxTokens:   defaultMaxTokens,
+	}
+}
+
+// Models lists the models offered by the provider
+func (s *Service) Models(ctx context.Context) ([]string, error) {
+	return s.provider.Models(ctx)
+}
+
+// ValidateModel checks that the provider offers the model
+func (s *Service) ValidateModel(ctx context.Context, model string) error {
+	models, err := s.provider.Models(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list models: %w", err)
+	}
+	if !slices.Contains(models, model) {
+		return fmt.Errorf("model %q is not available from provider %s", model, s.provider.Name())
+	}
+	return nil
+}
+
+// ValidateTemperature checks that the temperature is within the accepted
+// range. 0 is accepted and makes the output as deterministic as the provider
+// allows.
+func ValidateTemperature(temperature float32) error {
+	if temperature < 0 || temperature > maxTemperature {
+		return fmt.Errorf("temperature must be between 0 and %.1f", maxTemperature)
+	}
+	return nil
+}
+
+// ValidateMaxTokens checks that the token limit is within the accepted range
+func ValidateMaxTokens(maxTokens int) error {
+	if maxTokens < 1 || maxTokens > maxMaxTokens {
+		return fmt.Errorf("max tokens must be between 1 and %d", maxMaxTokens)
+	}
+	return nil
+}
+
+// ValidateSettings checks every field of the settings against the provider
+func (s *Service) ValidateSettings(ctx context.Context, settings Settings) error {
+	if err := s.ValidateModel(ctx, settings.Model); err != nil {
+		return err
+	}
+	if err := ValidateTemperature(settings.Temperature); err != nil {
+		return err
+	}
+	return ValidateMaxTokens(settings.MaxTokens)
+}
+
+// generationRun holds the state shared by all the completions of one generation
+type generationRun struct {
+	settings Settings
+	tracker  *progressTracker
+
+	mu    sync.Mutex
+	usage Usage
+}
+
+// addUsage accumulates the tokens consumed by one completion
+func (r *generationRun) addUsage(usage Usage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.usage.PromptTokens += usage.PromptTokens
+	r.usage.CompletionTokens += usage.CompletionTokens
+}
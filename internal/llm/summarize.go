@@ -0,0 +1,200 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+)
+
+const (
+	// defaultBatchTokenBudget is used when LLM_BATCH_TOKEN_BUDGET is not set
+	defaultBatchTokenBudget = 8000
+
+	// maxSummaryTokens caps the length of each batch or merge summary
+	maxSummaryTokens = 400
+
+	// summaryConcurrency limits how many batch summaries are requested at once
+	summaryConcurrency = 4
+)
+
+// ProgressFunc receives the number of completed stages and the total number of
+// stages needed to generate a description
+type ProgressFunc func(done, total int)
+
+// progressTracker counts completed stages and reports them to a ProgressFunc
+type progressTracker struct {
+	mu       sync.Mutex
+	done     int
+	total    int
+	progress ProgressFunc
+}
+
+func newProgressTracker(progress ProgressFunc, total int) *progressTracker {
+	t := &progressTracker{total: total, progress: progress}
+	if progress != nil {
+		progress(0, total)
+	}
+	return t
+}
+
+// step marks one more stage as completed
+func (t *progressTracker) step() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.done++
+	if t.progress != nil {
+		t.progress(t.done, t.total)
+	}
+}
+
+// planBatches groups the non-generated files into batches whose diffs fit the
+// token budget. Generated files are only listed by name since summarising
+// them is rarely useful.
+func planBatches(files []*codehost.File, budget int) (batches [][]*codehost.File, generated []string) {
+	var current []*codehost.File
+	used := 0
+
+	for _, file := range sortFilesByPriority(files) {
+		if classifyFile(file.Filename) == categoryGenerated {
+			generated = append(generated, file.Filename)
+			continue
+		}
+
+		cost := estimateTokens(fileEntry(file))
+		if len(current) > 0 && used+cost > budget {
+			batches = append(batches, current)
+			current = nil
+			used = 0
+		}
+		current = append(current, file)
+		used += cost
+	}
+
+	if len(current) > 0 {
+		batches = append(batches, current)
+	}
+
+	return batches, generated
+}
+
+// summaryGroupSize is the number of summaries that fit into one merge prompt
+func (s *Service) summaryGroupSize() int {
+	size := s.diffTokenBudget / maxSummaryTokens
+	if size < 2 {
+		return 2
+	}
+	return size
+}
+
+// countMergeSteps returns how many merge calls are needed to reduce n summaries
+// to a number that fits into the final prompt
+func countMergeSteps(n, groupSize int) int {
+	steps := 0
+	for n > groupSize {
+		n = (n + groupSize - 1) / groupSize
+		steps += n
+	}
+	return steps
+}
+
+// summariseChanges summarises the changed files batch by batch (map), then merges
+// the summaries until they fit into the final prompt (reduce)
+func (s *Service) summariseChanges(ctx context.Context, run *generationRun, prData *codehost.ChangeRequest, batches [][]*codehost.File, generated []string) (string, error) {
+	groupSize := s.summaryGroupSize()
+	summaries, err := s.summariseBatches(ctx, run, prData, batches)
+	if err != nil {
+		return "", err
+	}
+
+	for len(summaries) > groupSize {
+		var merged []string
+		for start := 0; start < len(summaries); start += groupSize {
+			end := min(start+groupSize, len(summaries))
+			summary, err := s.mergeSummaries(ctx, run, prData, summaries[start:end])
+			if err != nil {
+				return "", err
+			}
+			merged = append(merged, summary)
+			run.tracker.step()
+		}
+		summaries = merged
+	}
+
+	result := strings.Join(summaries, "\n\n")
+	if len(generated) > 0 {
+		result += fmt.Sprintf("\n\nGenerated files (not summarised): %s", strings.Join(generated, ", "))
+	}
+	return result, nil
+}
+
+// summariseBatches requests a summary for every batch with bounded concurrency
+func (s *Service) summariseBatches(ctx context.Context, run *generationRun, prData *codehost.ChangeRequest, batches [][]*codehost.File) ([]string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	summaries := make([]string, len(batches))
+	sem := make(chan struct{}, summaryConcurrency)
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+
+	for i, batch := range batches {
+		wg.Add(1)
+		go func(i int, batch []*codehost.File) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				return
+			}
+
+			prompt := fmt.Sprintf(`Summarise the following part (%d of %d) of the diff of pull request "%s" in %s.
+
+Write concise bullet points grouped by file, describing what changed and, when it is evident from the code, why. Do not speculate beyond the diff.
+
+%s`, i+1, len(batches), prData.Title, prData.Repository, buildDiffContext(batch, s.batchTokenBudget))
+
+			summary, err := s.complete(ctx, run, prompt, maxSummaryTokens, 0.2)
+			if err != nil {
+				once.Do(func() {
+					firstErr = fmt.Errorf("failed to summarise batch %d of %d: %w", i+1, len(batches), err)
+					cancel()
+				})
+				return
+			}
+			summaries[i] = summary
+			run.tracker.step()
+		}(i, batch)
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return summaries, nil
+}
+
+// mergeSummaries condenses several batch summaries into one
+func (s *Service) mergeSummaries(ctx context.Context, run *generationRun, prData *codehost.ChangeRequest, summaries []string) (string, error) {
+	prompt := fmt.Sprintf(`The following are summaries of different parts of the diff of pull request "%s" in %s.
+
+Merge them into a single concise list of bullet points, keeping every distinct change and dropping repetition.
+
+%s`, prData.Title, prData.Repository, strings.Join(summaries, "\n\n---\n\n"))
+
+	summary, err := s.complete(ctx, run, prompt, maxSummaryTokens, 0.2)
+	if err != nil {
+		return "", fmt.Errorf("failed to merge summaries: %w", err)
+	}
+	return summary, nil
+}
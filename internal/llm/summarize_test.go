@@ -0,0 +1,85 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+)
+
+func TestSortFilesByPriority(t *testing.T) {
+	files := []*codehost.File{
+		{Filename: "go.sum"},
+		{Filename: "b_test.go"},
+		{Filename: "b.go"},
+		{Filename: "a_test.go"},
+		{Filename: "a.go"},
+	}
+
+	var got []string
+	for _, file := range sortFilesByPriority(files) {
+		got = append(got, file.Filename)
+	}
+	if want := "b.go a.go b_test.go a_test.go go.sum"; strings.Join(got, " ") != want {
+		t.Errorf("sortFilesByPriority() = %v, want %s", got, want)
+	}
+	if files[0].Filename != "go.sum" {
+		t.Errorf("sortFilesByPriority() reordered its argument")
+	}
+}
+
+func TestPlanBatches(t *testing.T) {
+	small := func(name string) *codehost.File { return &codehost.File{Filename: name, Patch: patchOf(5)} }
+	smallCost := estimateTokens(fileEntry(small("a.go")))
+
+	tests := []struct {
+		name          string
+		files         []*codehost.File
+		budget        int
+		wantBatches   []string // file names per batch, space separated
+		wantGenerated []string
+	}{
+		{"empty", nil, 1000, nil, nil},
+		{"one batch", []*codehost.File{small("a.go"), small("b.go")}, 2 * smallCost, []string{"a.go b.go"}, nil},
+		{"split", []*codehost.File{small("a.go"), small("b.go"), small("c.go")}, 2*smallCost - 1, []string{"a.go", "b.go", "c.go"}, nil},
+		{"pairs", []*codehost.File{small("a.go"), small("b.go"), small("c.go")}, 2 * smallCost, []string{"a.go b.go", "c.go"}, nil},
+		{"oversized file gets its own batch", []*codehost.File{small("a.go"), {Filename: "big.go", Patch: patchOf(500)}, small("c.go")}, 2 * smallCost, []string{"a.go", "big.go", "c.go"}, nil},
+		{"tests after source", []*codehost.File{small("a_test.go"), small("a.go")}, 1000, []string{"a.go a_test.go"}, nil},
+		{"generated files are listed", []*codehost.File{small("go.sum"), small("a.go"), small("b_templ.go")}, 1000, []string{"a.go"}, []string{"go.sum", "b_templ.go"}},
+	}
+
+	for _, tt := range tests {
+		batches, generated := planBatches(tt.files, tt.budget)
+		var got []string
+		for _, batch := range batches {
+			var names []string
+			for _, file := range batch {
+				names = append(names, file.Filename)
+			}
+			got = append(got, strings.Join(names, " "))
+		}
+		if strings.Join(got, "|") != strings.Join(tt.wantBatches, "|") || strings.Join(generated, " ") != strings.Join(tt.wantGenerated, " ") {
+			t.Errorf("%s: planBatches() = %q, %q, want %q, %q", tt.name, got, generated, tt.wantBatches, tt.wantGenerated)
+		}
+	}
+}
+
+func TestCountMergeSteps(t *testing.T) {
+	tests := []struct {
+		n, groupSize, want int
+	}{
+		{0, 4, 0},
+		{4, 4, 0},  // fits into the final prompt
+		{5, 4, 2},  // 5 -> 2
+		{16, 4, 4}, // 16 -> 4
+		{17, 4, 7}, // 17 -> 5 -> 2
+		{100, 10, 10},
+		{9, 2, 10}, // 9 -> 5 -> 3 -> 2
+	}
+
+	for _, tt := range tests {
+		if got := countMergeSteps(tt.n, tt.groupSize); got != tt.want {
+			t.Errorf("countMergeSteps(%d, %d) = %d, want %d", tt.n, tt.groupSize, got, tt.want)
+		}
+	}
+}
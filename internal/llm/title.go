@@ -0,0 +1,47 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// maxTitleTokens caps the reply when generating a title
+const maxTitleTokens = 60
+
+// titleInstruction asks for a pull request title; the fake provider keys on it
+const titleInstruction = "Write a title for the pull request described below."
+
+// GenerateTitle writes a one-line pull request title for a generated
+// description, for opening a pull request with it
+func (s *Service) GenerateTitle(ctx context.Context, repository, description string, settings Settings) (string, error) {
+	prompt := fmt.Sprintf(`%s
+
+Use the imperative mood ("Add ...", "Fix ..."), keep it under 72 characters and do not end it with a period. Reply with the title only.
+
+Repository: %s
+
+%s`, titleInstruction, repository, description)
+
+	run := &generationRun{settings: settings}
+	title, err := s.complete(ctx, run, prompt, maxTitleTokens, 0.2)
+	if err != nil {
+		return "", err
+	}
+	return cleanTitle(title), nil
+}
+
+// cleanTitle keeps the first line of the reply without Markdown or quotes
+// the model may have wrapped it in
+func cleanTitle(reply string) string {
+	for _, line := range strings.Split(reply, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimLeft(line, "#* ")
+		line = strings.TrimPrefix(line, "Title:")
+		line = strings.Trim(strings.TrimSpace(line), "\"'`*")
+		if line != "" {
+			return strings.TrimSuffix(line, ".")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,299 @@
+// Package localgit reads a branch of a local git repository into a
+// codehost.ChangeRequest, so a change can be described before it is pushed to
+// any code host. It runs the git command line tool.
+package localgit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
+)
+
+// Host is the host recorded for change requests read from a local repository
+const Host = "local"
+
+var (
+	// ErrOutsideRoots is returned for repositories outside the allowed directories
+	ErrOutsideRoots = errors.New("repository is outside the allowed directories")
+
+	// ErrNotRepository is returned when the path is not inside a git repository
+	ErrNotRepository = errors.New("not a git repository")
+
+	// ErrUnknownRevision is returned when the base or head does not name a commit
+	ErrUnknownRevision = errors.New("unknown revision")
+
+	// ErrNoChanges is returned when head has no commits that are not in base
+	ErrNoChanges = errors.New("no commits between base and head")
+
+	// ErrUnrelatedHistories is returned when base and head share no commit
+	ErrUnrelatedHistories = errors.New("base and head have no common history")
+)
+
+// Request names the branch to describe: the commits reachable from Head but
+// not from Base, and the diff between their merge base and Head
+type Request struct {
+	RepoPath string `json:"repoPath"`
+	Base     string `json:"base"`
+	Head     string `json:"head"`
+}
+
+// Service reads change requests from local repositories
+type Service struct {
+	roots        []string // repositories must live under one of these; empty allows any path
+	maxListItems int
+}
+
+// NewService creates a service for repositories under roots. Without roots
+// any path is accepted, which is only appropriate for the command line.
+func NewService(roots []string) *Service {
+	return &Service{
+		roots:        roots,
+		maxListItems: codehost.MaxListItemsFromEnv(),
+	}
+}
+
+// RootsFromEnv reads LOCAL_GIT_ROOTS, a comma-separated list of directories
+// the web server may read repositories from. Directories that do not exist
+// are skipped with a warning.
+func RootsFromEnv() []string {
+	var roots []string
+	for _, entry := range strings.Split(os.Getenv("LOCAL_GIT_ROOTS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		root, err := canonicalPath(entry)
+		if err != nil {
+			log.Printf("Warning: Invalid LOCAL_GIT_ROOTS entry '%s', skipping it: %v", entry, err)
+			continue
+		}
+		roots = append(roots, root)
+	}
+	return roots
+}
+
+// canonicalPath returns the absolute path with symlinks resolved
+func canonicalPath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(abs)
+}
+
+// allowed reports whether path lies under one of the roots
+func (s *Service) allowed(path string) bool {
+	if len(s.roots) == 0 {
+		return true
+	}
+	for _, root := range s.roots {
+		if rel, err := filepath.Rel(root, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// git runs a git command in dir and returns its standard output
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir, "-c", "core.quotePath=false", "--no-pager"}, args...)...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "LC_ALL=C")
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], message)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
+
+// resolveRepository returns the top-level directory of the repository containing path
+func (s *Service) resolveRepository(ctx context.Context, path string) (string, error) {
+	if strings.TrimSpace(path) == "" {
+		return "", fmt.Errorf("repository path is required")
+	}
+	dir, err := canonicalPath(path)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrNotRepository, path)
+	}
+	if !s.allowed(dir) {
+		return "", fmt.Errorf("%w: %s", ErrOutsideRoots, path)
+	}
+
+	topLevel, err := git(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrNotRepository, path)
+	}
+	topLevel = strings.TrimSpace(topLevel)
+	if !s.allowed(topLevel) {
+		return "", fmt.Errorf("%w: %s", ErrOutsideRoots, topLevel)
+	}
+	return topLevel, nil
+}
+
+// resolveCommit returns the SHA of the commit rev names
+func resolveCommit(ctx context.Context, dir, rev string) (string, error) {
+	// Revisions come from users, so they must not be mistaken for options
+	if rev == "" || strings.HasPrefix(rev, "-") || strings.ContainsAny(rev, " \t\r\n") {
+		return "", fmt.Errorf("%w: %q", ErrUnknownRevision, rev)
+	}
+	sha, err := git(ctx, dir, "rev-parse", "--verify", "--quiet", "--end-of-options", rev+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrUnknownRevision, rev)
+	}
+	return strings.TrimSpace(sha), nil
+}
+
+// FetchChangeRequest computes the change request for the branch: its diff,
+// commits and authors, in the shape code hosts return
+func (s *Service) FetchChangeRequest(ctx context.Context, req Request) (*codehost.ChangeRequest, error) {
+	dir, err := s.resolveRepository(ctx, req.RepoPath)
+	if err != nil {
+		return nil, err
+	}
+	baseSHA, err := resolveCommit(ctx, dir, req.Base)
+	if err != nil {
+		return nil, err
+	}
+	headSHA, err := resolveCommit(ctx, dir, req.Head)
+	if err != nil {
+		return nil, err
+	}
+
+	mergeBase, err := git(ctx, dir, "merge-base", baseSHA, headSHA)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s and %s", ErrUnrelatedHistories, req.Base, req.Head)
+	}
+	mergeBase = strings.TrimSpace(mergeBase)
+
+	var truncatedLists []string
+
+	commits, err := s.fetchCommits(ctx, dir, baseSHA, headSHA)
+	if err != nil {
+		return nil, err
+	}
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("%w: %s already contains %s", ErrNoChanges, req.Base, req.Head)
+	}
+	if len(commits) > s.maxListItems {
+		commits = commits[:s.maxListItems]
+		truncatedLists = append(truncatedLists, "commits")
+	}
+
+	diff, err := git(ctx, dir, "diff", "--no-color", "--no-ext-diff", "--no-textconv", "--find-renames", "--src-prefix=a/", "--dst-prefix=b/", mergeBase, headSHA)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute diff: %w", err)
+	}
+	files := codehost.ParseGitDiff(diff)
+	totalFiles := len(files)
+	additions, deletions := 0, 0
+	for _, file := range files {
+		additions += file.Additions
+		deletions += file.Deletions
+	}
+	if len(files) > s.maxListItems {
+		files = files[:s.maxListItems]
+		truncatedLists = append(truncatedLists, "files")
+	}
+
+	// A single commit says what the branch does; otherwise the branch name has to
+	title := strings.TrimPrefix(req.Head, "refs/heads/")
+	if req.Head == "HEAD" {
+		if branch, err := git(ctx, dir, "symbolic-ref", "--short", "--quiet", "HEAD"); err == nil {
+			title = strings.TrimSpace(branch)
+		}
+	}
+	if len(commits) == 1 {
+		title, _, _ = strings.Cut(commits[0].Message, "\n")
+	}
+
+	first, last := commits[0], commits[len(commits)-1]
+	return &codehost.ChangeRequest{
+		Title:             title,
+		User:              &codehost.User{Name: last.AuthorName},
+		State:             "unpushed",
+		CreatedAt:         commitTime(ctx, dir, first.SHA),
+		UpdatedAt:         commitTime(ctx, dir, last.SHA),
+		ChangedFiles:      files,
+		TotalChangedFiles: totalFiles,
+		Commits:           commits,
+		Additions:         additions,
+		Deletions:         deletions,
+		Host:              Host,
+		Repository:        filepath.Base(dir),
+		HeadSHA:           headSHA,
+		Contributors:      codehost.BuildContributors(commits),
+		Truncated:         len(truncatedLists) > 0,
+		TruncatedLists:    truncatedLists,
+	}, nil
+}
+
+// Separators of the commit log format, which cannot occur in commit metadata
+const (
+	recordSeparator = "\x1e"
+	fieldSeparator  = "\x1f"
+)
+
+// fetchCommits lists the commits reachable from head but not from base,
+// oldest first, with their line counts
+func (s *Service) fetchCommits(ctx context.Context, dir, base, head string) ([]codehost.Commit, error) {
+	format := "--format=" + recordSeparator + strings.Join([]string{"%H", "%an", "%ae", "%B"}, fieldSeparator) + fieldSeparator
+	output, err := git(ctx, dir, "log", "--reverse", "--no-color", "--numstat", format, base+".."+head)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits: %w", err)
+	}
+
+	var commits []codehost.Commit
+	for _, record := range strings.Split(output, recordSeparator) {
+		fields := strings.SplitN(record, fieldSeparator, 5)
+		if len(fields) < 5 {
+			continue
+		}
+		commit := codehost.Commit{
+			SHA:         fields[0],
+			AuthorName:  fields[1],
+			AuthorEmail: strings.ToLower(fields[2]),
+			Message:     strings.TrimSpace(fields[3]),
+			HasStats:    true,
+		}
+		commit.CoAuthors = codehost.ParseCoAuthors(commit.Message)
+
+		// --numstat lines are "<added>\t<deleted>\t<path>", with "-" for binary files
+		for _, line := range strings.Split(fields[4], "\n") {
+			counts := strings.SplitN(line, "\t", 3)
+			if len(counts) < 3 {
+				continue
+			}
+			added, _ := strconv.Atoi(counts[0])
+			deleted, _ := strconv.Atoi(counts[1])
+			commit.Additions += added
+			commit.Deletions += deleted
+		}
+		commits = append(commits, commit)
+	}
+	return commits, nil
+}
+
+// commitTime returns the committer date of the commit, or the zero time
+func commitTime(ctx context.Context, dir, sha string) time.Time {
+	output, err := git(ctx, dir, "show", "--no-patch", "--format=%cI", sha)
+	if err != nil {
+		return time.Time{}
+	}
+	t, _ := time.Parse(time.RFC3339, strings.TrimSpace(output))
+	return t
+}
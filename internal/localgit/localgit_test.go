@@ -0,0 +1,116 @@
+package localgit_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/nahue/pr-toolbox-go/internal/localgit"
+)
+
+// newTestRepository creates a repository with one commit on main and two on
+// the feature branch, which is checked out
+func newTestRepository(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Alice Doe", "GIT_AUTHOR_EMAIL=Alice@example.com",
+			"GIT_COMMITTER_NAME=Alice Doe", "GIT_COMMITTER_EMAIL=alice@example.com",
+			"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1",
+		)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run("init", "--quiet", "--initial-branch=main")
+	write("README.md", "# Service\n")
+	write("main.go", "package main\n\nfunc main() {}\n")
+	run("add", ".")
+	run("commit", "--quiet", "-m", "Initial commit")
+
+	run("checkout", "--quiet", "-b", "feature/greeting")
+	write("main.go", "package main\n\nimport \"fmt\"\n\nfunc main() { fmt.Println(\"hi\") }\n")
+	run("commit", "--quiet", "-am", "Print a greeting")
+	run("mv", "README.md", "GUIDE.md")
+	run("commit", "--quiet", "-m", "Rename the readme\n\nCo-authored-by: Carol <carol@example.com>")
+	return dir
+}
+
+func TestFetchChangeRequest(t *testing.T) {
+	dir := newTestRepository(t)
+	service := localgit.NewService(nil)
+
+	pr, err := service.FetchChangeRequest(context.Background(), localgit.Request{RepoPath: dir, Base: "main", Head: "HEAD"})
+	if err != nil {
+		t.Fatalf("FetchChangeRequest returned error: %v", err)
+	}
+
+	if pr.Title != "feature/greeting" || pr.Host != localgit.Host || pr.Repository != filepath.Base(dir) || len(pr.HeadSHA) != 40 {
+		t.Errorf("unexpected metadata: title %q, host %q, repository %q, head %q", pr.Title, pr.Host, pr.Repository, pr.HeadSHA)
+	}
+
+	if len(pr.Commits) != 2 || pr.Commits[0].Message != "Print a greeting" {
+		t.Fatalf("unexpected commits: %+v", pr.Commits)
+	}
+	if commit := pr.Commits[0]; commit.AuthorEmail != "alice@example.com" || commit.Additions != 3 || commit.Deletions != 1 {
+		t.Errorf("unexpected first commit: %+v", commit)
+	}
+	if len(pr.Contributors) != 2 {
+		t.Errorf("got %d contributors, want Alice and Carol", len(pr.Contributors))
+	}
+
+	if len(pr.ChangedFiles) != 2 || pr.TotalChangedFiles != 2 {
+		t.Fatalf("unexpected files: %+v", pr.ChangedFiles)
+	}
+	byName := map[string]string{}
+	for _, file := range pr.ChangedFiles {
+		byName[file.Filename] = file.Status
+	}
+	if byName["main.go"] != "modified" || byName["GUIDE.md"] != "renamed" {
+		t.Errorf("unexpected file statuses: %v", byName)
+	}
+	if pr.Additions != 3 || pr.Deletions != 1 {
+		t.Errorf("line counts = +%d -%d, want +3 -1", pr.Additions, pr.Deletions)
+	}
+}
+
+func TestFetchChangeRequestErrors(t *testing.T) {
+	dir := newTestRepository(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		service *localgit.Service
+		req     localgit.Request
+		want    error
+	}{
+		{"outside roots", localgit.NewService([]string{t.TempDir()}), localgit.Request{RepoPath: dir, Base: "main", Head: "HEAD"}, localgit.ErrOutsideRoots},
+		{"not a repository", localgit.NewService(nil), localgit.Request{RepoPath: t.TempDir(), Base: "main", Head: "HEAD"}, localgit.ErrNotRepository},
+		{"unknown revision", localgit.NewService(nil), localgit.Request{RepoPath: dir, Base: "develop", Head: "HEAD"}, localgit.ErrUnknownRevision},
+		{"option as revision", localgit.NewService(nil), localgit.Request{RepoPath: dir, Base: "--output=/tmp/x", Head: "HEAD"}, localgit.ErrUnknownRevision},
+		{"no changes", localgit.NewService(nil), localgit.Request{RepoPath: dir, Base: "HEAD", Head: "main"}, localgit.ErrNoChanges},
+	}
+	for _, tt := range tests {
+		if _, err := tt.service.FetchChangeRequest(ctx, tt.req); !errors.Is(err, tt.want) {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
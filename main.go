@@ -5,9 +5,12 @@ import (
 
 	"github.com/joho/godotenv"
 	"github.com/nahue/pr-toolbox-go/internal/app"
+	"github.com/nahue/pr-toolbox-go/internal/codehost"
 	"github.com/nahue/pr-toolbox-go/internal/database"
+	"github.com/nahue/pr-toolbox-go/internal/gitea"
 	"github.com/nahue/pr-toolbox-go/internal/github"
-	"github.com/nahue/pr-toolbox-go/internal/openai"
+	"github.com/nahue/pr-toolbox-go/internal/gitlab"
+	"github.com/nahue/pr-toolbox-go/internal/llm"
 )
 
 func main() {
@@ -23,17 +26,19 @@ func main() {
 	}
 	defer db.Close()
 
-	// Initialize OpenAI service
-	openaiService, err := openai.NewService()
+	// Initialize LLM provider (selected by LLM_PROVIDER)
+	provider, err := llm.NewProviderFromEnv()
 	if err != nil {
-		log.Fatalf("Failed to initialize OpenAI service: %v", err)
+		log.Fatalf("Failed to initialize LLM provider: %v", err)
 	}
+	log.Printf("Using LLM provider %s (model %s)", provider.Name(), provider.DefaultModel())
+	llmService := llm.NewService(provider)
 
-	// Initialize GitHub service
-	githubService := github.NewService()
+	// Initialize the code hosts, routed by the host of each pull request URL
+	codeHosts := codehost.NewRegistry(github.NewService(), gitlab.NewService(), gitea.NewService())
 
 	// Create application with all dependencies
-	application := app.NewApplication(db, openaiService, githubService)
+	application := app.NewApplication(db, llmService, codeHosts)
 
 	// Start server
 	log.Fatal(application.Start("9090"))
@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE generation_settings (
+    id TEXT PRIMARY KEY,
+    scope TEXT NOT NULL CHECK (scope IN ('global', 'repository', 'user')),
+    scope_key TEXT NOT NULL DEFAULT '',
+    model TEXT,
+    temperature REAL,
+    max_tokens INTEGER,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (scope, scope_key)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS generation_settings;
@@ -0,0 +1,26 @@
+-- +goose Up
+CREATE TABLE pr_descriptions (
+    id TEXT PRIMARY KEY,
+    user_id TEXT, -- no foreign key: generations made without a signed-in user are kept too
+    repository TEXT NOT NULL,
+    pr_number INTEGER NOT NULL,
+    head_sha TEXT NOT NULL DEFAULT '',
+    provider TEXT NOT NULL,
+    model TEXT NOT NULL,
+    temperature REAL NOT NULL,
+    max_tokens INTEGER NOT NULL,
+    prompt_version TEXT NOT NULL,
+    description TEXT NOT NULL,
+    prompt_tokens INTEGER NOT NULL DEFAULT 0,
+    completion_tokens INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_pr_descriptions_repository ON pr_descriptions(repository, pr_number);
+CREATE INDEX idx_pr_descriptions_created_at ON pr_descriptions(created_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_pr_descriptions_created_at;
+DROP INDEX IF EXISTS idx_pr_descriptions_repository;
+DROP TABLE IF EXISTS pr_descriptions;
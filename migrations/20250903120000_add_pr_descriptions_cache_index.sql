@@ -0,0 +1,5 @@
+-- +goose Up
+CREATE INDEX idx_pr_descriptions_cache ON pr_descriptions(repository COLLATE NOCASE, pr_number, head_sha);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_pr_descriptions_cache;
@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE pr_description_applications (
+    id TEXT PRIMARY KEY,
+    pr_description_id TEXT NOT NULL,
+    user_id TEXT,
+    repository TEXT NOT NULL,
+    pr_number INTEGER NOT NULL,
+    mode TEXT NOT NULL CHECK (mode IN ('replace', 'merge')),
+    previous_body TEXT NOT NULL,
+    new_body TEXT NOT NULL,
+    applied_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (pr_description_id) REFERENCES pr_descriptions(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_pr_description_applications_description ON pr_description_applications(pr_description_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_pr_description_applications_description;
+DROP TABLE IF EXISTS pr_description_applications;
@@ -0,0 +1,24 @@
+-- +goose Up
+CREATE TABLE webhook_deliveries (
+    delivery_id TEXT PRIMARY KEY,
+    event TEXT NOT NULL,
+    received_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    -- Deliveries are only settled once processed, so failed ones can be redelivered
+    status TEXT NOT NULL DEFAULT 'done' CHECK (status IN ('processing', 'done', 'failed'))
+);
+
+-- Repositories with the same name on different GitHub hosts are opted in separately
+CREATE TABLE webhook_settings (
+    host TEXT NOT NULL DEFAULT 'github.com',
+    repository TEXT NOT NULL,
+    mode TEXT NOT NULL CHECK (mode IN ('comment', 'body', 'store')),
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (host, repository)
+);
+
+CREATE INDEX idx_webhook_deliveries_received_at ON webhook_deliveries(received_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_webhook_deliveries_received_at;
+DROP TABLE IF EXISTS webhook_settings;
+DROP TABLE IF EXISTS webhook_deliveries;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- A user can connect one account on each GitHub host
+CREATE TABLE github_connections (
+    user_id TEXT NOT NULL,
+    host TEXT NOT NULL DEFAULT 'github.com',
+    login TEXT NOT NULL,
+    encrypted_token TEXT NOT NULL,
+    scopes TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, host)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS github_connections;
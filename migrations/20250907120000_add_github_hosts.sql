@@ -0,0 +1,8 @@
+-- +goose Up
+-- Pull requests can live on github.com or on a GitHub Enterprise Server
+ALTER TABLE pr_descriptions ADD COLUMN host TEXT NOT NULL DEFAULT 'github.com';
+ALTER TABLE pr_description_applications ADD COLUMN host TEXT NOT NULL DEFAULT 'github.com';
+
+-- +goose Down
+ALTER TABLE pr_description_applications DROP COLUMN host;
+ALTER TABLE pr_descriptions DROP COLUMN host;
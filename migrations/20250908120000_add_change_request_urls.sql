@@ -0,0 +1,9 @@
+-- +goose Up
+-- Web addresses of the pull or merge request, whose shape differs between code hosts.
+-- Rows stored before this migration are GitHub pull requests.
+ALTER TABLE pr_descriptions ADD COLUMN url TEXT NOT NULL DEFAULT '';
+ALTER TABLE pr_description_applications ADD COLUMN url TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE pr_description_applications DROP COLUMN url;
+ALTER TABLE pr_descriptions DROP COLUMN url;
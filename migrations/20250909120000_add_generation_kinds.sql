@@ -0,0 +1,7 @@
+-- +goose Up
+-- What a stored generation is: a pull request description or release notes.
+-- Rows stored before this migration are descriptions.
+ALTER TABLE pr_descriptions ADD COLUMN kind TEXT NOT NULL DEFAULT 'description';
+
+-- +goose Down
+ALTER TABLE pr_descriptions DROP COLUMN kind;
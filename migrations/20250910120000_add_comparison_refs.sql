@@ -0,0 +1,9 @@
+-- +goose Up
+-- The refs a branch comparison or release notes were generated from, so they
+-- need not be parsed back out of the URL. Empty for pull requests.
+ALTER TABLE pr_descriptions ADD COLUMN base_ref TEXT NOT NULL DEFAULT '';
+ALTER TABLE pr_descriptions ADD COLUMN head_ref TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE pr_descriptions DROP COLUMN head_ref;
+ALTER TABLE pr_descriptions DROP COLUMN base_ref;
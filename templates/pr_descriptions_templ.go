@@ -8,7 +8,9 @@ package templates
 import "github.com/a-h/templ"
 import templruntime "github.com/a-h/templ/runtime"
 
-func PrDescriptions() templ.Component {
+// PrDescriptions renders the generation form. localGit adds the form for
+// describing a branch of a repository on the server.
+func PrDescriptions(localGit bool) templ.Component {
 	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
 		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
 		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
@@ -31,8 +33,8 @@ func PrDescriptions() templ.Component {
 		ctx = templ.ClearChildren(ctx)
 		templ_7745c5c3_Err = BaseLayout(PageData{
 			Title:       "PR Descriptions",
-			Description: "Generate descriptions for your GitHub pull requests",
-			Content:     PrDescriptionsContent(),
+			Description: "Generate descriptions for your pull requests and merge requests",
+			Content:     PrDescriptionsContent(localGit),
 		}).Render(ctx, templ_7745c5c3_Buffer)
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
@@ -41,7 +43,7 @@ func PrDescriptions() templ.Component {
 	})
 }
 
-func PrDescriptionsContent() templ.Component {
+func PrDescriptionsContent(localGit bool) templ.Component {
 	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
 		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
 		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
@@ -62,7 +64,83 @@ func PrDescriptionsContent() templ.Component {
 			templ_7745c5c3_Var2 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<div class=\"space-y-6\" x-data=\"{ prUrl: '', isLoading: false, error: null, result: null }\"><!-- Main Form Card --><div class=\"bg-white shadow rounded-lg\"><div class=\"px-4 py-5 sm:p-6\"><h3 class=\"text-lg leading-6 font-medium text-gray-900 mb-4\">Generate PR Description</h3><p class=\"text-sm text-gray-500 mb-6\">Enter a GitHub pull request URL to generate a professional description using AI.</p><!-- Form Section --><form x-target=\"pr-result\" method=\"POST\" action=\"/api/generate-pr-description\" class=\"space-y-4\" @submit=\"\n\t\t\t\t\t\tconst url = prUrl.trim();\n\t\t\t\t\t\tif (!url) {\n\t\t\t\t\t\t\terror = 'Please enter a valid GitHub pull request URL';\n\t\t\t\t\t\t\t$event.preventDefault();\n\t\t\t\t\t\t\treturn;\n\t\t\t\t\t\t}\n\t\t\t\t\t\tconst githubPrRegex = /^https:\\/\\/github\\.com\\/[^\\/]+\\/[^\\/]+\\/pull\\/\\d+$/;\n\t\t\t\t\t\tif (!githubPrRegex.test(url)) {\n\t\t\t\t\t\t\terror = 'Please enter a valid GitHub pull request URL (e.g., https://github.com/owner/repo/pull/123)';\n\t\t\t\t\t\t\t$event.preventDefault();\n\t\t\t\t\t\t\treturn;\n\t\t\t\t\t\t}\n\t\t\t\t\t\tisLoading = true;\n\t\t\t\t\t\terror = null;\n\t\t\t\t\t\tresult = null;\n\t\t\t\t\t\" @ajax:success=\"isLoading = false\" @ajax:error=\"isLoading = false; error = 'Failed to generate description. Please try again.'\" @ajax:sent=\"isLoading = false\"><div><label for=\"pr-url\" class=\"block text-sm font-medium text-gray-700 mb-2\">GitHub Pull Request URL</label> <input type=\"url\" id=\"pr-url\" name=\"prUrl\" x-model=\"prUrl\" placeholder=\"https://github.com/owner/repo/pull/123\" class=\"w-full px-4 py-3 border border-gray-300 rounded-lg focus:ring-2 focus:ring-indigo-500 focus:border-indigo-500 transition-colors\" required><p class=\"mt-1 text-sm text-gray-500\">Enter the full URL of your GitHub pull request</p></div><div class=\"flex gap-4\"><button type=\"submit\" :disabled=\"isLoading || !prUrl.trim()\" class=\"inline-flex items-center px-4 py-2 border border-transparent text-sm font-medium rounded-md shadow-sm text-white bg-indigo-600 hover:bg-indigo-700 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-indigo-500 disabled:opacity-50 disabled:cursor-not-allowed\"><svg x-show=\"isLoading\" class=\"animate-spin -ml-1 mr-2 h-4 w-4\" xmlns=\"http://www.w3.org/2000/svg\" fill=\"none\" viewBox=\"0 0 24 24\"><circle class=\"opacity-25\" cx=\"12\" cy=\"12\" r=\"10\" stroke=\"currentColor\" stroke-width=\"4\"></circle> <path class=\"opacity-75\" fill=\"currentColor\" d=\"M4 12a8 8 0 018-8V0C5.373 0 0 5.373 0 12h4zm2 5.291A7.962 7.962 0 014 12H0c0 3.042 1.135 5.824 3 7.938l3-2.647z\"></path></svg> <span x-show=\"!isLoading\">Generate Description</span> <span x-show=\"isLoading\">Generating...</span></button> <button type=\"button\" @click=\"prUrl = ''; result = null; error = null; document.getElementById('pr-result').innerHTML = ''\" class=\"inline-flex items-center px-4 py-2 border border-gray-300 text-sm font-medium rounded-md text-gray-700 bg-white hover:bg-gray-50 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-indigo-500\">Clear</button></div></form></div></div><!-- Error Display --><div x-show=\"error\" class=\"bg-red-50 border border-red-200 rounded-lg p-4\"><div class=\"flex items-center\"><svg class=\"w-5 h-5 text-red-400 mr-2\" fill=\"currentColor\" viewBox=\"0 0 20 20\"><path fill-rule=\"evenodd\" d=\"M10 18a8 8 0 100-16 8 8 0 000 16zM8.707 7.293a1 1 0 00-1.414 1.414L8.586 10l-1.293 1.293a1 1 0 101.414 1.414L10 11.414l1.293 1.293a1 1 0 001.414-1.414L11.414 10l1.293-1.293a1 1 0 00-1.414-1.414L10 8.586 8.707 7.293z\" clip-rule=\"evenodd\"></path></svg> <span x-text=\"error\" class=\"text-red-700\"></span></div></div><!-- Result Display --><div id=\"pr-result\" class=\"space-y-6\"><!-- PR description result will be loaded here via Alpine AJAX --></div><!-- Instructions Card --><div class=\"bg-white shadow rounded-lg\"><div class=\"px-4 py-5 sm:p-6\"><h3 class=\"text-lg leading-6 font-medium text-gray-900 mb-4\">How to use</h3><ol class=\"list-decimal list-inside space-y-2 text-gray-600\"><li>Copy the URL of your GitHub pull request</li><li>Paste it into the input field above</li><li>Click \"Generate Description\" to create a description</li><li>Copy the generated description to use in your PR</li></ol></div></div></div>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<script>\n\t\t// Streams the description over Server-Sent Events when the browser supports\n\t\t// EventSource, falling back to a regular Alpine AJAX submission otherwise.\n\t\t// Closing the event source cancels the generation on the server.\n\t\t// References are resolved by the server as they are typed, so the form\n\t\t// accepts exactly what generation does.\n\t\tfunction prDescriptionForm() {\n\t\t\treturn {\n\t\t\t\tprUrl: '',\n\t\t\t\tref: null,\n\t\t\t\trefError: null,\n\t\t\t\tforceRegenerate: false,\n\t\t\t\tisLoading: false,\n\t\t\t\terror: null,\n\t\t\t\tstreamed: '',\n\t\t\t\tprogress: { done: 0, total: 0 },\n\t\t\t\tsource: null,\n\n\t\t\t\tasync resolve() {\n\t\t\t\t\tconst value = this.prUrl.trim();\n\t\t\t\t\tthis.ref = null;\n\t\t\t\t\tthis.refError = null;\n\t\t\t\t\tif (!value) {\n\t\t\t\t\t\treturn;\n\t\t\t\t\t}\n\t\t\t\t\tconst response = await fetch('/api/pr-ref?' + new URLSearchParams({ ref: value }));\n\t\t\t\t\tif (this.prUrl.trim() !== value) {\n\t\t\t\t\t\treturn;\n\t\t\t\t\t}\n\t\t\t\t\tif (response.ok) {\n\t\t\t\t\t\tthis.ref = await response.json();\n\t\t\t\t\t} else {\n\t\t\t\t\t\tthis.refError = (await response.text()).trim();\n\t\t\t\t\t}\n\t\t\t\t},\n\n\t\t\t\tsubmit(event) {\n\t\t\t\t\tconst url = this.prUrl.trim();\n\t\t\t\t\tif (!url) {\n\t\t\t\t\t\tthis.error = 'Please enter a pull request URL or reference';\n\t\t\t\t\t\tevent.preventDefault();\n\t\t\t\t\t\treturn;\n\t\t\t\t\t}\n\t\t\t\t\tif (this.refError) {\n\t\t\t\t\t\tthis.error = 'Please enter a valid pull request reference: ' + this.refError;\n\t\t\t\t\t\tevent.preventDefault();\n\t\t\t\t\t\treturn;\n\t\t\t\t\t}\n\t\t\t\t\tthis.isLoading = true;\n\t\t\t\t\tthis.error = null;\n\t\t\t\t\tthis.streamed = '';\n\t\t\t\t\tthis.progress = { done: 0, total: 0 };\n\t\t\t\t\tif (!window.EventSource) {\n\t\t\t\t\t\treturn;\n\t\t\t\t\t}\n\n\t\t\t\t\tevent.preventDefault();\n\t\t\t\t\tevent.stopImmediatePropagation();\n\t\t\t\t\tdocument.getElementById('pr-result').innerHTML = '';\n\t\t\t\t\tconst params = new URLSearchParams({ prUrl: url, regenerate: this.forceRegenerate });\n\t\t\t\t\tconst source = new EventSource('/api/generate-pr-description/stream?' + params);\n\t\t\t\t\tthis.source = source;\n\t\t\t\t\tsource.addEventListener('progress', (e) => {\n\t\t\t\t\t\tthis.progress = JSON.parse(e.data);\n\t\t\t\t\t});\n\t\t\t\t\tsource.addEventListener('token', (e) => {\n\t\t\t\t\t\tthis.streamed += JSON.parse(e.data).text;\n\t\t\t\t\t});\n\t\t\t\t\tsource.addEventListener('done', (e) => {\n\t\t\t\t\t\tdocument.getElementById('pr-result').innerHTML = JSON.parse(e.data).html;\n\t\t\t\t\t\tthis.finish();\n\t\t\t\t\t});\n\t\t\t\t\tsource.addEventListener('error', (e) => {\n\t\t\t\t\t\tthis.error = e.data ? JSON.parse(e.data).message : 'The connection to the server was lost. Please try again.';\n\t\t\t\t\t\tthis.finish();\n\t\t\t\t\t});\n\t\t\t\t},\n\n\t\t\t\tregenerate() {\n\t\t\t\t\tthis.forceRegenerate = true;\n\t\t\t\t\tthis.$nextTick(() => this.$refs.form.requestSubmit());\n\t\t\t\t},\n\n\t\t\t\tcancel() {\n\t\t\t\t\tthis.finish();\n\t\t\t\t\tthis.error = 'Generation cancelled.';\n\t\t\t\t},\n\n\t\t\t\tfinish() {\n\t\t\t\t\tif (this.source) {\n\t\t\t\t\t\tthis.source.close();\n\t\t\t\t\t\tthis.source = null;\n\t\t\t\t\t}\n\t\t\t\t\tthis.isLoading = false;\n\t\t\t\t\tthis.streamed = '';\n\t\t\t\t},\n\n\t\t\t\tclear() {\n\t\t\t\t\tthis.finish();\n\t\t\t\t\tthis.prUrl = '';\n\t\t\t\t\tthis.ref = null;\n\t\t\t\t\tthis.refError = null;\n\t\t\t\t\tthis.error = null;\n\t\t\t\t\tdocument.getElementById('pr-result').innerHTML = '';\n\t\t\t\t},\n\t\t\t};\n\t\t}\n\t</script><div class=\"space-y-6\" x-data=\"prDescriptionForm()\"><!-- Main Form Card --><div class=\"bg-white shadow rounded-lg\"><div class=\"px-4 py-5 sm:p-6\"><h3 class=\"text-lg leading-6 font-medium text-gray-900 mb-4\">Generate PR Description</h3><p class=\"text-sm text-gray-500 mb-6\">Enter a GitHub, Gitea or Forgejo pull request or GitLab merge request URL or reference to generate a professional description using AI.</p><!-- Form Section --><form x-ref=\"form\" x-target=\"pr-result\" x-target.error=\"pr-result\" method=\"POST\" action=\"/api/generate-pr-description\" class=\"space-y-4\" @submit=\"submit($event)\" @ajax:success=\"isLoading = false\" @ajax:error=\"isLoading = false\"><div><label for=\"pr-url\" class=\"block text-sm font-medium text-gray-700 mb-2\">Pull Request</label> <input type=\"text\" id=\"pr-url\" name=\"prUrl\" x-model=\"prUrl\" @input.debounce.300ms=\"resolve()\" placeholder=\"https://github.com/owner/repo/pull/123\" class=\"w-full px-4 py-3 border border-gray-300 rounded-lg focus:ring-2 focus:ring-indigo-500 focus:border-indigo-500 transition-colors\" required><p class=\"mt-1 text-sm text-gray-500\" x-show=\"!ref && !refError\">A pull request or merge request URL (any tab), <code>owner/repo#123</code>, <code>group/project!123</code>, or <code>gh pr view 123 -R owner/repo</code></p><p class=\"mt-1 text-sm text-gray-500\" x-show=\"ref\"><span x-text=\"ref?.provider\"></span> <span x-text=\"ref?.provider === 'GitLab' ? 'merge request' : 'pull request'\"></span> <a :href=\"ref?.url\" target=\"_blank\" rel=\"noopener\" class=\"font-medium text-indigo-600 hover:text-indigo-800\" x-text=\"ref?.name\"></a></p><p class=\"mt-1 text-sm text-red-600\" x-show=\"refError\" x-text=\"refError\"></p></div><label class=\"flex items-center gap-2 text-sm text-gray-600\"><input type=\"checkbox\" name=\"regenerate\" value=\"true\" x-model=\"forceRegenerate\" class=\"rounded border-gray-300 text-indigo-600 focus:ring-indigo-500\"> Regenerate even if a description for the current commit is cached</label><div class=\"flex gap-4\"><button type=\"submit\" :disabled=\"isLoading || !prUrl.trim()\" class=\"inline-flex items-center px-4 py-2 border border-transparent text-sm font-medium rounded-md shadow-sm text-white bg-indigo-600 hover:bg-indigo-700 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-indigo-500 disabled:opacity-50 disabled:cursor-not-allowed\"><svg x-show=\"isLoading\" class=\"animate-spin -ml-1 mr-2 h-4 w-4\" xmlns=\"http://www.w3.org/2000/svg\" fill=\"none\" viewBox=\"0 0 24 24\"><circle class=\"opacity-25\" cx=\"12\" cy=\"12\" r=\"10\" stroke=\"currentColor\" stroke-width=\"4\"></circle> <path class=\"opacity-75\" fill=\"currentColor\" d=\"M4 12a8 8 0 018-8V0C5.373 0 0 5.373 0 12h4zm2 5.291A7.962 7.962 0 014 12H0c0 3.042 1.135 5.824 3 7.938l3-2.647z\"></path></svg> <span x-show=\"!isLoading\">Generate Description</span> <span x-show=\"isLoading\" x-text=\"progress.total ? `Generating... (stage ${progress.done} of ${progress.total})` : 'Generating...'\"></span></button> <button type=\"button\" x-show=\"source\" @click=\"cancel()\" class=\"inline-flex items-center px-4 py-2 border border-gray-300 text-sm font-medium rounded-md text-gray-700 bg-white hover:bg-gray-50 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-indigo-500\">Cancel</button> <button type=\"button\" @click=\"clear()\" class=\"inline-flex items-center px-4 py-2 border border-gray-300 text-sm font-medium rounded-md text-gray-700 bg-white hover:bg-gray-50 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-indigo-500\">Clear</button></div></form></div></div>")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = CompareForm().Render(ctx, templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		if localGit {
+			templ_7745c5c3_Err = LocalBranchForm().Render(ctx, templ_7745c5c3_Buffer)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "<!-- Error Display --><div x-show=\"error\" class=\"bg-red-50 border border-red-200 rounded-lg p-4\"><div class=\"flex items-center\"><svg class=\"w-5 h-5 text-red-400 mr-2\" fill=\"currentColor\" viewBox=\"0 0 20 20\"><path fill-rule=\"evenodd\" d=\"M10 18a8 8 0 100-16 8 8 0 000 16zM8.707 7.293a1 1 0 00-1.414 1.414L8.586 10l-1.293 1.293a1 1 0 101.414 1.414L10 11.414l1.293 1.293a1 1 0 001.414-1.414L11.414 10l1.293-1.293a1 1 0 00-1.414-1.414L10 8.586 8.707 7.293z\" clip-rule=\"evenodd\"></path></svg> <span x-text=\"error\" class=\"text-red-700\"></span></div></div><!-- Streaming Preview --><div x-show=\"streamed\" class=\"bg-white shadow rounded-lg\"><div class=\"px-4 py-5 sm:p-6\"><h3 class=\"text-lg leading-6 font-medium text-gray-900 mb-4\">Writing description...</h3><pre class=\"whitespace-pre-wrap text-sm text-gray-800 font-sans\" x-text=\"streamed\"></pre></div></div><!-- Result Display --><div id=\"pr-result\" class=\"space-y-6\"><!-- PR description result will be loaded here via Alpine AJAX --></div><!-- Instructions Card --><div class=\"bg-white shadow rounded-lg\"><div class=\"px-4 py-5 sm:p-6\"><h3 class=\"text-lg leading-6 font-medium text-gray-900 mb-4\">How to use</h3><ol class=\"list-decimal list-inside space-y-2 text-gray-600\"><li>Copy the URL of your pull request or merge request</li><li>Paste it into the input field above</li><li>Click \"Generate Description\" to create a description</li><li>Copy the generated description to use in your PR</li></ol></div></div></div>")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		return nil
+	})
+}
+
+// CompareForm describes the changes between two branches of a GitHub
+// repository, before a pull request is opened for them
+func CompareForm() templ.Component {
+	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
+			return templ_7745c5c3_CtxErr
+		}
+		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+		if !templ_7745c5c3_IsBuffer {
+			defer func() {
+				templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err == nil {
+					templ_7745c5c3_Err = templ_7745c5c3_BufErr
+				}
+			}()
+		}
+		ctx = templ.InitializeContext(ctx)
+		templ_7745c5c3_Var3 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var3 == nil {
+			templ_7745c5c3_Var3 = templ.NopComponent
+		}
+		ctx = templ.ClearChildren(ctx)
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 3, "<div class=\"bg-white shadow rounded-lg\" x-data=\"{ isLoading: false }\"><div class=\"px-4 py-5 sm:p-6\"><h3 class=\"text-lg leading-6 font-medium text-gray-900 mb-4\">Describe a Branch Comparison</h3><p class=\"text-sm text-gray-500 mb-6\">Describe a branch before opening its pull request, then open a draft pull request with the description. Enter a GitHub compare URL, or the repository and branches.</p><form x-target=\"pr-result\" x-target.error=\"pr-result\" method=\"POST\" action=\"/api/generate-compare-description\" class=\"grid grid-cols-1 gap-4 sm:grid-cols-3 items-end\" @submit=\"isLoading = true; error = null\" @ajax:success=\"isLoading = false\" @ajax:error=\"isLoading = false\"><div class=\"sm:col-span-3\"><label for=\"compare-url\" class=\"block text-sm font-medium text-gray-700 mb-2\">Compare URL</label> <input type=\"text\" id=\"compare-url\" name=\"compareUrl\" placeholder=\"https://github.com/owner/repo/compare/main...feature\" class=\"w-full px-4 py-3 border border-gray-300 rounded-lg focus:ring-2 focus:ring-indigo-500 focus:border-indigo-500 transition-colors\"></div><div><label for=\"compare-repository\" class=\"block text-sm font-medium text-gray-700 mb-2\">or Repository</label> <input type=\"text\" id=\"compare-repository\" name=\"repository\" placeholder=\"owner/repo\" class=\"w-full px-4 py-3 border border-gray-300 rounded-lg focus:ring-2 focus:ring-indigo-500 focus:border-indigo-500 transition-colors\"></div><div><label for=\"compare-base\" class=\"block text-sm font-medium text-gray-700 mb-2\">Base</label> <input type=\"text\" id=\"compare-base\" name=\"base\" value=\"main\" class=\"w-full px-4 py-3 border border-gray-300 rounded-lg focus:ring-2 focus:ring-indigo-500 focus:border-indigo-500 transition-colors\"></div><div><label for=\"compare-head\" class=\"block text-sm font-medium text-gray-700 mb-2\">Head</label> <input type=\"text\" id=\"compare-head\" name=\"head\" placeholder=\"feature/login\" class=\"w-full px-4 py-3 border border-gray-300 rounded-lg focus:ring-2 focus:ring-indigo-500 focus:border-indigo-500 transition-colors\"></div><div><button type=\"submit\" :disabled=\"isLoading\" class=\"inline-flex items-center px-4 py-3 border border-transparent text-sm font-medium rounded-md shadow-sm text-white bg-indigo-600 hover:bg-indigo-700 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-indigo-500 disabled:opacity-50 disabled:cursor-not-allowed\"><span x-show=\"!isLoading\">Describe Comparison</span> <span x-show=\"isLoading\">Generating...</span></button></div></form></div></div>")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		return nil
+	})
+}
+
+// LocalBranchForm describes a branch of a repository on the server's disk,
+// before it has been pushed to any code host
+func LocalBranchForm() templ.Component {
+	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
+			return templ_7745c5c3_CtxErr
+		}
+		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+		if !templ_7745c5c3_IsBuffer {
+			defer func() {
+				templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err == nil {
+					templ_7745c5c3_Err = templ_7745c5c3_BufErr
+				}
+			}()
+		}
+		ctx = templ.InitializeContext(ctx)
+		templ_7745c5c3_Var4 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var4 == nil {
+			templ_7745c5c3_Var4 = templ.NopComponent
+		}
+		ctx = templ.ClearChildren(ctx)
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 4, "<div class=\"bg-white shadow rounded-lg\" x-data=\"{ isLoading: false }\"><div class=\"px-4 py-5 sm:p-6\"><h3 class=\"text-lg leading-6 font-medium text-gray-900 mb-4\">Describe a Local Branch</h3><p class=\"text-sm text-gray-500 mb-6\">Describe the commits of a branch in a repository on this server that have not been pushed yet.</p><form x-target=\"pr-result\" x-target.error=\"pr-result\" method=\"POST\" action=\"/api/generate-local-description\" class=\"grid grid-cols-1 gap-4 sm:grid-cols-3 items-end\" @submit=\"isLoading = true; error = null\" @ajax:success=\"isLoading = false\" @ajax:error=\"isLoading = false\"><div class=\"sm:col-span-3\"><label for=\"local-repo-path\" class=\"block text-sm font-medium text-gray-700 mb-2\">Repository path</label> <input type=\"text\" id=\"local-repo-path\" name=\"repoPath\" placeholder=\"/srv/repos/service\" required class=\"w-full px-4 py-3 border border-gray-300 rounded-lg focus:ring-2 focus:ring-indigo-500 focus:border-indigo-500 transition-colors\"></div><div><label for=\"local-base\" class=\"block text-sm font-medium text-gray-700 mb-2\">Base</label> <input type=\"text\" id=\"local-base\" name=\"base\" value=\"main\" required class=\"w-full px-4 py-3 border border-gray-300 rounded-lg focus:ring-2 focus:ring-indigo-500 focus:border-indigo-500 transition-colors\"></div><div><label for=\"local-head\" class=\"block text-sm font-medium text-gray-700 mb-2\">Head</label> <input type=\"text\" id=\"local-head\" name=\"head\" value=\"HEAD\" required class=\"w-full px-4 py-3 border border-gray-300 rounded-lg focus:ring-2 focus:ring-indigo-500 focus:border-indigo-500 transition-colors\"></div><div><button type=\"submit\" :disabled=\"isLoading\" class=\"inline-flex items-center px-4 py-3 border border-transparent text-sm font-medium rounded-md shadow-sm text-white bg-indigo-600 hover:bg-indigo-700 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-indigo-500 disabled:opacity-50 disabled:cursor-not-allowed\"><span x-show=\"!isLoading\">Describe Branch</span> <span x-show=\"isLoading\">Generating...</span></button></div></form></div></div>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}